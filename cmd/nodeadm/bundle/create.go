@@ -0,0 +1,117 @@
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/integrii/flaggy"
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/bundle"
+	"github.com/aws/eks-hybrid/internal/cli"
+	"github.com/aws/eks-hybrid/internal/creds"
+	"github.com/aws/eks-hybrid/internal/logger"
+	"github.com/aws/eks-hybrid/internal/ssm"
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+type createCmd struct {
+	flaggy                           *flaggy.Subcommand
+	kubernetesVersion                string
+	arches                           []string
+	region                           string
+	output                           string
+	manifestOverride                 string
+	manifestMirrors                  []string
+	manifestKeys                     []string
+	insecureSkipManifestVerification bool
+	skipSSM                          bool
+	timeout                          time.Duration
+}
+
+func NewCreateCommand() cli.Command {
+	cmd := createCmd{
+		region:  ssm.DefaultSsmInstallerRegion,
+		timeout: 30 * time.Minute,
+	}
+	cmd.flaggy = flaggy.NewSubcommand("create")
+	cmd.flaggy.Description = "Create a bundle with everything needed to install nodes without network access"
+	cmd.flaggy.AddPositionalValue(&cmd.kubernetesVersion, "KUBERNETES_VERSION", 1, true, "The major[.minor[.patch]] version of Kubernetes to bundle.")
+	cmd.flaggy.StringSlice(&cmd.arches, "a", "arch", "Architecture to bundle artifacts for. Can be repeated. Defaults to the architecture of this host.")
+	cmd.flaggy.String(&cmd.region, "r", "region", "AWS region of the SSM installer endpoint. Hosts must install from the bundle with the same region.")
+	cmd.flaggy.String(&cmd.output, "o", "output", "Path of the bundle to create. Defaults to nodeadm-bundle-<kubernetes version>.tar in the current directory.")
+	cmd.flaggy.String(&cmd.manifestOverride, "m", "manifest-override", "URI to a manifest file containing custom artifact URLs. Supports file:// for local files, https:// for remote files and s3:// for S3 objects read with the node credentials. Can be repeated to list mirrors tried in order.")
+	cmd.flaggy.StringSlice(&cmd.manifestKeys, "", "manifest-key", "Path to an armored OpenPGP public key trusted to sign the release manifest, in addition to the keys in "+aws.TrustedKeysDir+". Can be repeated.")
+	cmd.flaggy.Bool(&cmd.insecureSkipManifestVerification, "", "insecure-skip-manifest-verification", "Accept release manifests without verifying their signature.")
+	cmd.flaggy.Bool(&cmd.skipSSM, "", "skip-ssm", "Leave the SSM installer out of the bundle, for hosts that use IAM Roles Anywhere.")
+	cmd.flaggy.Duration(&cmd.timeout, "t", "timeout", "Maximum bundle create duration. Input follows duration format. Example: 1h23s")
+	return &cmd
+}
+
+func (c *createCmd) Flaggy() *flaggy.Subcommand {
+	return c.flaggy
+}
+
+func (c *createCmd) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
+	ctx := context.Background()
+	ctx = logger.NewContext(ctx, log)
+	cli.ManifestOverrides(c.flaggy, &c.manifestOverride, &c.manifestMirrors)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if len(c.arches) == 0 {
+		c.arches = []string{runtime.GOARCH}
+	}
+	// Bundles are built outside the nodes, s3:// URIs are read with the default credentials.
+	util.SetS3ClientProvider(creds.S3ClientProvider("", "", c.region))
+
+	manifest, err := aws.GetReleaseManifestFile(ctx, c.manifestOverride, c.region, append(aws.ManifestVerificationOptions(c.manifestKeys, c.insecureSkipManifestVerification), aws.WithManifestMirrors(c.manifestMirrors...))...)
+	if err != nil {
+		return err
+	}
+	source, err := aws.GetReleaseSourceFromManifest(c.kubernetesVersion, manifest.Manifest)
+	if err != nil {
+		return err
+	}
+	log.Info("Using Kubernetes version", zap.String("version", source.Eks.Version))
+
+	output := c.output
+	if output == "" {
+		output = fmt.Sprintf("nodeadm-bundle-%s.tar", source.Eks.Version)
+	}
+
+	// Write to a temporary file next to the output so a failed create never leaves a
+	// partial bundle behind.
+	tmp, err := os.CreateTemp(filepath.Dir(output), "."+filepath.Base(output)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := bundle.Create(ctx, tmp, manifest, bundle.Options{
+		KubernetesVersion: c.kubernetesVersion,
+		Arches:            c.arches,
+		Region:            c.region,
+		SkipSSM:           c.skipSSM,
+	}, log); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), output); err != nil {
+		return err
+	}
+
+	log.Info("Created bundle", zap.String("path", output), zap.Strings("arches", c.arches))
+	return nil
+}
@@ -0,0 +1,19 @@
+package bundle
+
+import (
+	"github.com/aws/eks-hybrid/internal/cli"
+)
+
+const bundleHelpText = `Examples:
+  # Create a bundle with every Linux AMD64 and ARM64 artifact for Kubernetes version 1.31
+  nodeadm bundle create 1.31 --arch amd64 --arch arm64 --output nodeadm-bundle-1.31.tar
+
+  # Install from the bundle on a disconnected host
+  nodeadm install 1.31 --credential-provider iam-ra --bundle file:///media/usb/nodeadm-bundle-1.31.tar`
+
+func NewBundleCommand() cli.Command {
+	container := cli.NewCommandContainer("bundle", "Manage offline installation bundles")
+	container.Flaggy().AdditionalHelpAppend = bundleHelpText
+	container.AddCommand(NewCreateCommand())
+	return container.AsCommand()
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/integrii/flaggy"
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/cache"
+	"github.com/aws/eks-hybrid/internal/cli"
+)
+
+type importCmd struct {
+	flaggy *flaggy.Subcommand
+	dir    string
+	path   string
+}
+
+func NewImportCommand() cli.Command {
+	cmd := importCmd{
+		dir: cache.DefaultDir,
+	}
+	cmd.flaggy = flaggy.NewSubcommand("import")
+	cmd.flaggy.Description = "Add artifacts to the cache so they aren't downloaded by install or upgrade"
+	cmd.flaggy.AddPositionalValue(&cmd.path, "PATH", 1, true, "Artifact file, or directory of artifact files, to import. Artifacts are imported as they are installed, so gzipped artifacts must be decompressed first.")
+	cmd.flaggy.String(&cmd.dir, "d", "dir", "Directory of the artifact cache.")
+	return &cmd
+}
+
+func (c *importCmd) Flaggy() *flaggy.Subcommand {
+	return c.flaggy
+}
+
+func (c *importCmd) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
+	root, err := cli.IsRunningAsRoot()
+	if err != nil {
+		return err
+	}
+	if !root {
+		return cli.ErrMustRunAsRoot
+	}
+
+	paths, err := importPaths(c.path)
+	if err != nil {
+		return err
+	}
+
+	artifactCache := cache.New(c.dir)
+	for _, path := range paths {
+		entry, err := artifactCache.Import(path)
+		if err != nil {
+			return fmt.Errorf("importing %s: %w", path, err)
+		}
+		log.Info("Imported artifact", zap.String("path", path), zap.String("sha256", entry.Sha256), zap.Int64("size", entry.Size))
+	}
+	return nil
+}
+
+// importPaths returns path if it's a file, or the regular files directly inside it if
+// it's a directory.
+func importPaths(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	dirEntries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, dirEntry := range dirEntries {
+		if dirEntry.Type().IsRegular() {
+			paths = append(paths, filepath.Join(path, dirEntry.Name()))
+		}
+	}
+	return paths, nil
+}
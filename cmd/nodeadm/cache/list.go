@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/integrii/flaggy"
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/cache"
+	"github.com/aws/eks-hybrid/internal/cli"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+type listCmd struct {
+	flaggy *flaggy.Subcommand
+	dir    string
+	output string
+}
+
+// listEntry is a cached artifact along with the installed artifacts that use it.
+type listEntry struct {
+	cache.Entry
+	InstalledAs []string `json:",omitempty"`
+}
+
+func NewListCommand() cli.Command {
+	cmd := listCmd{
+		dir:    cache.DefaultDir,
+		output: outputText,
+	}
+	cmd.flaggy = flaggy.NewSubcommand("list")
+	cmd.flaggy.Description = "List the cached artifacts"
+	cmd.flaggy.String(&cmd.dir, "d", "dir", "Directory of the artifact cache.")
+	cmd.flaggy.String(&cmd.output, "o", "output", fmt.Sprintf("Output format. Allowed values: [%s, %s].", outputText, outputJSON))
+	return &cmd
+}
+
+func (c *listCmd) Flaggy() *flaggy.Subcommand {
+	return c.flaggy
+}
+
+func (c *listCmd) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
+	if c.output != outputText && c.output != outputJSON {
+		return fmt.Errorf("invalid output format %s. Allowed values: [%s, %s]", c.output, outputText, outputJSON)
+	}
+
+	entries, err := cache.New(c.dir).List()
+	if err != nil {
+		return fmt.Errorf("listing cached artifacts: %w", err)
+	}
+	checksums, err := installedChecksums()
+	if err != nil {
+		return fmt.Errorf("reading installed artifacts: %w", err)
+	}
+
+	listed := make([]listEntry, 0, len(entries))
+	for _, entry := range entries {
+		listed = append(listed, listEntry{Entry: entry, InstalledAs: checksums[entry.Sha256]})
+	}
+
+	if c.output == outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(listed)
+	}
+	return printList(os.Stdout, listed)
+}
+
+func printList(w io.Writer, entries []listEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SHA256\tSIZE\tLAST USED\tINSTALLED AS")
+	for _, entry := range entries {
+		installedAs := strings.Join(entry.InstalledAs, ",")
+		if installedAs == "" {
+			installedAs = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", entry.Sha256, entry.Size, entry.ModTime.Format(time.RFC3339), installedAs)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/integrii/flaggy"
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/cache"
+	"github.com/aws/eks-hybrid/internal/cli"
+)
+
+type pruneCmd struct {
+	flaggy    *flaggy.Subcommand
+	dir       string
+	all       bool
+	olderThan time.Duration
+}
+
+func NewPruneCommand() cli.Command {
+	cmd := pruneCmd{
+		dir: cache.DefaultDir,
+	}
+	cmd.flaggy = flaggy.NewSubcommand("prune")
+	cmd.flaggy.Description = "Remove cached artifacts that aren't used by the current installation"
+	cmd.flaggy.String(&cmd.dir, "d", "dir", "Directory of the artifact cache.")
+	cmd.flaggy.Bool(&cmd.all, "", "all", "Remove every cached artifact, including the ones used by the current installation.")
+	cmd.flaggy.Duration(&cmd.olderThan, "", "older-than", "Only remove artifacts that haven't been used for longer than this duration. Example: 720h")
+	return &cmd
+}
+
+func (c *pruneCmd) Flaggy() *flaggy.Subcommand {
+	return c.flaggy
+}
+
+func (c *pruneCmd) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
+	root, err := cli.IsRunningAsRoot()
+	if err != nil {
+		return err
+	}
+	if !root {
+		return cli.ErrMustRunAsRoot
+	}
+
+	checksums, err := installedChecksums()
+	if err != nil {
+		return fmt.Errorf("reading installed artifacts: %w", err)
+	}
+
+	now := time.Now()
+	removed, err := cache.New(c.dir).Prune(func(entry cache.Entry) bool {
+		if _, ok := checksums[entry.Sha256]; ok && !c.all {
+			return true
+		}
+		return c.olderThan > 0 && now.Sub(entry.ModTime) < c.olderThan
+	})
+	for _, entry := range removed {
+		log.Info("Removed cached artifact", zap.String("sha256", entry.Sha256), zap.Int64("size", entry.Size))
+	}
+	if err != nil {
+		return fmt.Errorf("pruning artifact cache: %w", err)
+	}
+	log.Info("Pruned artifact cache", zap.Int("removed", len(removed)))
+	return nil
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"os"
+	"sort"
+
+	"github.com/aws/eks-hybrid/internal/cli"
+	"github.com/aws/eks-hybrid/internal/tracker"
+)
+
+const cacheHelpText = `Examples:
+  # List the cached artifacts
+  nodeadm cache list
+
+  # Remove the cached artifacts that aren't used by the current installation
+  nodeadm cache prune
+
+  # Pre-seed the cache with artifacts copied to the node
+  nodeadm cache import /tmp/artifacts`
+
+func NewCacheCommand() cli.Command {
+	container := cli.NewCommandContainer("cache", "Manage the local artifact cache")
+	container.Flaggy().AdditionalHelpAppend = cacheHelpText
+	container.AddCommand(NewListCommand())
+	container.AddCommand(NewPruneCommand())
+	container.AddCommand(NewImportCommand())
+	return container.AsCommand()
+}
+
+// installedChecksums returns the names of the installed artifacts keyed by their sha256
+// checksum, as recorded in the tracker. It returns an empty map if nothing is installed.
+func installedChecksums() (map[string][]string, error) {
+	installed, err := tracker.GetInstalledArtifacts()
+	if os.IsNotExist(err) {
+		return map[string][]string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	checksums := map[string][]string{}
+	for name, record := range installed.Records {
+		if record != nil && record.Sha256 != "" {
+			checksums[record.Sha256] = append(checksums[record.Sha256], name)
+		}
+	}
+	for _, names := range checksums {
+		sort.Strings(names)
+	}
+	return checksums, nil
+}
@@ -7,18 +7,21 @@ import (
 	"github.com/aws/eks-hybrid/internal/cli"
 	"github.com/aws/eks-hybrid/internal/configprovider"
 	"github.com/aws/eks-hybrid/internal/node"
+	"github.com/aws/eks-hybrid/internal/util"
 )
 
 type fileCmd struct {
-	cmd          *flaggy.Subcommand
-	configSource string
+	cmd             *flaggy.Subcommand
+	configSources   []string
+	configCABundles []string
 }
 
 func NewCheckCommand() cli.Command {
 	file := fileCmd{}
 	file.cmd = flaggy.NewSubcommand("check")
 	file.cmd.Description = "Verify configuration"
-	file.cmd.String(&file.configSource, "c", "config-source", "Source of node configuration. The format is a URI with supported schemes: [file, imds].")
+	file.cmd.StringSlice(&file.configSources, "c", "config-source", "Source of node configuration. The format is a URI with supported schemes: [file, imds, https, s3]. A file:// directory merges its YAML files in lexical order. An https:// or s3:// source can pin its digest with a #sha256=<hex> fragment. Can be repeated, later sources override earlier ones.")
+	file.cmd.StringSlice(&file.configCABundles, "", "config-ca-bundle", "Path to a PEM encoded CA bundle trusted, in addition to the system roots, to fetch https:// and s3:// config sources. Can be repeated.")
 	return &file
 }
 
@@ -27,8 +30,12 @@ func (c *fileCmd) Flaggy() *flaggy.Subcommand {
 }
 
 func (c *fileCmd) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
-	log.Info("Checking configuration", zap.String("source", c.configSource))
-	provider, err := configprovider.BuildConfigProvider(c.configSource)
+	cli.ConfigSources(c.cmd, &c.configSources)
+	log.Info("Checking configuration", zap.Strings("source", util.RedactURIs(c.configSources)))
+	// Checking the config doesn't resolve its secret references, which would read them from
+	// AWS and write the secret files, only init does.
+	providerOpts := []configprovider.Option{configprovider.WithCABundles(c.configCABundles...), configprovider.WithoutSecretResolution()}
+	provider, err := configprovider.BuildLayeredConfigProvider(c.configSources, providerOpts...)
 	if err != nil {
 		return err
 	}
@@ -37,7 +44,7 @@ func (c *fileCmd) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 		return err
 	}
 
-	nodeProvider, err := node.NewNodeProvider(c.configSource, []string{}, log)
+	nodeProvider, err := node.NewNodeProvider(c.configSources, []string{}, log, providerOpts...)
 	if err != nil {
 		return err
 	}
@@ -7,6 +7,12 @@ import (
 const configHelpText = `Examples:
   # Check configuration file
   nodeadm config check --config-source file:///root/nodeConfig.yaml
+
+  # Print the configuration init would use, with the generated kubelet config
+  nodeadm config show --effective --kubelet-config --config-source file:///root/nodeConfig.yaml
+
+  # Print the configuration with defaults, without calling AWS, as JSON
+  nodeadm config show --effective --offline -o json --config-source file:///root/nodeConfig.yaml
   
 Documentation:
   https://docs.aws.amazon.com/eks/latest/userguide/hybrid-nodes-nodeadm.html#_config_check`
@@ -15,5 +21,6 @@ func NewConfigCommand() cli.Command {
 	container := cli.NewCommandContainer("config", "Manage configuration")
 	container.Flaggy().AdditionalHelpAppend = configHelpText
 	container.AddCommand(NewCheckCommand())
+	container.AddCommand(NewShowCommand())
 	return container.AsCommand()
 }
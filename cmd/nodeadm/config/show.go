@@ -0,0 +1,179 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/integrii/flaggy"
+	"go.uber.org/zap"
+	"sigs.k8s.io/yaml"
+
+	"github.com/aws/eks-hybrid/internal/api"
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/cli"
+	"github.com/aws/eks-hybrid/internal/configprovider"
+	"github.com/aws/eks-hybrid/internal/creds"
+	"github.com/aws/eks-hybrid/internal/flows"
+	"github.com/aws/eks-hybrid/internal/kubelet"
+	"github.com/aws/eks-hybrid/internal/logger"
+	"github.com/aws/eks-hybrid/internal/node"
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+const (
+	outputYAML = "yaml"
+	outputJSON = "json"
+)
+
+type showCmd struct {
+	cmd                              *flaggy.Subcommand
+	configSources                    []string
+	configCABundles                  []string
+	effective                        bool
+	offline                          bool
+	kubeletConfig                    bool
+	output                           string
+	manifestOverride                 string
+	manifestMirrors                  []string
+	manifestKeys                     []string
+	insecureSkipManifestVerification bool
+}
+
+// shownConfig is the document printed by config show.
+type shownConfig struct {
+	NodeConfig    *api.NodeConfig        `json:"nodeConfig"`
+	KubeletConfig map[string]interface{} `json:"kubeletConfig,omitempty"`
+}
+
+func NewShowCommand() cli.Command {
+	show := showCmd{
+		output: outputYAML,
+	}
+	show.cmd = flaggy.NewSubcommand("show")
+	show.cmd.Description = "Print the node configuration with secrets redacted"
+	show.cmd.StringSlice(&show.configSources, "c", "config-source", "Source of node configuration. The format is a URI with supported schemes: [file, imds, https, s3]. A file:// directory merges its YAML files in lexical order. An https:// or s3:// source can pin its digest with a #sha256=<hex> fragment. Can be repeated, later sources override earlier ones.")
+	show.cmd.StringSlice(&show.configCABundles, "", "config-ca-bundle", "Path to a PEM encoded CA bundle trusted, in addition to the system roots, to fetch https:// and s3:// config sources. Can be repeated.")
+	show.cmd.Bool(&show.effective, "", "effective", "Print the configuration init would use, after defaulting and enrichment, including its status. Nothing on the host is changed.")
+	show.cmd.Bool(&show.offline, "", "offline", "With --effective, skip the steps reading AWS credentials or calling AWS, such as reading the cluster details or resolving secret references.")
+	show.cmd.Bool(&show.kubeletConfig, "", "kubelet-config", "With --effective, also print the kubelet configuration init would generate. Requires kubelet to be installed.")
+	show.cmd.String(&show.output, "o", "output", fmt.Sprintf("Output format. Allowed values: [%s, %s].", outputYAML, outputJSON))
+	show.cmd.String(&show.manifestOverride, "m", "manifest-override", "URI to a manifest file containing custom artifact URLs, used to resolve the region config with --effective. Supports file:// for local files, https:// for remote files and s3:// for S3 objects read with the node credentials. Can be repeated to list mirrors tried in order.")
+	show.cmd.StringSlice(&show.manifestKeys, "", "manifest-key", "Path to an armored OpenPGP public key trusted to sign the release manifest, in addition to the keys in "+aws.TrustedKeysDir+". Can be repeated.")
+	show.cmd.Bool(&show.insecureSkipManifestVerification, "", "insecure-skip-manifest-verification", "Accept release manifests without verifying their signature.")
+	return &show
+}
+
+func (c *showCmd) Flaggy() *flaggy.Subcommand {
+	return c.cmd
+}
+
+func (c *showCmd) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
+	ctx := context.Background()
+	ctx = logger.NewContext(ctx, log)
+	cli.ConfigSources(c.cmd, &c.configSources)
+	cli.ManifestOverrides(c.cmd, &c.manifestOverride, &c.manifestMirrors)
+
+	if len(c.configSources) == 0 {
+		flaggy.ShowHelpAndExit("--config-source is a required flag. The format is a URI with supported schemes: [file, imds, https, s3]." +
+			" For example on hybrid nodes --config-source file://nodeConfig.yaml")
+	}
+	if c.output != outputYAML && c.output != outputJSON {
+		return fmt.Errorf("invalid output format %s. Allowed values: [%s, %s]", c.output, outputYAML, outputJSON)
+	}
+	if !c.effective && (c.offline || c.kubeletConfig) {
+		return fmt.Errorf("--offline and --kubelet-config require --effective")
+	}
+
+	var shown shownConfig
+	var err error
+	if c.effective {
+		shown.NodeConfig, err = c.resolveEffective(ctx, log)
+	} else {
+		shown.NodeConfig, err = c.provide()
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.kubeletConfig {
+		shown.KubeletConfig, err = kubelet.EffectiveConfig(shown.NodeConfig)
+		if err != nil {
+			return fmt.Errorf("generating kubelet config: %w", err)
+		}
+	}
+
+	flows.RedactSecrets(shown.NodeConfig)
+	return printConfig(c.output, shown)
+}
+
+// provide reads the configuration from its sources, without defaults or enrichment. Secret
+// references are shown as they are written.
+func (c *showCmd) provide() (*api.NodeConfig, error) {
+	provider, err := configprovider.BuildLayeredConfigProvider(c.configSources, configprovider.WithCABundles(c.configCABundles...), configprovider.WithoutSecretResolution())
+	if err != nil {
+		return nil, err
+	}
+	return provider.Provide()
+}
+
+// resolveEffective runs the steps of init resolving the configuration, rendering any host
+// file they write into a scratch directory.
+func (c *showCmd) resolveEffective(ctx context.Context, log *zap.Logger) (*api.NodeConfig, error) {
+	if !c.offline {
+		root, err := cli.IsRunningAsRoot()
+		if err != nil {
+			return nil, err
+		} else if !root {
+			return nil, cli.ErrMustRunAsRoot
+		}
+	}
+
+	rootDir, err := os.MkdirTemp("", "nodeadm-config-show-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(rootDir)
+	defer util.SetDryRunRoot(rootDir)()
+
+	options := []configprovider.Option{configprovider.WithCABundles(c.configCABundles...)}
+	if c.offline {
+		// Reading secret references calls AWS, the references are shown instead.
+		options = append(options, configprovider.WithoutSecretResolution())
+	}
+	nodeProvider, err := node.NewNodeProvider(c.configSources, []string{}, log, options...)
+	if err != nil {
+		return nil, err
+	}
+	if !c.offline {
+		provider, err := creds.NodeS3ClientProvider(nodeProvider.GetNodeConfig())
+		if err != nil {
+			return nil, err
+		}
+		util.SetS3ClientProvider(provider)
+	}
+
+	resolver := &flows.ConfigResolver{
+		NodeProvider:     nodeProvider,
+		ManifestOverride: c.manifestOverride,
+		ManifestOptions:  append(aws.ManifestVerificationOptions(c.manifestKeys, c.insecureSkipManifestVerification), aws.WithManifestMirrors(c.manifestMirrors...)),
+		Offline:          c.offline,
+		Logger:           log,
+	}
+	return resolver.Run(ctx)
+}
+
+func printConfig(output string, shown shownConfig) error {
+	if output == outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(shown)
+	}
+	data, err := yaml.Marshal(shown)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
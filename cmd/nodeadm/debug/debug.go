@@ -17,6 +17,7 @@ import (
 	"github.com/aws/eks-hybrid/internal/configprovider"
 	"github.com/aws/eks-hybrid/internal/creds"
 	"github.com/aws/eks-hybrid/internal/errors"
+	"github.com/aws/eks-hybrid/internal/flows"
 	"github.com/aws/eks-hybrid/internal/kubelet"
 	"github.com/aws/eks-hybrid/internal/kubernetes"
 	"github.com/aws/eks-hybrid/internal/logger"
@@ -36,7 +37,8 @@ Documentation:
 func NewCommand() cli.Command {
 	debug := debug{}
 	debug.cmd = flaggy.NewSubcommand("debug")
-	debug.cmd.String(&debug.nodeConfigSource, "c", "config-source", "Source of node configuration. The format is a URI with supported schemes: [file, imds].")
+	debug.cmd.StringSlice(&debug.nodeConfigSources, "c", "config-source", "Source of node configuration. The format is a URI with supported schemes: [file, imds, https, s3]. A file:// directory merges its YAML files in lexical order. An https:// or s3:// source can pin its digest with a #sha256=<hex> fragment. Can be repeated, later sources override earlier ones.")
+	debug.cmd.StringSlice(&debug.configCABundles, "", "config-ca-bundle", "Path to a PEM encoded CA bundle trusted, in addition to the system roots, to fetch https:// and s3:// config sources. Can be repeated.")
 	debug.cmd.Bool(&debug.noColor, "", "no-color", "If set, suppresses color output.")
 	debug.cmd.Description = "Debug the node registration process"
 	debug.cmd.AdditionalHelpPrepend = debugHelpText
@@ -44,9 +46,10 @@ func NewCommand() cli.Command {
 }
 
 type debug struct {
-	cmd              *flaggy.Subcommand
-	nodeConfigSource string
-	noColor          bool
+	cmd               *flaggy.Subcommand
+	nodeConfigSources []string
+	configCABundles   []string
+	noColor           bool
 }
 
 func (c *debug) Flaggy() *flaggy.Subcommand {
@@ -56,13 +59,14 @@ func (c *debug) Flaggy() *flaggy.Subcommand {
 func (c *debug) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 	ctx := context.Background()
 	ctx = logger.NewContext(ctx, log)
+	cli.ConfigSources(c.cmd, &c.nodeConfigSources)
 
-	if c.nodeConfigSource == "" {
-		flaggy.ShowHelpAndExit("--config-source is a required flag. The format is a URI with supported schemes: [file, imds]." +
+	if len(c.nodeConfigSources) == 0 {
+		flaggy.ShowHelpAndExit("--config-source is a required flag. The format is a URI with supported schemes: [file, imds, https, s3]." +
 			" For example on hybrid nodes --config-source file://nodeConfig.yaml")
 	}
 
-	provider, err := configprovider.BuildConfigProvider(c.nodeConfigSource)
+	provider, err := configprovider.BuildLayeredConfigProvider(c.nodeConfigSources, configprovider.WithCABundles(c.configCABundles...), configprovider.WithoutSecretResolution())
 	if err != nil {
 		return err
 	}
@@ -104,6 +108,7 @@ func (c *debug) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 		validation.New("ulimit", system.NewUlimitValidator().Run),
 		validation.New("aws-auth", sts.NewAuthenticationValidator(awsConfig).Run),
 		validation.New("proxy-config", network.NewProxyValidator().Run),
+		validation.New("binary-drift", flows.NewArtifactValidator().Run),
 	)
 
 	clusterDetail, err := clusterProvider.ReadClusterDetails(ctx, nodeConfig)
@@ -2,7 +2,9 @@ package init
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"strings"
 
@@ -10,13 +12,21 @@ import (
 	"go.uber.org/zap"
 	"k8s.io/utils/strings/slices"
 
+	"github.com/aws/eks-hybrid/internal/api"
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/bundle"
 	"github.com/aws/eks-hybrid/internal/cli"
+	"github.com/aws/eks-hybrid/internal/configprovider"
 	"github.com/aws/eks-hybrid/internal/containerd"
+	"github.com/aws/eks-hybrid/internal/creds"
 	"github.com/aws/eks-hybrid/internal/flows"
+	"github.com/aws/eks-hybrid/internal/journal"
+	"github.com/aws/eks-hybrid/internal/ledger"
 	"github.com/aws/eks-hybrid/internal/logger"
 	"github.com/aws/eks-hybrid/internal/node"
 	"github.com/aws/eks-hybrid/internal/system"
 	"github.com/aws/eks-hybrid/internal/tracker"
+	"github.com/aws/eks-hybrid/internal/util"
 )
 
 const (
@@ -54,29 +64,48 @@ const initHelpText = `Examples:
   # Initialize using configuration file
   nodeadm init --config-source file://nodeConfig.yaml
 
+  # Resume an init that was interrupted, continuing from the last completed step
+  nodeadm init --config-source file://nodeConfig.yaml --resume
+
+  # Show the changes init would make to host files without applying them
+  nodeadm init --config-source file://nodeConfig.yaml --dry-run
+
 Documentation:
   https://docs.aws.amazon.com/eks/latest/userguide/hybrid-nodes-nodeadm.html#_init`
 
 func NewInitCommand() cli.Command {
 	init := initCmd{}
 	init.cmd = flaggy.NewSubcommand("init")
-	init.cmd.String(&init.configSource, "c", "config-source", "Source of node configuration. The format is a URI with supported schemes: [file, imds].")
+	init.cmd.StringSlice(&init.configSources, "c", "config-source", "Source of node configuration. The format is a URI with supported schemes: [file, imds, https, s3]. A file:// directory merges its YAML files in lexical order. An https:// or s3:// source can pin its digest with a #sha256=<hex> fragment. Can be repeated, later sources override earlier ones.")
+	init.cmd.StringSlice(&init.configCABundles, "", "config-ca-bundle", "Path to a PEM encoded CA bundle trusted, in addition to the system roots, to fetch https:// and s3:// config sources. Can be repeated.")
 	init.cmd.StringSlice(&init.daemons, "d", "daemon", "Specify one or more of `containerd` and `kubelet`. This is intended for testing and should not be used in a production environment.")
 	init.cmd.StringSlice(&init.skipPhases, "s", "skip", fmt.Sprintf("Phases of the bootstrap to skip. Allowed values: [%s].", strings.Join(Phases(), ", ")))
-	init.cmd.String(&init.manifestOverride, "m", "manifest-override", "URI to a manifest file containing custom artifact URLs. Supports file:// for local files and https:// for remote files.")
+	init.cmd.String(&init.manifestOverride, "m", "manifest-override", "URI to a manifest file containing custom artifact URLs. Supports file:// for local files, https:// for remote files and s3:// for S3 objects read with the node credentials. Can be repeated to list mirrors tried in order.")
+	init.cmd.StringSlice(&init.manifestKeys, "", "manifest-key", "Path to an armored OpenPGP public key trusted to sign the release manifest, in addition to the keys in "+aws.TrustedKeysDir+". Can be repeated.")
+	init.cmd.Bool(&init.insecureSkipManifestVerification, "", "insecure-skip-manifest-verification", "Accept release manifests without verifying their signature.")
+	init.cmd.String(&init.bundle, "", "bundle", "Offline bundle created by nodeadm bundle create to init from without network access. The format is a URI with supported schemes: [file]. Implies --private-mode.")
 	init.cmd.Bool(&init.privateMode, "", "private-mode", "Enable private init mode (requires --manifest-override for region config).")
+	init.cmd.Bool(&init.dryRun, "", "dry-run", "Render every file init would write to a scratch directory and print a diff against the host files. No daemon is started, stopped or reloaded.")
+	init.cmd.Bool(&init.resume, "", "resume", fmt.Sprintf("Resume a previous init from the last completed step recorded in %s.", journal.InitJournalFile))
 	init.cmd.Description = "Initialize this instance as a node in an EKS cluster"
 	init.cmd.AdditionalHelpAppend = initHelpText
 	return &init
 }
 
 type initCmd struct {
-	cmd              *flaggy.Subcommand
-	configSource     string
-	skipPhases       []string
-	daemons          []string
-	manifestOverride string
-	privateMode      bool
+	cmd                              *flaggy.Subcommand
+	configSources                    []string
+	configCABundles                  []string
+	skipPhases                       []string
+	daemons                          []string
+	manifestOverride                 string
+	manifestMirrors                  []string
+	manifestKeys                     []string
+	insecureSkipManifestVerification bool
+	bundle                           string
+	privateMode                      bool
+	resume                           bool
+	dryRun                           bool
 }
 
 func (c *initCmd) Flaggy() *flaggy.Subcommand {
@@ -86,6 +115,8 @@ func (c *initCmd) Flaggy() *flaggy.Subcommand {
 func (c *initCmd) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 	ctx := context.Background()
 	ctx = logger.NewContext(ctx, log)
+	cli.ConfigSources(c.cmd, &c.configSources)
+	cli.ManifestOverrides(c.cmd, &c.manifestOverride, &c.manifestMirrors)
 
 	log.Info("Checking user is root...")
 	root, err := cli.IsRunningAsRoot()
@@ -95,15 +126,33 @@ func (c *initCmd) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 		return cli.ErrMustRunAsRoot
 	}
 
-	if c.configSource == "" {
-		flaggy.ShowHelpAndExit("--config-source is a required flag. The format is a URI with supported schemes: [file, imds]." +
+	if len(c.configSources) == 0 {
+		flaggy.ShowHelpAndExit("--config-source is a required flag. The format is a URI with supported schemes: [file, imds, https, s3]." +
 			" For example on hybrid nodes --config-source file://nodeConfig.yaml")
 	}
 
+	if c.bundle != "" {
+		if c.manifestOverride != "" {
+			return fmt.Errorf("--bundle and --manifest-override cannot be used together")
+		}
+		log.Info("Extracting bundle", zap.String("bundle", c.bundle))
+		offlineBundle, err := bundle.Mount(c.bundle, bundle.ExtractDir)
+		if err != nil {
+			return err
+		}
+		defer offlineBundle.Unmount()
+		c.manifestOverride = offlineBundle.ManifestURI()
+		c.privateMode = true
+	}
+
 	if c.privateMode && c.manifestOverride == "" {
 		return fmt.Errorf("--private-mode requires --manifest-override to be specified")
 	}
 
+	if c.dryRun && c.resume {
+		return fmt.Errorf("--dry-run and --resume cannot be used together")
+	}
+
 	if !slices.Contains(c.skipPhases, installValidation) {
 		log.Info("Loading installed components")
 		_, err = tracker.GetInstalledArtifacts()
@@ -120,7 +169,8 @@ func (c *initCmd) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 	}
 
 	// Check if either of cilium or calico vxlan port are open
-	if !slices.Contains(c.skipPhases, cniPortCheckValidation) {
+	// This flushes the firewall rules, so it's skipped on dry runs.
+	if !c.dryRun && !slices.Contains(c.skipPhases, cniPortCheckValidation) {
 		log.Info("Validating firewall ports for cilium and calico")
 		if err := validateFirewallOpenPorts(); err != nil {
 			return fmt.Errorf("Cilium (%s/%s) or Calico (%s/%s) VxLan ports are not open on the host. If you are not using VxLan, this validation can by bypassed with --skip %s",
@@ -128,20 +178,108 @@ func (c *initCmd) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 		}
 	}
 
-	nodeProvider, err := node.NewNodeProvider(c.configSource, c.skipPhases, log)
+	if c.dryRun {
+		return c.runDryRun(ctx, log)
+	}
+
+	nodeProvider, err := node.NewNodeProvider(c.configSources, c.skipPhases, log, configprovider.WithCABundles(c.configCABundles...))
+	if err != nil {
+		return err
+	}
+
+	initJournal, err := c.openJournal(log, nodeProvider.GetNodeConfig())
 	if err != nil {
 		return err
 	}
+	if err := setS3ClientProvider(nodeProvider.GetNodeConfig()); err != nil {
+		return err
+	}
 
 	initer := &flows.Initer{
 		NodeProvider:     nodeProvider,
 		SkipPhases:       c.skipPhases,
 		Logger:           log,
 		ManifestOverride: c.manifestOverride,
+		ManifestOptions:  append(aws.ManifestVerificationOptions(c.manifestKeys, c.insecureSkipManifestVerification), aws.WithManifestMirrors(c.manifestMirrors...)),
 		PrivateMode:      c.privateMode,
+		Journal:          initJournal,
+	}
+
+	if err := ledger.Track(ledger.FilePath, func() error { return initer.Run(ctx) }); err != nil {
+		log.Error("Init failed. Fix the error and re-run with --resume to continue from the failed step", zap.String("journal", journal.InitJournalFile))
+		return err
 	}
+	// A completed init is not resumable, a later --resume re-runs every step.
+	return initJournal.Remove()
+}
+
+func (c *initCmd) runDryRun(ctx context.Context, log *zap.Logger) error {
+	rootDir, err := os.MkdirTemp("", "nodeadm-dry-run-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(rootDir)
+
+	log.Info("Dry run: rendering host files", zap.String("rootDir", rootDir))
+	defer util.SetDryRunRoot(rootDir)()
 
-	return initer.Run(ctx)
+	nodeProvider, err := node.NewNodeProvider(c.configSources, c.skipPhases, log, configprovider.WithCABundles(c.configCABundles...))
+	if err != nil {
+		return err
+	}
+	if err := setS3ClientProvider(nodeProvider.GetNodeConfig()); err != nil {
+		return err
+	}
+
+	dryRunner := &flows.DryRunner{
+		NodeProvider:     nodeProvider,
+		ManifestOverride: c.manifestOverride,
+		ManifestOptions:  append(aws.ManifestVerificationOptions(c.manifestKeys, c.insecureSkipManifestVerification), aws.WithManifestMirrors(c.manifestMirrors...)),
+		RootDir:          rootDir,
+		Output:           os.Stdout,
+		Logger:           log,
+	}
+	return dryRunner.Run(ctx)
+}
+
+// setS3ClientProvider reads s3:// manifests and artifacts with the credentials of the node.
+func setS3ClientProvider(nodeConfig *api.NodeConfig) error {
+	provider, err := creds.NodeS3ClientProvider(nodeConfig)
+	if err != nil {
+		return err
+	}
+	util.SetS3ClientProvider(provider)
+	return nil
+}
+
+// openJournal returns the journal to record init progress in. With --resume, the existing
+// journal is reused as long as it was recorded for the same node configuration.
+func (c *initCmd) openJournal(log *zap.Logger, nodeConfig *api.NodeConfig) (*journal.Journal, error) {
+	checksum, err := journal.Checksum(nodeConfig.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("calculating node config checksum: %w", err)
+	}
+	if !c.resume {
+		return journal.New(journal.InitJournalFile, checksum), nil
+	}
+
+	existing, err := journal.Load(journal.InitJournalFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Info("No init journal found, starting from the beginning")
+		return journal.New(journal.InitJournalFile, checksum), nil
+	} else if err != nil {
+		return nil, err
+	}
+	if existing.ConfigChecksum != checksum {
+		log.Warn("Node configuration changed since the journal was recorded, starting from the beginning")
+		return journal.New(journal.InitJournalFile, checksum), nil
+	}
+	if failed := existing.Failed(); failed != nil {
+		log.Info("Resuming init", zap.String("failedStep", failed.Name), zap.String("failure", failed.Error))
+	} else {
+		log.Info("Resuming init")
+	}
+	return existing, nil
 }
 
 func validateFirewallOpenPorts() error {
@@ -3,20 +3,25 @@ package install
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/integrii/flaggy"
 	"go.uber.org/zap"
 
 	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/bundle"
+	"github.com/aws/eks-hybrid/internal/cache"
 	"github.com/aws/eks-hybrid/internal/cli"
 	"github.com/aws/eks-hybrid/internal/containerd"
 	"github.com/aws/eks-hybrid/internal/creds"
 	"github.com/aws/eks-hybrid/internal/flows"
+	"github.com/aws/eks-hybrid/internal/ledger"
 	"github.com/aws/eks-hybrid/internal/logger"
 	"github.com/aws/eks-hybrid/internal/packagemanager"
 	"github.com/aws/eks-hybrid/internal/ssm"
 	"github.com/aws/eks-hybrid/internal/tracker"
+	"github.com/aws/eks-hybrid/internal/util"
 )
 
 const installHelpText = `Examples:
@@ -37,8 +42,10 @@ Documentation:
 
 func NewCommand() cli.Command {
 	cmd := command{
-		timeout:          20 * time.Minute,
-		containerdSource: string(tracker.ContainerdSourceDistro),
+		timeout:              20 * time.Minute,
+		containerdSource:     string(tracker.ContainerdSourceDistro),
+		downloadParallelism:  aws.DefaultDownloadParallelism,
+		downloadStallTimeout: util.DefaultStallTimeout,
 	}
 	cmd.region = ssm.DefaultSsmInstallerRegion
 
@@ -49,23 +56,40 @@ func NewCommand() cli.Command {
 	fc.String(&cmd.credentialProvider, "p", "credential-provider", "Credential process to install. Allowed values: [ssm, iam-ra].")
 	fc.String(&cmd.containerdSource, "s", "containerd-source", "Source for containerd artifact. Allowed values: [none, distro, docker].")
 	fc.String(&cmd.region, "r", "region", "AWS region for downloading regional artifacts.")
-	fc.String(&cmd.manifestOverride, "m", "manifest-override", "URI to a manifest file containing custom artifact URLs. Supports file:// for local files and https:// for remote files.")
+	fc.String(&cmd.manifestOverride, "m", "manifest-override", "URI to a manifest file containing custom artifact URLs. Supports file:// for local files, https:// for remote files and s3:// for S3 objects read with the node credentials. Can be repeated to list mirrors tried in order.")
+	fc.String(&cmd.bundle, "", "bundle", "Offline bundle created by nodeadm bundle create to install from without network access. The format is a URI with supported schemes: [file]. Implies --private-mode.")
 	fc.Bool(&cmd.privateMode, "", "private-mode", "Enable private installation mode (skips OS packages, requires --manifest-override).")
 	fc.Duration(&cmd.timeout, "t", "timeout", "Maximum install command duration. Input follows duration format. Example: 1h23s")
+	fc.Int(&cmd.downloadParallelism, "", "download-parallelism", "Maximum number of artifacts downloaded at the same time.")
+	fc.String(&cmd.downloadBandwidthLimit, "", "download-bandwidth-limit", "Maximum combined bandwidth of the downloads in bytes per second, as a quantity. Example: 512Ki, 2M. Unlimited by default.")
+	fc.Duration(&cmd.downloadStallTimeout, "", "download-stall-timeout", "Time without receiving data after which a download is resumed. 0 disables stall detection.")
+	fc.StringSlice(&cmd.manifestKeys, "", "manifest-key", "Path to an armored OpenPGP public key trusted to sign the release manifest, in addition to the keys in "+aws.TrustedKeysDir+". Can be repeated.")
+	fc.Bool(&cmd.insecureSkipManifestVerification, "", "insecure-skip-manifest-verification", "Accept release manifests without verifying their signature.")
+	fc.Bool(&cmd.noCache, "", "no-cache", "Download every artifact instead of reusing the ones in the local artifact cache.")
+	fc.StringSlice(&cmd.pins, "", "pin", "Pin a component to a version of the manifest, as component=version. Can be repeated. Allowed components: ["+strings.Join(aws.PinnableComponents(), ", ")+"]. The signing helper is pinned to an IAM Roles Anywhere release version, the other components to the Kubernetes patch version they are taken from.")
 	cmd.flaggy = fc
 
 	return &cmd
 }
 
 type command struct {
-	flaggy             *flaggy.Subcommand
-	kubernetesVersion  string
-	credentialProvider string
-	containerdSource   string
-	region             string
-	manifestOverride   string
-	privateMode        bool
-	timeout            time.Duration
+	flaggy                           *flaggy.Subcommand
+	kubernetesVersion                string
+	credentialProvider               string
+	containerdSource                 string
+	region                           string
+	manifestOverride                 string
+	manifestMirrors                  []string
+	bundle                           string
+	privateMode                      bool
+	timeout                          time.Duration
+	downloadParallelism              int
+	downloadBandwidthLimit           string
+	downloadStallTimeout             time.Duration
+	noCache                          bool
+	manifestKeys                     []string
+	insecureSkipManifestVerification bool
+	pins                             []string
 }
 
 func (c *command) Flaggy() *flaggy.Subcommand {
@@ -75,6 +99,7 @@ func (c *command) Flaggy() *flaggy.Subcommand {
 func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 	ctx := context.Background()
 	ctx = logger.NewContext(ctx, log)
+	cli.ManifestOverrides(c.flaggy, &c.manifestOverride, &c.manifestMirrors)
 
 	root, err := cli.IsRunningAsRoot()
 	if err != nil {
@@ -88,14 +113,40 @@ func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 		flaggy.ShowHelpAndExit("--credential-provider is a required flag. Allowed values are ssm & iam-ra")
 	}
 
+	if c.bundle != "" {
+		if c.manifestOverride != "" {
+			return fmt.Errorf("--bundle and --manifest-override cannot be used together")
+		}
+		log.Info("Extracting bundle", zap.String("bundle", c.bundle))
+		offlineBundle, err := bundle.Mount(c.bundle, bundle.ExtractDir)
+		if err != nil {
+			return err
+		}
+		defer offlineBundle.Unmount()
+		c.manifestOverride = offlineBundle.ManifestURI()
+		c.privateMode = true
+	}
+
 	if c.privateMode && c.manifestOverride == "" {
 		return fmt.Errorf("--private-mode requires --manifest-override to be specified")
 	}
 
+	pins, err := aws.ParsePins(c.pins)
+	if err != nil {
+		return err
+	}
+
+	bandwidthLimit, err := util.ParseBandwidth(c.downloadBandwidthLimit)
+	if err != nil {
+		return err
+	}
+	util.SetDownloadLimits(bandwidthLimit, c.downloadStallTimeout)
+
 	credentialProvider, err := creds.GetCredentialProvider(c.credentialProvider)
 	if err != nil {
 		return err
 	}
+	util.SetS3ClientProvider(creds.S3ClientProvider(credentialProvider, "", c.region))
 
 	containerdSource, err := tracker.ContainerdSource(c.containerdSource)
 	if err != nil {
@@ -109,12 +160,13 @@ func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 	defer cancel()
 
 	var awsSource aws.Source
+	manifestOpts := append(aws.ManifestVerificationOptions(c.manifestKeys, c.insecureSkipManifestVerification), aws.WithManifestMirrors(c.manifestMirrors...))
 	var packageManager *packagemanager.DistroPackageManager
 
 	// Use manifest override if provided, otherwise use default AWS source
 	if c.manifestOverride != "" {
 		log.Info("Using manifest override", zap.String("manifest", c.manifestOverride))
-		awsSource, err = aws.GetLatestSourceFromManifest(ctx, c.kubernetesVersion, c.region, c.manifestOverride)
+		awsSource, err = aws.GetLatestSourceFromManifest(ctx, c.kubernetesVersion, c.region, c.manifestOverride, manifestOpts...)
 		if err != nil {
 			return err
 		}
@@ -122,12 +174,21 @@ func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 	} else {
 		log.Info("Validating Kubernetes version", zap.Reflect("kubernetes version", c.kubernetesVersion))
 		// Create a Source for all AWS managed artifacts.
-		awsSource, err = aws.GetLatestSource(ctx, c.kubernetesVersion, c.region)
+		awsSource, err = aws.GetLatestSource(ctx, c.kubernetesVersion, c.region, manifestOpts...)
 		if err != nil {
 			return err
 		}
 		log.Info("Using Kubernetes version", zap.String("version", awsSource.Eks.Version))
 	}
+	if err := awsSource.ApplyPins(pins); err != nil {
+		return err
+	}
+	if len(pins) > 0 {
+		log.Info("Pinning components", zap.Any("pins", pins))
+	}
+	if !c.noCache {
+		awsSource.Cache = cache.New(cache.DefaultDir)
+	}
 
 	// Create package manager unless in private mode
 	if !c.privateMode {
@@ -139,14 +200,15 @@ func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 	}
 
 	installer := &flows.Installer{
-		AwsSource:          awsSource,
-		PackageManager:     packageManager,
-		ContainerdSource:   containerdSource,
-		SsmRegion:          c.region,
-		CredentialProvider: credentialProvider,
-		Logger:             log,
-		PrivateMode:        c.privateMode,
+		AwsSource:           awsSource,
+		PackageManager:      packageManager,
+		ContainerdSource:    containerdSource,
+		SsmRegion:           c.region,
+		CredentialProvider:  credentialProvider,
+		Logger:              log,
+		PrivateMode:         c.privateMode,
+		DownloadParallelism: c.downloadParallelism,
 	}
 
-	return installer.Run(ctx)
+	return ledger.Track(ledger.FilePath, func() error { return installer.Run(ctx) })
 }
@@ -6,13 +6,19 @@ import (
 	"github.com/integrii/flaggy"
 	"go.uber.org/zap"
 
+	"github.com/aws/eks-hybrid/cmd/nodeadm/bundle"
+	"github.com/aws/eks-hybrid/cmd/nodeadm/cache"
 	"github.com/aws/eks-hybrid/cmd/nodeadm/config"
 	"github.com/aws/eks-hybrid/cmd/nodeadm/debug"
 	initcmd "github.com/aws/eks-hybrid/cmd/nodeadm/init"
 	"github.com/aws/eks-hybrid/cmd/nodeadm/install"
+	"github.com/aws/eks-hybrid/cmd/nodeadm/manifest"
+	"github.com/aws/eks-hybrid/cmd/nodeadm/rollback"
+	"github.com/aws/eks-hybrid/cmd/nodeadm/status"
 	"github.com/aws/eks-hybrid/cmd/nodeadm/sync_artifacts"
 	"github.com/aws/eks-hybrid/cmd/nodeadm/uninstall"
 	"github.com/aws/eks-hybrid/cmd/nodeadm/upgrade"
+	"github.com/aws/eks-hybrid/cmd/nodeadm/verify"
 	"github.com/aws/eks-hybrid/cmd/nodeadm/version"
 	"github.com/aws/eks-hybrid/internal/cli"
 	"github.com/aws/eks-hybrid/internal/errors"
@@ -34,17 +40,23 @@ func main() {
 	cmds := []cli.Command{
 		config.NewConfigCommand(),
 		sync_artifacts.NewCommand(),
+		bundle.NewBundleCommand(),
+		manifest.NewManifestCommand(),
 		initcmd.NewInitCommand(),
 		install.NewCommand(),
 		uninstall.NewCommand(),
 		upgrade.NewUpgradeCommand(),
+		rollback.NewCommand(),
+		status.NewCommand(),
+		verify.NewCommand(),
+		cache.NewCacheCommand(),
 		debug.NewCommand(),
 	}
 
 	for _, cmd := range cmds {
 		flaggy.AttachSubcommand(cmd.Flaggy(), 1)
 	}
-	flaggy.Parse()
+	flaggy.ParseArgs(cli.JoinFlagValues(os.Args[1:], "manifest"))
 
 	for _, cmd := range cmds {
 		if cmd.Flaggy().Used {
@@ -0,0 +1,131 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/integrii/flaggy"
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/cli"
+	"github.com/aws/eks-hybrid/internal/logger"
+)
+
+type diffCmd struct {
+	flaggy   *flaggy.Subcommand
+	manifest manifestFlags
+	from     string
+	to       string
+	output   string
+}
+
+// releaseDiff is the difference between two patch releases.
+type releaseDiff struct {
+	From            string               `json:"from"`
+	FromReleaseDate string               `json:"fromReleaseDate"`
+	To              string               `json:"to"`
+	ToReleaseDate   string               `json:"toReleaseDate"`
+	Changes         []aws.ArtifactChange `json:"changes"`
+}
+
+func NewDiffCommand() cli.Command {
+	cmd := diffCmd{output: outputText}
+	cmd.flaggy = flaggy.NewSubcommand("diff")
+	cmd.flaggy.Description = "Show the artifacts that changed between two Kubernetes versions"
+	cmd.flaggy.AddPositionalValue(&cmd.from, "FROM_VERSION", 1, true, "The major.minor[.patch] version of Kubernetes to compare from.")
+	cmd.flaggy.AddPositionalValue(&cmd.to, "TO_VERSION", 2, true, "The major.minor[.patch] version of Kubernetes to compare to.")
+	cmd.manifest.register(cmd.flaggy, "manifest-override")
+	cmd.flaggy.String(&cmd.output, "o", "output", fmt.Sprintf("Output format. Allowed values: [%s, %s].", outputText, outputJSON))
+	return &cmd
+}
+
+func (c *diffCmd) Flaggy() *flaggy.Subcommand {
+	return c.flaggy
+}
+
+func (c *diffCmd) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
+	if err := validateOutput(c.output); err != nil {
+		return err
+	}
+	ctx := logger.NewContext(context.Background(), log)
+
+	manifest, err := c.manifest.read(ctx)
+	if err != nil {
+		return err
+	}
+	from, err := aws.GetReleaseSourceFromManifest(c.from, manifest.Manifest)
+	if err != nil {
+		return fmt.Errorf("finding %s: %w", c.from, err)
+	}
+	to, err := aws.GetReleaseSourceFromManifest(c.to, manifest.Manifest)
+	if err != nil {
+		return fmt.Errorf("finding %s: %w", c.to, err)
+	}
+	diff := releaseDiff{
+		From:            from.Eks.Version,
+		FromReleaseDate: from.Eks.ReleaseDate,
+		To:              to.Eks.Version,
+		ToReleaseDate:   to.Eks.ReleaseDate,
+		Changes:         aws.DiffEksPatchReleases(from.Eks, to.Eks),
+	}
+
+	if c.output == outputJSON {
+		return printJSON(diff)
+	}
+	return printDiff(os.Stdout, diff)
+}
+
+func printDiff(w io.Writer, diff releaseDiff) error {
+	fmt.Fprintf(w, "%s (%s) -> %s (%s)\n", diff.From, diff.FromReleaseDate, diff.To, diff.ToReleaseDate)
+	if len(diff.Changes) == 0 {
+		fmt.Fprintln(w, "No artifact changes")
+		return nil
+	}
+	for _, change := range diff.Changes {
+		switch change.Type {
+		case aws.ChangeAdded:
+			fmt.Fprintf(w, "+ %s %s/%s\n", change.Name, change.OS, change.Arch)
+		case aws.ChangeRemoved:
+			fmt.Fprintf(w, "- %s %s/%s\n", change.Name, change.OS, change.Arch)
+		case aws.ChangeUpdated:
+			fmt.Fprintf(w, "~ %s %s/%s\n", change.Name, change.OS, change.Arch)
+			for _, field := range changedFields(*change.From, *change.To) {
+				fmt.Fprintf(w, "    %s: %s -> %s\n", field.name, orNone(field.from), orNone(field.to))
+			}
+		}
+	}
+	return nil
+}
+
+type fieldChange struct {
+	name, from, to string
+}
+
+func changedFields(from, to aws.Artifact) []fieldChange {
+	fields := []fieldChange{
+		{"uri", from.URI, to.URI},
+		{"gzip_uri", from.GzipURI, to.GzipURI},
+		{"checksum_uri", from.ChecksumURI, to.ChecksumURI},
+		{"sha256", from.Sha256, to.Sha256},
+		{"sha512", from.Sha512, to.Sha512},
+		{"mirrors", strings.Join(from.Mirrors, ","), strings.Join(to.Mirrors, ",")},
+	}
+	var changed []fieldChange
+	for _, field := range fields {
+		if field.from != field.to {
+			changed = append(changed, field)
+		}
+	}
+	return changed
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
@@ -0,0 +1,113 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/integrii/flaggy"
+	"go.uber.org/zap"
+	"golang.org/x/mod/semver"
+
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/cli"
+	"github.com/aws/eks-hybrid/internal/logger"
+)
+
+type listCmd struct {
+	flaggy   *flaggy.Subcommand
+	manifest manifestFlags
+	output   string
+}
+
+// listEntry is a patch release offered by a manifest.
+type listEntry struct {
+	Version     string   `json:"version"`
+	ReleaseDate string   `json:"releaseDate"`
+	Latest      bool     `json:"latest"`
+	Platforms   []string `json:"platforms"`
+}
+
+func NewListCommand() cli.Command {
+	cmd := listCmd{output: outputText}
+	cmd.flaggy = flaggy.NewSubcommand("list")
+	cmd.flaggy.Description = "List the Kubernetes patch versions offered by a release manifest"
+	cmd.manifest.register(cmd.flaggy, "manifest-override")
+	cmd.flaggy.String(&cmd.output, "o", "output", fmt.Sprintf("Output format. Allowed values: [%s, %s].", outputText, outputJSON))
+	return &cmd
+}
+
+func (c *listCmd) Flaggy() *flaggy.Subcommand {
+	return c.flaggy
+}
+
+func (c *listCmd) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
+	if err := validateOutput(c.output); err != nil {
+		return err
+	}
+	ctx := logger.NewContext(context.Background(), log)
+
+	manifest, err := c.manifest.read(ctx)
+	if err != nil {
+		return err
+	}
+	entries := listReleases(manifest.Manifest)
+
+	if c.output == outputJSON {
+		return printJSON(entries)
+	}
+	return printList(os.Stdout, entries)
+}
+
+// listReleases returns the patch releases of a manifest, newest first.
+func listReleases(manifest *aws.Manifest) []listEntry {
+	var entries []listEntry
+	for _, release := range manifest.SupportedEksReleases {
+		for _, patch := range release.PatchReleases {
+			entries = append(entries, listEntry{
+				Version:     patch.Version,
+				ReleaseDate: patch.ReleaseDate,
+				Latest:      patch.PatchVersion == release.LatestPatchVersion,
+				Platforms:   platforms(patch.Artifacts),
+			})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if c := semver.Compare("v"+entries[i].Version, "v"+entries[j].Version); c != 0 {
+			return c > 0
+		}
+		return entries[i].ReleaseDate > entries[j].ReleaseDate
+	})
+	return entries
+}
+
+func platforms(artifacts []aws.Artifact) []string {
+	seen := map[string]bool{}
+	var platforms []string
+	for _, a := range artifacts {
+		platform := a.OS + "/" + a.Arch
+		if !seen[platform] {
+			seen[platform] = true
+			platforms = append(platforms, platform)
+		}
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+func printList(w io.Writer, entries []listEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tRELEASE DATE\tLATEST\tPLATFORMS")
+	for _, entry := range entries {
+		latest := ""
+		if entry.Latest {
+			latest = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entry.Version, entry.ReleaseDate, latest, strings.Join(entry.Platforms, ","))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,102 @@
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/integrii/flaggy"
+
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/cli"
+	"github.com/aws/eks-hybrid/internal/creds"
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+const manifestHelpText = `Examples:
+  # List the Kubernetes versions of the published release manifest
+  nodeadm manifest list
+
+  # Show the artifacts of the latest 1.31 patch release
+  nodeadm manifest show 1.31
+
+  # Show what changed between two patch releases of a private manifest
+  nodeadm manifest diff 1.31.1 1.31.2 --manifest-override https://example.com/manifest.yaml
+
+  # Check a hand-edited manifest before signing it
+  nodeadm manifest validate --manifest file:///root/manifest.yaml --insecure-skip-manifest-verification`
+
+func NewManifestCommand() cli.Command {
+	container := cli.NewCommandContainer("manifest", "Inspect, compare and validate release manifests")
+	container.Flaggy().AdditionalHelpAppend = manifestHelpText
+	container.AddCommand(NewListCommand())
+	container.AddCommand(NewShowCommand())
+	container.AddCommand(NewDiffCommand())
+	container.AddCommand(NewValidateCommand())
+	return container.AsCommand()
+}
+
+// manifestFlags select the release manifest a subcommand reads and how its signature is verified.
+type manifestFlags struct {
+	cmd *flaggy.Subcommand
+	// flag is the long name of the flag selecting the manifest: --manifest-override like in
+	// the other commands, or --manifest for validate.
+	flag                             string
+	manifest                         string
+	region                           string
+	manifestKeys                     []string
+	insecureSkipManifestVerification bool
+}
+
+func (f *manifestFlags) register(cmd *flaggy.Subcommand, flag string) {
+	f.cmd, f.flag = cmd, flag
+	cmd.String(&f.manifest, "m", flag, "URI of the release manifest to read instead of the published one. Supports file:// for local files, https:// for remote files and s3:// for S3 objects. Can be repeated to list mirrors tried in order.")
+	cmd.String(&f.region, "r", "region", "AWS region used to pick the published manifest of its partition and to read s3:// URIs.")
+	cmd.StringSlice(&f.manifestKeys, "", "manifest-key", "Path to an armored OpenPGP public key trusted to sign the release manifest, in addition to the keys in "+aws.TrustedKeysDir+". Can be repeated.")
+	cmd.Bool(&f.insecureSkipManifestVerification, "", "insecure-skip-manifest-verification", "Accept release manifests without verifying their signature.")
+}
+
+// read reads the selected manifest and verifies its signature.
+func (f *manifestFlags) read(ctx context.Context) (*aws.ManifestFile, error) {
+	return aws.GetReleaseManifestFile(ctx, f.manifest, f.region, f.options()...)
+}
+
+// readUnverified reads the selected manifest and its signature, without verifying or parsing it.
+func (f *manifestFlags) readUnverified(ctx context.Context) (*aws.ManifestFile, error) {
+	return aws.ReadReleaseManifestFile(ctx, f.manifest, f.region, f.options()...)
+}
+
+// verify checks the signature of a manifest read with readUnverified.
+func (f *manifestFlags) verify(ctx context.Context, file *aws.ManifestFile) error {
+	return file.Verify(ctx, f.options()...)
+}
+
+func (f *manifestFlags) options() []aws.ManifestOption {
+	// Manifests are usually inspected outside the nodes, s3:// URIs are read with the default credentials.
+	util.SetS3ClientProvider(creds.S3ClientProvider("", "", f.region))
+	opts := aws.ManifestVerificationOptions(f.manifestKeys, f.insecureSkipManifestVerification)
+	if overrides := cli.SubcommandFlag(f.cmd, "m", f.flag); len(overrides) > 0 {
+		f.manifest = overrides[0]
+		opts = append(opts, aws.WithManifestMirrors(overrides[1:]...))
+	}
+	return opts
+}
+
+func validateOutput(output string) error {
+	if output != outputText && output != outputJSON {
+		return fmt.Errorf("invalid output format %s. Allowed values: [%s, %s]", output, outputText, outputJSON)
+	}
+	return nil
+}
+
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,88 @@
+package manifest
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/integrii/flaggy"
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/cli"
+	"github.com/aws/eks-hybrid/internal/logger"
+	"github.com/aws/eks-hybrid/internal/oci"
+)
+
+type showCmd struct {
+	flaggy            *flaggy.Subcommand
+	manifest          manifestFlags
+	kubernetesVersion string
+	output            string
+}
+
+func NewShowCommand() cli.Command {
+	cmd := showCmd{output: outputText}
+	cmd.flaggy = flaggy.NewSubcommand("show")
+	cmd.flaggy.Description = "Show the artifacts of a Kubernetes version"
+	cmd.flaggy.AddPositionalValue(&cmd.kubernetesVersion, "KUBERNETES_VERSION", 1, true, "The major.minor[.patch] version of Kubernetes to show. major.minor shows the latest patch release.")
+	cmd.manifest.register(cmd.flaggy, "manifest-override")
+	cmd.flaggy.String(&cmd.output, "o", "output", fmt.Sprintf("Output format. Allowed values: [%s, %s].", outputText, outputJSON))
+	return &cmd
+}
+
+func (c *showCmd) Flaggy() *flaggy.Subcommand {
+	return c.flaggy
+}
+
+func (c *showCmd) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
+	if err := validateOutput(c.output); err != nil {
+		return err
+	}
+	ctx := logger.NewContext(context.Background(), log)
+
+	manifest, err := c.manifest.read(ctx)
+	if err != nil {
+		return err
+	}
+	source, err := aws.GetReleaseSourceFromManifest(c.kubernetesVersion, manifest.Manifest)
+	if err != nil {
+		return err
+	}
+
+	if c.output == outputJSON {
+		return printJSON(source.Eks)
+	}
+	return printRelease(os.Stdout, source.Eks)
+}
+
+func printRelease(w io.Writer, release aws.EksPatchRelease) error {
+	fmt.Fprintf(w, "Version:       %s\n", release.Version)
+	fmt.Fprintf(w, "Release date:  %s\n\n", release.ReleaseDate)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tPLATFORM\tURI\tCHECKSUM")
+	for _, a := range release.Artifacts {
+		fmt.Fprintf(tw, "%s\t%s/%s\t%s\t%s\n", a.Name, a.OS, a.Arch, a.DownloadURI(), checksumOf(a))
+	}
+	return tw.Flush()
+}
+
+// checksumOf describes where the checksum of an artifact comes from.
+func checksumOf(a aws.Artifact) string {
+	if algorithm, digest, err := a.InlineDigest(); err == nil && digest != nil {
+		return string(algorithm) + ":" + hex.EncodeToString(digest)
+	}
+	if a.ChecksumURI != "" {
+		return a.ChecksumURI
+	}
+	if strings.HasPrefix(a.URI, oci.Scheme) {
+		if ref, err := oci.ParseReference(a.URI); err == nil {
+			return ref.Digest
+		}
+	}
+	return "-"
+}
@@ -0,0 +1,71 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/integrii/flaggy"
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/cli"
+	"github.com/aws/eks-hybrid/internal/errors"
+	"github.com/aws/eks-hybrid/internal/logger"
+)
+
+type validateCmd struct {
+	flaggy   *flaggy.Subcommand
+	manifest manifestFlags
+	output   string
+}
+
+func NewValidateCommand() cli.Command {
+	cmd := validateCmd{output: outputText}
+	cmd.flaggy = flaggy.NewSubcommand("validate")
+	cmd.flaggy.Description = "Check that a release manifest is well formed"
+	cmd.manifest.register(cmd.flaggy, "manifest")
+	cmd.flaggy.String(&cmd.output, "o", "output", fmt.Sprintf("Output format. Allowed values: [%s, %s].", outputText, outputJSON))
+	return &cmd
+}
+
+func (c *validateCmd) Flaggy() *flaggy.Subcommand {
+	return c.flaggy
+}
+
+func (c *validateCmd) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
+	if err := validateOutput(c.output); err != nil {
+		return err
+	}
+	ctx := logger.NewContext(context.Background(), log)
+
+	// A manifest that isn't signed yet or doesn't parse is reported like any other issue.
+	manifest, err := c.manifest.readUnverified(ctx)
+	if err != nil {
+		return err
+	}
+	issues := aws.ValidateManifest(manifest.Data)
+	if err := c.manifest.verify(ctx, manifest); err != nil {
+		issues = append([]aws.ManifestIssue{{Path: "signature", Message: err.Error()}}, issues...)
+	}
+
+	if c.output == outputJSON {
+		if issues == nil {
+			issues = []aws.ManifestIssue{}
+		}
+		err = printJSON(issues)
+	} else {
+		for _, issue := range issues {
+			fmt.Fprintln(os.Stdout, issue)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(issues) > 0 {
+		return errors.NewSilent(fmt.Errorf("manifest %s has %d issues", manifest.URI, len(issues)))
+	}
+	log.Info("Manifest is valid", zap.String("manifest", manifest.URI))
+	return nil
+}
@@ -0,0 +1,77 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/integrii/flaggy"
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/cli"
+	"github.com/aws/eks-hybrid/internal/daemon"
+	"github.com/aws/eks-hybrid/internal/flows"
+	"github.com/aws/eks-hybrid/internal/logger"
+	"github.com/aws/eks-hybrid/internal/snapshot"
+)
+
+const rollbackHelpText = `Examples:
+  # Restore the components replaced by the last upgrade
+  nodeadm rollback
+
+Rollback restores the binaries, configuration files and installed components tracking
+saved before the last upgrade and restarts containerd and kubelet.
+Containerd packages upgraded through the package manager are not rolled back.`
+
+func NewCommand() cli.Command {
+	cmd := command{}
+
+	fc := flaggy.NewSubcommand("rollback")
+	fc.Description = "Restore the components replaced by the last upgrade"
+	fc.AdditionalHelpAppend = rollbackHelpText
+	cmd.flaggy = fc
+
+	return &cmd
+}
+
+type command struct {
+	flaggy *flaggy.Subcommand
+}
+
+func (c *command) Flaggy() *flaggy.Subcommand {
+	return c.flaggy
+}
+
+func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
+	ctx := context.Background()
+	ctx = logger.NewContext(ctx, log)
+
+	root, err := cli.IsRunningAsRoot()
+	if err != nil {
+		return err
+	}
+	if !root {
+		return cli.ErrMustRunAsRoot
+	}
+
+	log.Info("Loading upgrade snapshot...", zap.String("dir", snapshot.UpgradeDir))
+	snap, err := snapshot.Load(snapshot.UpgradeDir)
+	if err != nil && os.IsNotExist(err) {
+		return fmt.Errorf("no upgrade snapshot found, nodeadm upgrade must run before rolling back")
+	} else if err != nil {
+		return err
+	}
+
+	log.Info("Creating daemon manager...")
+	daemonManager, err := daemon.NewDaemonManager()
+	if err != nil {
+		return err
+	}
+	defer daemonManager.Close()
+
+	rollbacker := &flows.Rollbacker{
+		DaemonManager: daemonManager,
+		Logger:        log,
+	}
+	return rollbacker.Run(ctx, snap)
+}
@@ -0,0 +1,119 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/integrii/flaggy"
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/cli"
+	"github.com/aws/eks-hybrid/internal/configprovider"
+	"github.com/aws/eks-hybrid/internal/daemon"
+	"github.com/aws/eks-hybrid/internal/logger"
+	"github.com/aws/eks-hybrid/internal/status"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+const statusHelpText = `Examples:
+  # Show the status of the node
+  nodeadm status
+
+  # Show the status of the node in JSON format
+  nodeadm status -o json
+
+  # Use the AWS config path from the node configuration when using IAM Roles Anywhere
+  nodeadm status --config-source file:///root/nodeConfig.yaml`
+
+func NewCommand() cli.Command {
+	cmd := command{
+		output:  outputText,
+		timeout: 30 * time.Second,
+	}
+
+	fc := flaggy.NewSubcommand("status")
+	fc.Description = "Show installed components, daemons, credentials and node registration status"
+	fc.AdditionalHelpAppend = statusHelpText
+	fc.String(&cmd.output, "o", "output", fmt.Sprintf("Output format. Allowed values: [%s, %s].", outputText, outputJSON))
+	fc.StringSlice(&cmd.configSources, "c", "config-source", "Optional source of node configuration. The format is a URI with supported schemes: [file, imds, https, s3]. A file:// directory merges its YAML files in lexical order. An https:// or s3:// source can pin its digest with a #sha256=<hex> fragment. Can be repeated, later sources override earlier ones.")
+	fc.StringSlice(&cmd.configCABundles, "", "config-ca-bundle", "Path to a PEM encoded CA bundle trusted, in addition to the system roots, to fetch https:// and s3:// config sources. Can be repeated.")
+	fc.Duration(&cmd.timeout, "t", "timeout", "Maximum time to collect the status. Input follows duration format. Example: 1m")
+	cmd.flaggy = fc
+
+	return &cmd
+}
+
+type command struct {
+	flaggy          *flaggy.Subcommand
+	output          string
+	configSources   []string
+	configCABundles []string
+	timeout         time.Duration
+}
+
+func (c *command) Flaggy() *flaggy.Subcommand {
+	return c.flaggy
+}
+
+func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
+	ctx := context.Background()
+	ctx = logger.NewContext(ctx, log)
+	cli.ConfigSources(c.flaggy, &c.configSources)
+
+	if c.output != outputText && c.output != outputJSON {
+		return fmt.Errorf("invalid output format %s. Allowed values: [%s, %s]", c.output, outputText, outputJSON)
+	}
+
+	root, err := cli.IsRunningAsRoot()
+	if err != nil {
+		return err
+	}
+	if !root {
+		return cli.ErrMustRunAsRoot
+	}
+
+	collector := &status.Collector{}
+	if len(c.configSources) > 0 {
+		// Only paths are read from the config, its secret references aren't resolved.
+		provider, err := configprovider.BuildLayeredConfigProvider(c.configSources, configprovider.WithCABundles(c.configCABundles...), configprovider.WithoutSecretResolution())
+		if err != nil {
+			return err
+		}
+		nodeConfig, err := provider.Provide()
+		if err != nil {
+			return err
+		}
+		if nodeConfig.IsIAMRolesAnywhere() {
+			collector.AwsConfigPath = nodeConfig.Spec.Hybrid.IAMRolesAnywhere.AwsConfigPath
+		}
+	}
+
+	daemonManager, err := daemon.NewDaemonManager()
+	if err != nil {
+		return err
+	}
+	defer daemonManager.Close()
+	collector.DaemonManager = daemonManager
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	nodeStatus, err := collector.Collect(ctx)
+	if err != nil {
+		return err
+	}
+
+	if c.output == outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(nodeStatus)
+	}
+	return status.PrintText(os.Stdout, nodeStatus, time.Now())
+}
@@ -1,29 +1,37 @@
 package sync_artifacts
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"net/http"
+	"io"
 	"os"
 	"runtime"
 	"strings"
 	"time"
 
-	awsSDKv2 "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/integrii/flaggy"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"sigs.k8s.io/yaml"
 
+	"github.com/aws/eks-hybrid/internal/artifact"
 	"github.com/aws/eks-hybrid/internal/aws"
 	"github.com/aws/eks-hybrid/internal/cli"
+	"github.com/aws/eks-hybrid/internal/destination"
 	"github.com/aws/eks-hybrid/internal/logger"
+	"github.com/aws/eks-hybrid/internal/oci"
+	"github.com/aws/eks-hybrid/internal/util"
 )
 
+// destinationPasswordEnv is the environment variable holding the password of --destination-username,
+// so it never shows up in the process list or shell history.
+const destinationPasswordEnv = "NODEADM_DESTINATION_PASSWORD"
+
 const syncArtifactsHelpText = `Examples:
   # Sync all Linux ARM64 dependencies for Kubernetes version 1.31 to S3
   nodeadm sync-artifacts 1.31 --arch arm64 --s3-bucket my-private-bucket --s3-prefix eks-deps/v1.31
@@ -34,6 +42,18 @@ const syncArtifactsHelpText = `Examples:
   # Sync all Linux dependencies corresponding to host system's architecture to a non-default region in S3
   nodeadm sync-artifacts 1.34 --region ap-south-1 --s3-bucket my-private-bucket --s3-prefix eks-deps/ap-south-1/v1.34
 
+  # Sync to a private S3 prefix, read by the nodes with their own credentials
+  nodeadm sync-artifacts 1.34 --destination s3://my-private-bucket/eks-deps/v1.34
+
+  # Sync to a directory served by a web server
+  nodeadm sync-artifacts 1.34 --destination file:///srv/www/eks-deps --base-url https://mirror.example.com/eks-deps
+
+  # Sync to an Artifactory, Nexus or WebDAV repository accepting PUT requests
+  NODEADM_DESTINATION_PASSWORD=secret nodeadm sync-artifacts 1.34 --destination https://artifactory.example.com/eks-deps --destination-username ci
+
+  # Sync to an OCI registry such as Harbor
+  nodeadm sync-artifacts 1.34 --destination oci://harbor.example.com/eks/hybrid-artifacts --destination-username robot
+
 Documentation:
   https://docs.aws.amazon.com/eks/latest/userguide/hybrid-nodes-nodeadm.html#_syncartifacts`
 
@@ -46,28 +66,40 @@ func NewCommand() cli.Command {
 	}
 
 	fc := flaggy.NewSubcommand("sync-artifacts")
-	fc.Description = "Sync EKS hybrid node dependencies directly to S3 for private installation"
+	fc.Description = "Sync EKS hybrid node dependencies to S3, a directory, an HTTP server or an OCI registry for private installation"
 	fc.AdditionalHelpAppend = syncArtifactsHelpText
 	fc.AddPositionalValue(&cmd.kubernetesVersion, "KUBERNETES_VERSION", 1, true, "The major[.minor[.patch]] version of Kubernetes to sync dependencies for.")
 	fc.String(&cmd.arch, "a", "arch", "Target architecture for artifacts.")
 	fc.String(&cmd.region, "r", "region", "AWS region for downloading regional artifacts.")
-	fc.String(&cmd.s3Bucket, "", "s3-bucket", "S3 bucket to sync the dependencies to (required).")
-	fc.String(&cmd.s3Prefix, "", "s3-prefix", "S3 key prefix for the synced artifacts (required).")
+	fc.String(&cmd.s3Bucket, "", "s3-bucket", "S3 bucket to sync the dependencies to.")
+	fc.String(&cmd.s3Prefix, "", "s3-prefix", "S3 key prefix for the synced artifacts.")
+	fc.String(&cmd.destination, "", "destination", "Where to sync the dependencies to instead of a public S3 prefix: a file://, http://, https://, oci:// or s3:// URI. Artifacts synced to s3:// are private and read with the node credentials.")
+	fc.String(&cmd.baseURL, "", "base-url", "URL a file:// destination is served from. Defaults to file:// URIs in the generated manifest.")
+	fc.String(&cmd.destinationUsername, "", "destination-username", "Username for an HTTP or OCI destination. The password is read from "+destinationPasswordEnv+".")
+	fc.Bool(&cmd.plainHTTP, "", "plain-http", "Use http instead of https for an OCI destination.")
 	fc.Duration(&cmd.timeout, "t", "timeout", "Maximum sync command duration.")
+	fc.StringSlice(&cmd.manifestKeys, "", "manifest-key", "Path to an armored OpenPGP public key trusted to sign the release manifest, in addition to the keys in "+aws.TrustedKeysDir+". Can be repeated.")
+	fc.Bool(&cmd.insecureSkipManifestVerification, "", "insecure-skip-manifest-verification", "Accept release manifests without verifying their signature.")
 	cmd.flaggy = fc
 
 	return &cmd
 }
 
 type command struct {
-	flaggy            *flaggy.Subcommand
-	kubernetesVersion string
-	arch              string
-	os                string
-	region            string
-	s3Bucket          string
-	s3Prefix          string
-	timeout           time.Duration
+	flaggy                           *flaggy.Subcommand
+	kubernetesVersion                string
+	arch                             string
+	os                               string
+	region                           string
+	s3Bucket                         string
+	s3Prefix                         string
+	destination                      string
+	baseURL                          string
+	destinationUsername              string
+	plainHTTP                        bool
+	timeout                          time.Duration
+	manifestKeys                     []string
+	insecureSkipManifestVerification bool
 }
 
 func (c *command) Flaggy() *flaggy.Subcommand {
@@ -81,78 +113,115 @@ func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
+	if c.destination != "" && (c.s3Bucket != "" || c.s3Prefix != "") {
+		return fmt.Errorf("--destination can't be combined with --s3-bucket or --s3-prefix")
+	}
+	if c.destination == "" && (c.s3Bucket == "" || c.s3Prefix == "") {
+		return fmt.Errorf("either --s3-bucket and --s3-prefix, or --destination is required")
+	}
+
 	log.Info("Validating Kubernetes version", zap.String("version", c.kubernetesVersion))
 
 	// Create a Source for all AWS managed artifacts
-	awsSource, err := aws.GetLatestSource(ctx, c.kubernetesVersion, c.region)
+	awsSource, err := aws.GetLatestSource(ctx, c.kubernetesVersion, c.region, aws.ManifestVerificationOptions(c.manifestKeys, c.insecureSkipManifestVerification)...)
 	if err != nil {
 		return err
 	}
 	log.Info("Using Kubernetes version", zap.String("version", awsSource.Eks.Version))
 
-	// Validate S3 parameters - both are required now
-	if c.s3Bucket == "" {
-		return fmt.Errorf("--s3-bucket is required")
-	}
-	if c.s3Prefix == "" {
-		return fmt.Errorf("--s3-prefix is required")
-	}
-
-	// Load AWS config once
-	log.Info("Loading AWS configuration", zap.String("region", c.region))
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(c.region))
+	syncTimestamp := time.Now().Unix()
+	dest, err := c.newDestination(ctx, log, awsSource, syncTimestamp)
 	if err != nil {
-		return fmt.Errorf("failed to load AWS config: %w", err)
+		return err
 	}
 
-	// Create S3 service client once
-	svc := s3.NewFromConfig(cfg)
-
-	// Validate S3 bucket exists and is accessible
-	log.Info("Validating S3 bucket exists", zap.String("bucket", c.s3Bucket))
-	if err := validateS3Bucket(ctx, svc, c.s3Bucket); err != nil {
-		return fmt.Errorf("S3 bucket validation failed: %w", err)
+	log.Info("Validating destination")
+	if err := dest.Validate(ctx); err != nil {
+		return fmt.Errorf("destination validation failed: %w", err)
 	}
-	log.Info("S3 bucket validation successful", zap.String("bucket", c.s3Bucket))
+	log.Info("Destination validation successful")
 
 	downloader := &Downloader{
 		AwsSource:     awsSource,
 		Arch:          c.arch,
 		OS:            c.os,
 		Region:        c.region,
-		S3Bucket:      c.s3Bucket,
-		S3Prefix:      c.s3Prefix,
+		Destination:   dest,
 		Logger:        log,
-		S3Client:      svc,
-		SyncTimestamp: time.Now().Unix(),
+		SyncTimestamp: syncTimestamp,
 	}
 
 	return downloader.Run(ctx)
 }
 
+func (c *command) newDestination(ctx context.Context, log *zap.Logger, awsSource aws.Source, syncTimestamp int64) (destination.Destination, error) {
+	bucket, prefix, private := c.s3Bucket, c.s3Prefix, false
+	if strings.HasPrefix(c.destination, util.S3Scheme) {
+		var err error
+		if bucket, prefix, err = util.ParseS3URI(c.destination); err != nil {
+			return nil, err
+		}
+		private = true
+	} else if c.destination != "" {
+		return destination.New(c.destination, destination.Options{
+			BaseURL:   c.baseURL,
+			Username:  c.destinationUsername,
+			Password:  os.Getenv(destinationPasswordEnv),
+			PlainHTTP: c.plainHTTP,
+			Tag:       fmt.Sprintf("%s-%s-%s-%d", awsSource.Eks.Version, c.os, c.arch, syncTimestamp),
+		})
+	}
+
+	// Load AWS config once
+	log.Info("Loading AWS configuration", zap.String("region", c.region))
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(c.region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	dnsSuffix := awsSource.RegionInfo.DnsSuffix
+	if dnsSuffix == "" {
+		partition := aws.GetPartitionFromRegionFallback(c.region)
+		dnsSuffix = aws.GetPartitionDNSSuffix(partition)
+	}
+
+	return &destination.S3{
+		Client:    s3.NewFromConfig(cfg),
+		Bucket:    bucket,
+		Prefix:    prefix,
+		Region:    c.region,
+		DnsSuffix: dnsSuffix,
+		Private:   private,
+	}, nil
+}
+
 type Downloader struct {
 	AwsSource     aws.Source
 	Arch          string
 	OS            string
 	Region        string
-	S3Bucket      string
-	S3Prefix      string
+	Destination   destination.Destination
 	Logger        *zap.Logger
-	S3Client      *s3.Client
 	SyncTimestamp int64
 }
 
 type ArtifactInfo struct {
-	Name        string
-	URL         string
-	ChecksumURL string
-	LocalPath   string
+	Name      string
+	URL       string
+	LocalPath string
+	// Release is the manifest artifact the downloaded content is verified against. It is nil
+	// for the SSM installer and its signature, which are verified with the signature on install.
+	Release *aws.Artifact
+
+	// SyncedURI and SyncedChecksumURI are where the artifact and its checksum were uploaded to.
+	SyncedURI         string
+	SyncedChecksumURI string
+	// Sha256 is the digest of the synced artifact, written inline in the generated manifest.
+	Sha256 []byte
 }
 
 func (d *Downloader) Run(ctx context.Context) error {
-	d.Logger.Info("Starting dependency sync to S3",
-		zap.String("s3Bucket", d.S3Bucket),
-		zap.String("s3Prefix", d.S3Prefix),
+	d.Logger.Info("Starting dependency sync",
 		zap.String("arch", d.Arch),
 		zap.String("os", d.OS),
 	)
@@ -164,21 +233,22 @@ func (d *Downloader) Run(ctx context.Context) error {
 
 	d.Logger.Info("Found artifacts to sync", zap.Int("count", len(artifacts)))
 
-	for _, artifact := range artifacts {
-		if err := d.syncArtifactToS3(ctx, artifact); err != nil {
-			return errors.Wrapf(err, "sync artifact %s", artifact.Name)
+	for i := range artifacts {
+		if err := d.syncArtifact(ctx, &artifacts[i]); err != nil {
+			return errors.Wrapf(err, "sync artifact %s", artifacts[i].Name)
 		}
 	}
 
-	// Generate local manifest with custom S3 URIs
+	if err := d.Destination.Finish(ctx); err != nil {
+		return errors.Wrap(err, "finishing sync")
+	}
+
+	// Generate local manifest with the synced URIs
 	if err := d.generateCustomManifest(artifacts); err != nil {
 		return errors.Wrap(err, "generating custom manifest")
 	}
 
-	d.Logger.Info("Successfully synced all dependencies to S3",
-		zap.String("bucket", d.S3Bucket),
-		zap.String("prefix", d.S3Prefix),
-		zap.Int("artifacts", len(artifacts)))
+	d.Logger.Info("Successfully synced all dependencies", zap.Int("artifacts", len(artifacts)))
 
 	return nil
 }
@@ -191,10 +261,10 @@ func (d *Downloader) collectArtifacts() ([]ArtifactInfo, error) {
 	for _, name := range eksArtifacts {
 		if artifact := d.findArtifact(d.AwsSource.Eks.Artifacts, name); artifact != nil {
 			artifacts = append(artifacts, ArtifactInfo{
-				Name:        name,
-				URL:         artifact.URI,
-				ChecksumURL: artifact.ChecksumURI,
-				LocalPath:   fmt.Sprintf("eks/%s", name),
+				Name:      name,
+				URL:       artifact.URI,
+				LocalPath: fmt.Sprintf("eks/%s", name),
+				Release:   artifact,
 			})
 		}
 	}
@@ -202,10 +272,10 @@ func (d *Downloader) collectArtifacts() ([]ArtifactInfo, error) {
 	// IAM Roles Anywhere artifacts
 	if artifact := d.findArtifact(d.AwsSource.Iam.Artifacts, "aws_signing_helper"); artifact != nil {
 		artifacts = append(artifacts, ArtifactInfo{
-			Name:        "aws_signing_helper",
-			URL:         artifact.URI,
-			ChecksumURL: artifact.ChecksumURI,
-			LocalPath:   "iam-ra/aws_signing_helper",
+			Name:      "aws_signing_helper",
+			URL:       artifact.URI,
+			LocalPath: "iam-ra/aws_signing_helper",
+			Release:   artifact,
 		})
 	}
 
@@ -221,10 +291,9 @@ func (d *Downloader) collectArtifacts() ([]ArtifactInfo, error) {
 		// Add the signature file
 		sigURL := installerURL + ".sig"
 		artifacts = append(artifacts, ArtifactInfo{
-			Name:        "ssm-setup-cli.sig",
-			URL:         sigURL,
-			ChecksumURL: "", // Signature files don't have checksums
-			LocalPath:   "ssm/ssm-setup-cli.sig",
+			Name:      "ssm-setup-cli.sig",
+			URL:       sigURL,
+			LocalPath: "ssm/ssm-setup-cli.sig",
 		})
 	} else {
 		d.Logger.Warn("Failed to get SSM installer URL", zap.Error(err))
@@ -246,59 +315,81 @@ func (d *Downloader) findArtifact(artifacts []aws.Artifact, name string) *aws.Ar
 	return nil
 }
 
-func (d *Downloader) syncArtifactToS3(ctx context.Context, artifact ArtifactInfo) error {
-	d.Logger.Info("Syncing artifact to S3",
-		zap.String("name", artifact.Name),
-		zap.String("url", artifact.URL))
-
-	// Download and upload main artifact
-	s3Key := fmt.Sprintf("%s/%d/%s", strings.TrimSuffix(d.S3Prefix, "/"), d.SyncTimestamp, artifact.LocalPath)
-	if err := d.streamToS3(ctx, d.S3Client, artifact.URL, s3Key); err != nil {
-		return errors.Wrapf(err, "streaming %s to S3", artifact.Name)
+func (d *Downloader) syncArtifact(ctx context.Context, info *ArtifactInfo) error {
+	d.Logger.Info("Syncing artifact",
+		zap.String("name", info.Name),
+		zap.String("url", info.URL))
+
+	// Content that doesn't match the checksum in the manifest is never uploaded, nodes would
+	// trust it through the sha256 written in the generated manifest.
+	var algorithm artifact.Algorithm
+	var expected []byte
+	if info.Release != nil {
+		var err error
+		if algorithm, expected, err = info.Release.ExpectedChecksum(ctx); err != nil {
+			return errors.Wrapf(err, "getting checksum of %s", info.Name)
+		}
 	}
 
-	// Download and upload checksum if available
-	if artifact.ChecksumURL != "" {
-		checksumKey := s3Key + ".sha256"
-		if err := d.streamToS3(ctx, d.S3Client, artifact.ChecksumURL, checksumKey); err != nil {
-			d.Logger.Warn("Failed to sync checksum to S3",
-				zap.String("artifact", artifact.Name),
+	// Download, verify and upload main artifact
+	path := fmt.Sprintf("%d/%s", d.SyncTimestamp, info.LocalPath)
+	uri, digest, err := d.transfer(ctx, info.URL, path, algorithm, expected)
+	if err != nil {
+		return errors.Wrapf(err, "transferring %s", info.Name)
+	}
+	info.SyncedURI = uri
+	info.Sha256 = digest
+
+	// Upload a checksum file for nodeadm versions that don't read inline digests. The digest
+	// of an OCI blob is its checksum.
+	if info.Release != nil && !strings.HasPrefix(uri, oci.Scheme) {
+		checksum := []byte(fmt.Sprintf("%x  %s\n", digest, info.Name))
+		checksumURI, err := d.Destination.Upload(ctx, path+".sha256", bytes.NewReader(checksum), int64(len(checksum)))
+		if err != nil {
+			d.Logger.Warn("Failed to sync checksum",
+				zap.String("artifact", info.Name),
 				zap.Error(err))
+		} else {
+			info.SyncedChecksumURI = checksumURI
 		}
 	}
 
 	return nil
 }
 
-func (d *Downloader) streamToS3(ctx context.Context, svc *s3.Client, url, s3Key string) error {
-	// Download from source URL
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// transfer downloads url to a temporary file, as destinations may need to read the content
+// more than once, and uploads it to path in the destination once it matches the expected
+// checksum computed with algorithm. It returns the uploaded URI and the sha256 digest of the
+// content. Content without an expected checksum is uploaded as is.
+func (d *Downloader) transfer(ctx context.Context, url, path string, algorithm artifact.Algorithm, expected []byte) (string, []byte, error) {
+	body, err := util.GetHttpFileReader(ctx, url)
 	if err != nil {
-		return errors.Wrap(err, "creating request")
+		return "", nil, err
 	}
+	defer body.Close()
 
-	resp, err := http.DefaultClient.Do(req)
+	tmp, err := os.CreateTemp("", "nodeadm-sync-")
 	if err != nil {
-		return errors.Wrap(err, "making request")
+		return "", nil, err
 	}
-	defer resp.Body.Close()
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	sha := sha256.New()
+	verified := sha
+	if expected != nil && algorithm != artifact.SHA256 {
+		verified = algorithm.New()
+	}
+	size, err := io.Copy(io.MultiWriter(tmp, sha, verified), body)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "downloading %s", url)
+	}
+	if actual := verified.Sum(nil); expected != nil && !bytes.Equal(actual, expected) {
+		return "", nil, fmt.Errorf("downloaded content doesn't match %s checksum %x, got %x", algorithm, expected, actual)
 	}
 
-	// Create S3 Manager uploader
-	uploader := manager.NewUploader(svc)
-
-	// Upload using S3 Manager with public-read ACL
-	_, err = uploader.Upload(ctx, &s3.PutObjectInput{
-		Bucket: awsSDKv2.String(d.S3Bucket),
-		Key:    awsSDKv2.String(s3Key),
-		Body:   resp.Body,
-		ACL:    types.ObjectCannedACLPublicRead,
-	})
-
-	return errors.Wrap(err, "uploading to S3 using manager")
+	uri, err := d.Destination.Upload(ctx, path, tmp, size)
+	return uri, sha.Sum(nil), err
 }
 
 func (d *Downloader) getSSMInstallerURL() (string, error) {
@@ -329,34 +420,19 @@ func (d *Downloader) detectPlatformVariant() (string, error) {
 }
 
 func (d *Downloader) generateCustomManifest(artifacts []ArtifactInfo) error {
-	dnsSuffix := d.AwsSource.RegionInfo.DnsSuffix
-	if dnsSuffix == "" {
-		partition := aws.GetPartitionFromRegionFallback(d.Region)
-		dnsSuffix = aws.GetPartitionDNSSuffix(partition)
-	}
-
-	// Create base S3 URL
-	baseS3URL := fmt.Sprintf("https://%s.s3.%s.%s/%s/%d",
-		d.S3Bucket, d.Region, dnsSuffix, strings.TrimSuffix(d.S3Prefix, "/"), d.SyncTimestamp)
-
-	// Build artifact list with custom S3 URIs
+	// Build artifact list with the synced URIs
 	var eksArtifacts []aws.Artifact
 	var iamArtifacts []aws.Artifact
 	var ssmArtifacts []aws.Artifact
 
 	for _, artifact := range artifacts {
-		customURI := fmt.Sprintf("%s/%s", baseS3URL, artifact.LocalPath)
-		customChecksumURI := ""
-		if artifact.ChecksumURL != "" {
-			customChecksumURI = fmt.Sprintf("%s/%s.sha256", baseS3URL, artifact.LocalPath)
-		}
-
 		awsArtifact := aws.Artifact{
 			Name:        artifact.Name,
 			Arch:        d.Arch,
 			OS:          d.OS,
-			URI:         customURI,
-			ChecksumURI: customChecksumURI,
+			URI:         artifact.SyncedURI,
+			ChecksumURI: artifact.SyncedChecksumURI,
+			Sha256:      hex.EncodeToString(artifact.Sha256),
 		}
 
 		// Categorize artifacts
@@ -431,9 +507,10 @@ func (d *Downloader) generateCustomManifest(artifacts []ArtifactInfo) error {
 		return errors.Wrapf(err, "writing manifest file %s", filename)
 	}
 
-	d.Logger.Info("Generated custom manifest with S3 URIs",
-		zap.String("filename", filename),
-		zap.String("baseS3URL", baseS3URL))
+	d.Logger.Info("Generated custom manifest with the synced URIs", zap.String("filename", filename))
+	d.Logger.Info("Sign the generated manifest with a detached signature, next to it with a .sig suffix, using a key trusted by the nodes",
+		zap.String("signature", filename+".sig"),
+		zap.String("trustedKeysDir", aws.TrustedKeysDir))
 
 	return nil
 }
@@ -446,22 +523,3 @@ func (d *Downloader) extractMajorMinor(version string) string {
 	}
 	return version
 }
-
-// validateS3Bucket verifies that the specified S3 bucket exists and is accessible
-func validateS3Bucket(ctx context.Context, svc *s3.Client, bucketName string) error {
-	// Use HeadBucket to check if bucket exists and is accessible
-	_, err := svc.HeadBucket(ctx, &s3.HeadBucketInput{
-		Bucket: awsSDKv2.String(bucketName),
-	})
-	if err != nil {
-		// Check for specific error types to provide better error messages
-		if strings.Contains(err.Error(), "NotFound") || strings.Contains(err.Error(), "NoSuchBucket") || strings.Contains(err.Error(), "MovedPermanently") {
-			return fmt.Errorf("bucket '%s' does not exist", bucketName)
-		}
-		if strings.Contains(err.Error(), "Forbidden") || strings.Contains(err.Error(), "AccessDenied") {
-			return fmt.Errorf("access denied to bucket '%s' - check your AWS credentials and bucket permissions", bucketName)
-		}
-	}
-
-	return nil
-}
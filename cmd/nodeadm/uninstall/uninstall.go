@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/integrii/flaggy"
 	"go.uber.org/zap"
@@ -15,6 +16,7 @@ import (
 	"github.com/aws/eks-hybrid/internal/daemon"
 	"github.com/aws/eks-hybrid/internal/flows"
 	"github.com/aws/eks-hybrid/internal/kubelet"
+	"github.com/aws/eks-hybrid/internal/ledger"
 	"github.com/aws/eks-hybrid/internal/logger"
 	"github.com/aws/eks-hybrid/internal/node"
 	"github.com/aws/eks-hybrid/internal/packagemanager"
@@ -40,26 +42,35 @@ const uninstallHelpText = `Examples:
   # Uninstall all components and skip pod-validation and node-validation pre-flight validation
   nodeadm uninstall --skip node-validation,pod-validation
 
+  # Cordon and drain the node before uninstalling
+  nodeadm uninstall --drain
+
 Documentation:
   https://docs.aws.amazon.com/eks/latest/userguide/hybrid-nodes-nodeadm.html#_uninstall`
 
 func NewCommand() cli.Command {
-	cmd := command{}
+	cmd := command{
+		drainTimeout: node.DefaultDrainTimeout,
+	}
 
 	fc := flaggy.NewSubcommand("uninstall")
 	fc.Description = "Uninstall components installed using the install sub-command"
 	fc.AdditionalHelpAppend = uninstallHelpText
 	fc.StringSlice(&cmd.skipPhases, "s", "skip", "Phases of uninstall to skip. Allowed values: [pod-validation, node-validation].")
 	fc.Bool(&cmd.force, "f", "force", forceWarningText)
+	fc.Bool(&cmd.drain, "", "drain", "Cordon the node and evict its pods before uninstalling, respecting PodDisruptionBudgets.")
+	fc.Duration(&cmd.drainTimeout, "", "drain-timeout", "Maximum time to wait for pods to be evicted when using --drain.")
 	cmd.flaggy = fc
 
 	return &cmd
 }
 
 type command struct {
-	flaggy     *flaggy.Subcommand
-	skipPhases []string
-	force      bool
+	flaggy       *flaggy.Subcommand
+	skipPhases   []string
+	force        bool
+	drain        bool
+	drainTimeout time.Duration
 }
 
 func (c *command) Flaggy() *flaggy.Subcommand {
@@ -99,7 +110,15 @@ func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 		if err != nil {
 			return err
 		}
-		if kubeletStatus == daemon.DaemonStatusRunning {
+		if kubeletStatus == daemon.DaemonStatusRunning && c.drain {
+			drainer, err := node.NewDrainer(log, c.drainTimeout)
+			if err != nil {
+				return err
+			}
+			if err := drainer.Drain(ctx); err != nil {
+				return fmt.Errorf("draining node: %w", err)
+			}
+		} else if kubeletStatus == daemon.DaemonStatusRunning {
 			if !slices.Contains(c.skipPhases, skipPodPreflightCheck) {
 				log.Info("Validating if node has been drained...")
 				if drained, err := node.IsDrained(ctx); err != nil {
@@ -126,8 +145,16 @@ func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 		return err
 	}
 
+	// Nodes installed before the ledger existed don't have one and fall back to
+	// removing the known nodeadm paths.
+	installLedger, err := ledger.Load(ledger.FilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
 	uninstaller := &flows.Uninstaller{
 		Artifacts:      installed.Artifacts,
+		Ledger:         installLedger,
 		DaemonManager:  daemonManager,
 		PackageManager: packageManager,
 		Logger:         log,
@@ -13,15 +13,20 @@ import (
 
 	initCmd "github.com/aws/eks-hybrid/cmd/nodeadm/init"
 	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/bundle"
+	"github.com/aws/eks-hybrid/internal/cache"
 	"github.com/aws/eks-hybrid/internal/cli"
+	"github.com/aws/eks-hybrid/internal/configprovider"
 	"github.com/aws/eks-hybrid/internal/creds"
 	"github.com/aws/eks-hybrid/internal/daemon"
 	"github.com/aws/eks-hybrid/internal/flows"
 	"github.com/aws/eks-hybrid/internal/kubelet"
+	"github.com/aws/eks-hybrid/internal/ledger"
 	"github.com/aws/eks-hybrid/internal/logger"
 	"github.com/aws/eks-hybrid/internal/node"
 	"github.com/aws/eks-hybrid/internal/packagemanager"
 	"github.com/aws/eks-hybrid/internal/tracker"
+	"github.com/aws/eks-hybrid/internal/util"
 )
 
 const (
@@ -39,6 +44,8 @@ func upgradePhases() []string {
 		"init-validation",
 		"pod-validation",
 		"node-validation",
+		flows.ReadinessValidationPhase,
+		flows.RollbackPhase,
 	}
 
 	phases = append(phases, upgradePhases...)
@@ -52,35 +59,72 @@ const upgradeHelpText = `Examples:
   # Upgrade all components with a custom timeout
   nodeadm upgrade 1.31 --config-source file:///root/nodeConfig.yaml --timeout 1h23s
 
+  # Upgrade all components without rolling back if the node doesn't become Ready
+  nodeadm upgrade 1.31 --config-source file:///root/nodeConfig.yaml --skip rollback
+
+  # Cordon and drain the node before upgrading and uncordon it once it is Ready
+  nodeadm upgrade 1.31 --config-source file:///root/nodeConfig.yaml --drain --drain-timeout 15m
+
 Documentation:
   https://docs.aws.amazon.com/eks/latest/userguide/hybrid-nodes-nodeadm.html#_upgrade`
 
 func NewUpgradeCommand() cli.Command {
 	cmd := command{
-		timeout: 20 * time.Minute,
+		timeout:              20 * time.Minute,
+		readinessTimeout:     5 * time.Minute,
+		drainTimeout:         node.DefaultDrainTimeout,
+		downloadParallelism:  aws.DefaultDownloadParallelism,
+		downloadStallTimeout: util.DefaultStallTimeout,
 	}
 
 	fc := flaggy.NewSubcommand("upgrade")
 	fc.Description = "Upgrade components installed using the install sub-command"
 	fc.AdditionalHelpAppend = upgradeHelpText
 	fc.AddPositionalValue(&cmd.kubernetesVersion, "KUBERNETES_VERSION", 1, true, "The major[.minor[.patch]] version of Kubernetes to install.")
-	fc.String(&cmd.configSource, "c", "config-source", "Source of node configuration. The format is a URI with supported schemes: [file, imds].")
+	fc.StringSlice(&cmd.configSources, "c", "config-source", "Source of node configuration. The format is a URI with supported schemes: [file, imds, https, s3]. A file:// directory merges its YAML files in lexical order. An https:// or s3:// source can pin its digest with a #sha256=<hex> fragment. Can be repeated, later sources override earlier ones.")
+	fc.StringSlice(&cmd.configCABundles, "", "config-ca-bundle", "Path to a PEM encoded CA bundle trusted, in addition to the system roots, to fetch https:// and s3:// config sources. Can be repeated.")
 	fc.StringSlice(&cmd.skipPhases, "s", "skip", fmt.Sprintf("Phases of the upgrade to skip. Allowed values: [%s].", strings.Join(upgradePhases(), ", ")))
-	fc.String(&cmd.manifestOverride, "m", "manifest-override", "URI to a manifest file containing custom artifact URLs. Supports file:// for local files and https:// for remote files.")
+	fc.String(&cmd.manifestOverride, "m", "manifest-override", "URI to a manifest file containing custom artifact URLs. Supports file:// for local files, https:// for remote files and s3:// for S3 objects read with the node credentials. Can be repeated to list mirrors tried in order.")
+	fc.String(&cmd.bundle, "", "bundle", "Offline bundle created by nodeadm bundle create to upgrade from without network access. The format is a URI with supported schemes: [file]. Implies --private-mode.")
 	fc.Bool(&cmd.privateMode, "", "private-mode", "Enable private upgrade mode (skips OS packages, requires --manifest-override).")
 	fc.Duration(&cmd.timeout, "t", "timeout", "Maximum upgrade command duration. Input follows duration format. Example: 1h23s")
+	fc.Duration(&cmd.readinessTimeout, "", "readiness-timeout", "Maximum time to wait for the node to be Ready after the upgrade before rolling back.")
+	fc.Bool(&cmd.drain, "", "drain", "Cordon the node and evict its pods before upgrading, respecting PodDisruptionBudgets. The node is uncordoned once it is Ready after the upgrade.")
+	fc.Duration(&cmd.drainTimeout, "", "drain-timeout", "Maximum time to wait for pods to be evicted when using --drain.")
+	fc.Int(&cmd.downloadParallelism, "", "download-parallelism", "Maximum number of artifacts downloaded at the same time.")
+	fc.String(&cmd.downloadBandwidthLimit, "", "download-bandwidth-limit", "Maximum combined bandwidth of the downloads in bytes per second, as a quantity. Example: 512Ki, 2M. Unlimited by default.")
+	fc.Duration(&cmd.downloadStallTimeout, "", "download-stall-timeout", "Time without receiving data after which a download is resumed. 0 disables stall detection.")
+	fc.StringSlice(&cmd.manifestKeys, "", "manifest-key", "Path to an armored OpenPGP public key trusted to sign the release manifest, in addition to the keys in "+aws.TrustedKeysDir+". Can be repeated.")
+	fc.Bool(&cmd.insecureSkipManifestVerification, "", "insecure-skip-manifest-verification", "Accept release manifests without verifying their signature.")
+	fc.Bool(&cmd.noCache, "", "no-cache", "Download every artifact instead of reusing the ones in the local artifact cache.")
+	fc.StringSlice(&cmd.pins, "", "pin", "Pin a component to a version of the manifest, as component=version. Can be repeated. Allowed components: ["+strings.Join(aws.PinnableComponents(), ", ")+"]. The signing helper is pinned to an IAM Roles Anywhere release version, the other components to the Kubernetes patch version they are taken from. Pins are kept by later upgrades until released with --unpin.")
+	fc.StringSlice(&cmd.unpins, "", "unpin", "Release the pin of a component so it follows the Kubernetes release again. Can be repeated.")
 	cmd.flaggy = fc
 	return &cmd
 }
 
 type command struct {
-	flaggy            *flaggy.Subcommand
-	configSource      string
-	skipPhases        []string
-	kubernetesVersion string
-	manifestOverride  string
-	privateMode       bool
-	timeout           time.Duration
+	flaggy                           *flaggy.Subcommand
+	configSources                    []string
+	configCABundles                  []string
+	skipPhases                       []string
+	kubernetesVersion                string
+	manifestOverride                 string
+	manifestMirrors                  []string
+	bundle                           string
+	privateMode                      bool
+	timeout                          time.Duration
+	readinessTimeout                 time.Duration
+	drain                            bool
+	drainTimeout                     time.Duration
+	downloadParallelism              int
+	downloadBandwidthLimit           string
+	downloadStallTimeout             time.Duration
+	noCache                          bool
+	manifestKeys                     []string
+	insecureSkipManifestVerification bool
+	pins                             []string
+	unpins                           []string
 }
 
 func (c *command) Flaggy() *flaggy.Subcommand {
@@ -90,6 +134,8 @@ func (c *command) Flaggy() *flaggy.Subcommand {
 func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 	ctx := context.Background()
 	ctx = logger.NewContext(ctx, log)
+	cli.ConfigSources(c.flaggy, &c.configSources)
+	cli.ManifestOverrides(c.flaggy, &c.manifestOverride, &c.manifestMirrors)
 
 	root, err := cli.IsRunningAsRoot()
 	if err != nil {
@@ -99,15 +145,35 @@ func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 		return cli.ErrMustRunAsRoot
 	}
 
-	if c.configSource == "" {
-		flaggy.ShowHelpAndExit("--config-source is a required flag. The format is a URI with supported schemes: [file, imds]." +
+	if len(c.configSources) == 0 {
+		flaggy.ShowHelpAndExit("--config-source is a required flag. The format is a URI with supported schemes: [file, imds, https, s3]." +
 			" For example on hybrid nodes --config-source file://nodeConfig.yaml")
 	}
 
+	if c.bundle != "" {
+		if c.manifestOverride != "" {
+			return fmt.Errorf("--bundle and --manifest-override cannot be used together")
+		}
+		log.Info("Extracting bundle", zap.String("bundle", c.bundle))
+		offlineBundle, err := bundle.Mount(c.bundle, bundle.ExtractDir)
+		if err != nil {
+			return err
+		}
+		defer offlineBundle.Unmount()
+		c.manifestOverride = offlineBundle.ManifestURI()
+		c.privateMode = true
+	}
+
 	if c.privateMode && c.manifestOverride == "" {
 		return fmt.Errorf("--private-mode requires --manifest-override to be specified")
 	}
 
+	bandwidthLimit, err := util.ParseBandwidth(c.downloadBandwidthLimit)
+	if err != nil {
+		return err
+	}
+	util.SetDownloadLimits(bandwidthLimit, c.downloadStallTimeout)
+
 	log.Info("Loading installed components")
 	installed, err := tracker.GetInstalledArtifacts()
 	if err != nil && os.IsNotExist(err) {
@@ -127,8 +193,8 @@ func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 		}
 	}
 
-	log.Info("Loading configuration...", zap.String("configSource", c.configSource))
-	nodeProvider, err := node.NewNodeProvider(c.configSource, c.skipPhases, log)
+	log.Info("Loading configuration...", zap.Strings("configSource", util.RedactURIs(c.configSources)))
+	nodeProvider, err := node.NewNodeProvider(c.configSources, c.skipPhases, log, configprovider.WithCABundles(c.configCABundles...))
 	if err != nil {
 		return err
 	}
@@ -156,12 +222,18 @@ func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 	if installedCredsProvider != credsProvider {
 		return fmt.Errorf("upgrade does not support changing credential providers. Please uninstall and install with new credential provider")
 	}
+	s3ClientProvider, err := creds.NodeS3ClientProvider(nodeConfig)
+	if err != nil {
+		return err
+	}
+	util.SetS3ClientProvider(s3ClientProvider)
 
 	var awsSource aws.Source
+	manifestOpts := append(aws.ManifestVerificationOptions(c.manifestKeys, c.insecureSkipManifestVerification), aws.WithManifestMirrors(c.manifestMirrors...))
 	// Use manifest override if provided, otherwise use default AWS source
 	if c.manifestOverride != "" {
 		log.Info("Using manifest override", zap.String("manifest", c.manifestOverride))
-		awsSource, err = aws.GetLatestSourceFromManifest(ctx, c.kubernetesVersion, region, c.manifestOverride)
+		awsSource, err = aws.GetLatestSourceFromManifest(ctx, c.kubernetesVersion, region, c.manifestOverride, manifestOpts...)
 		if err != nil {
 			return err
 		}
@@ -169,12 +241,25 @@ func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 	} else {
 		log.Info("Validating Kubernetes version", zap.Reflect("kubernetes version", c.kubernetesVersion))
 		// Create a Source for all AWS managed artifacts.
-		awsSource, err = aws.GetLatestSource(ctx, c.kubernetesVersion, region)
+		awsSource, err = aws.GetLatestSource(ctx, c.kubernetesVersion, region, manifestOpts...)
 		if err != nil {
 			return err
 		}
 		log.Info("Using Kubernetes version", zap.Reflect("kubernetes version", awsSource.Eks.Version))
 	}
+	pins, err := c.resolvePins(installed)
+	if err != nil {
+		return err
+	}
+	if err := awsSource.ApplyPins(pins); err != nil {
+		return err
+	}
+	if len(pins) > 0 {
+		log.Info("Pinning components", zap.Any("pins", pins))
+	}
+	if !c.noCache {
+		awsSource.Cache = cache.New(cache.DefaultDir)
+	}
 
 	log.Info("Creating daemon manager...")
 	daemonManager, err := daemon.NewDaemonManager()
@@ -183,12 +268,19 @@ func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 	}
 	defer daemonManager.Close()
 
+	var drainer *node.Drainer
 	if installed.Artifacts.Kubelet {
 		kubeletStatus, err := daemonManager.GetDaemonStatus(kubelet.KubeletDaemonName)
 		if err != nil {
 			return err
 		}
-		if kubeletStatus == daemon.DaemonStatusRunning {
+		if kubeletStatus == daemon.DaemonStatusRunning && c.drain {
+			// The upgrader drains the node once the artifacts are downloaded.
+			drainer, err = node.NewDrainer(log, c.drainTimeout)
+			if err != nil {
+				return err
+			}
+		} else if kubeletStatus == daemon.DaemonStatusRunning {
 			if !slices.Contains(c.skipPhases, skipPodPreflightCheck) {
 				log.Info("Validating if node has been drained...")
 				if drained, err := node.IsDrained(ctx); err != nil {
@@ -219,16 +311,45 @@ func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
 	}
 
 	upgrader := &flows.Upgrader{
-		NodeProvider:       nodeProvider,
-		AwsSource:          awsSource,
-		PackageManager:     packageManager,
-		CredentialProvider: credsProvider,
-		Artifacts:          installed.Artifacts,
-		DaemonManager:      daemonManager,
-		SkipPhases:         c.skipPhases,
-		Logger:             log,
-		PrivateMode:        c.privateMode,
-	}
-
-	return upgrader.Run(ctx)
+		NodeProvider:        nodeProvider,
+		AwsSource:           awsSource,
+		PackageManager:      packageManager,
+		CredentialProvider:  credsProvider,
+		Tracker:             installed,
+		DaemonManager:       daemonManager,
+		SkipPhases:          c.skipPhases,
+		Logger:              log,
+		PrivateMode:         c.privateMode,
+		ReadinessTimeout:    c.readinessTimeout,
+		Drainer:             drainer,
+		DownloadParallelism: c.downloadParallelism,
+	}
+
+	return ledger.Track(ledger.FilePath, func() error { return upgrader.Run(ctx) })
+}
+
+// resolvePins returns the pins recorded at install time or by previous upgrades, updated
+// with --pin and without the components released with --unpin.
+func (c *command) resolvePins(installed *tracker.Tracker) (map[string]string, error) {
+	requested, err := aws.ParsePins(c.pins)
+	if err != nil {
+		return nil, err
+	}
+	pins := map[string]string{}
+	for component, version := range installed.Pins {
+		pins[component] = version
+	}
+	for component, version := range requested {
+		pins[component] = version
+	}
+	for _, component := range c.unpins {
+		if err := aws.ValidatePinnable(component); err != nil {
+			return nil, err
+		}
+		if _, ok := requested[component]; ok {
+			return nil, fmt.Errorf("%s can't be pinned and released at the same time", component)
+		}
+		delete(pins, component)
+	}
+	return pins, nil
 }
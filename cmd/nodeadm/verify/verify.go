@@ -0,0 +1,167 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/integrii/flaggy"
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/cli"
+	"github.com/aws/eks-hybrid/internal/creds"
+	"github.com/aws/eks-hybrid/internal/errors"
+	"github.com/aws/eks-hybrid/internal/flows"
+	"github.com/aws/eks-hybrid/internal/logger"
+	"github.com/aws/eks-hybrid/internal/tracker"
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+
+	sourceTracker  = "tracker"
+	sourceManifest = "manifest"
+)
+
+const verifyHelpText = `Examples:
+  # Verify the installed artifacts against the checksums recorded at install time
+  nodeadm verify
+
+  # Verify the installed artifacts against the release manifest they were installed from
+  nodeadm verify --source manifest
+
+  # Verify against a specific manifest and Kubernetes version, printing JSON
+  nodeadm verify --source manifest --manifest-override https://example.com/manifest.yaml --kubernetes-version 1.31 -o json`
+
+func NewCommand() cli.Command {
+	cmd := command{
+		output: outputText,
+		source: sourceTracker,
+	}
+
+	fc := flaggy.NewSubcommand("verify")
+	fc.Description = "Verify the artifacts installed by nodeadm haven't been modified or removed"
+	fc.AdditionalHelpAppend = verifyHelpText
+	fc.String(&cmd.output, "o", "output", fmt.Sprintf("Output format. Allowed values: [%s, %s].", outputText, outputJSON))
+	fc.String(&cmd.source, "s", "source", fmt.Sprintf("Source of the expected checksums. Allowed values: [%s, %s].", sourceTracker, sourceManifest))
+	fc.String(&cmd.manifestOverride, "m", "manifest-override", "Manifest to read checksums from when --source is manifest. Defaults to the manifest recorded at install time.")
+	fc.String(&cmd.kubernetesVersion, "", "kubernetes-version", "Kubernetes version to read checksums for when --source is manifest. Defaults to the version recorded at install time.")
+	fc.StringSlice(&cmd.manifestKeys, "", "manifest-key", "Path to an armored OpenPGP public key trusted to sign the release manifest, in addition to the keys in "+aws.TrustedKeysDir+". Can be repeated.")
+	fc.Bool(&cmd.insecureSkipManifestVerification, "", "insecure-skip-manifest-verification", "Accept release manifests without verifying their signature.")
+	cmd.flaggy = fc
+
+	return &cmd
+}
+
+type command struct {
+	flaggy                           *flaggy.Subcommand
+	output                           string
+	source                           string
+	manifestOverride                 string
+	kubernetesVersion                string
+	manifestKeys                     []string
+	insecureSkipManifestVerification bool
+}
+
+func (c *command) Flaggy() *flaggy.Subcommand {
+	return c.flaggy
+}
+
+func (c *command) Run(log *zap.Logger, opts *cli.GlobalOptions) error {
+	ctx := context.Background()
+	ctx = logger.NewContext(ctx, log)
+
+	if c.output != outputText && c.output != outputJSON {
+		return fmt.Errorf("invalid output format %s. Allowed values: [%s, %s]", c.output, outputText, outputJSON)
+	}
+	if c.source != sourceTracker && c.source != sourceManifest {
+		return fmt.Errorf("invalid source %s. Allowed values: [%s, %s]", c.source, sourceTracker, sourceManifest)
+	}
+
+	root, err := cli.IsRunningAsRoot()
+	if err != nil {
+		return err
+	}
+	if !root {
+		return cli.ErrMustRunAsRoot
+	}
+
+	installed, err := tracker.GetInstalledArtifacts()
+	if err != nil {
+		return fmt.Errorf("reading installed artifacts, ensure nodeadm install has run: %w", err)
+	}
+
+	verifier := &flows.Verifier{Tracker: installed}
+	if c.source == sourceManifest {
+		// The region isn't recorded at install time, it's read from the AWS config or environment.
+		if credsProvider, err := creds.GetCredentialProviderFromInstalledArtifacts(installed.Artifacts); err == nil {
+			util.SetS3ClientProvider(creds.S3ClientProvider(credsProvider, "", ""))
+		}
+		source, err := c.releaseSource(ctx, installed)
+		if err != nil {
+			return err
+		}
+		verifier.Source = &source
+	}
+
+	results, err := verifier.Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	if c.output == outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(results)
+	} else {
+		err = printText(os.Stdout, results)
+	}
+	if err != nil {
+		return err
+	}
+
+	if flows.VerifyFailed(results) {
+		return errors.NewSilent(fmt.Errorf("installed artifacts failed verification"))
+	}
+	return nil
+}
+
+func (c *command) releaseSource(ctx context.Context, installed *tracker.Tracker) (aws.Source, error) {
+	kubernetesVersion := c.kubernetesVersion
+	manifestURI := c.manifestOverride
+	if installed.Manifest != nil {
+		if kubernetesVersion == "" {
+			kubernetesVersion = installed.Manifest.KubernetesVersion
+		}
+		if manifestURI == "" {
+			manifestURI = installed.Manifest.URI
+		}
+	}
+	if kubernetesVersion == "" {
+		return aws.Source{}, fmt.Errorf("the installed Kubernetes version is not recorded, please set --kubernetes-version")
+	}
+	source, err := aws.GetReleaseSource(ctx, kubernetesVersion, manifestURI, aws.ManifestVerificationOptions(c.manifestKeys, c.insecureSkipManifestVerification)...)
+	if err != nil {
+		return aws.Source{}, err
+	}
+	// Pinned components were installed from the release of their pinned version.
+	if err := source.ApplyPins(installed.Pins); err != nil {
+		return aws.Source{}, err
+	}
+	return source, nil
+}
+
+func printText(w io.Writer, results []flows.VerifyResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ARTIFACT\tPATH\tSTATUS")
+	for _, result := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", result.Artifact, result.Path, result.Status)
+	}
+	return tw.Flush()
+}
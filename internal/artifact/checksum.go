@@ -2,6 +2,8 @@ package artifact
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -9,6 +11,54 @@ import (
 	"strings"
 )
 
+// Algorithm is a digest algorithm artifacts are verified with.
+type Algorithm string
+
+const (
+	SHA256 Algorithm = "sha256"
+	SHA512 Algorithm = "sha512"
+)
+
+// New returns a hash computing digests of the algorithm.
+func (a Algorithm) New() hash.Hash {
+	if a == SHA512 {
+		return sha512.New()
+	}
+	return sha256.New()
+}
+
+// Size returns the length in bytes of the digests of the algorithm.
+func (a Algorithm) Size() int {
+	if a == SHA512 {
+		return sha512.Size
+	}
+	return sha256.Size
+}
+
+// AlgorithmOf returns the algorithm producing digests of the length of digest.
+func AlgorithmOf(digest []byte) (Algorithm, error) {
+	switch len(digest) {
+	case sha256.Size:
+		return SHA256, nil
+	case sha512.Size:
+		return SHA512, nil
+	default:
+		return "", fmt.Errorf("unsupported checksum of %d bytes, expected a sha256 or sha512 digest", len(digest))
+	}
+}
+
+// ParseDigest decodes a hex encoded digest of the given algorithm.
+func ParseDigest(algorithm Algorithm, digest string) ([]byte, error) {
+	decoded, err := hex.DecodeString(digest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s digest: %w", algorithm, err)
+	}
+	if len(decoded) != algorithm.Size() {
+		return nil, fmt.Errorf("invalid %s digest: expected %d bytes, got %d", algorithm, algorithm.Size(), len(decoded))
+	}
+	return decoded, nil
+}
+
 type checksumVerifier struct {
 	expect []byte
 	digest hash.Hash
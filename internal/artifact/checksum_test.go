@@ -18,3 +18,20 @@ func TestParseGNUChecksum(t *testing.T) {
 		t.Fatalf("Received unexpected checksum: %v", checksum)
 	}
 }
+
+func TestParseDigest(t *testing.T) {
+	digest, err := artifact.ParseDigest(artifact.SHA256, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if algorithm, err := artifact.AlgorithmOf(digest); err != nil || algorithm != artifact.SHA256 {
+		t.Fatalf("Expected a sha256 digest, got %s: %v", algorithm, err)
+	}
+
+	if _, err := artifact.ParseDigest(artifact.SHA512, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"); err == nil {
+		t.Fatal("Expected an error for a sha256 digest parsed as sha512")
+	}
+	if _, err := artifact.ParseDigest(artifact.SHA256, "not hex"); err == nil {
+		t.Fatal("Expected an error for a digest that isn't hex encoded")
+	}
+}
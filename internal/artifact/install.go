@@ -3,6 +3,8 @@ package artifact
 import (
 	"archive/tar"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/fs"
@@ -12,6 +14,8 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+
+	"github.com/aws/eks-hybrid/internal/ledger"
 )
 
 // DefaultDirPerms are the permissions assigned to a directory when an Install* func is called
@@ -19,12 +23,13 @@ import (
 const DefaultDirPerms = fs.ModeDir | 0o755
 
 // InstallFile installs src to dst with perms permissions. It ensures any base paths exist
-// before installing.
+// before installing. The file is recorded in the install ledger.
 func InstallFile(dst string, src io.Reader, perms fs.FileMode) error {
+	preexisting := ledger.Exists(dst)
 	if err := os.RemoveAll(dst); err != nil {
 		return err
 	}
-	if err := os.MkdirAll(path.Dir(dst), DefaultDirPerms); err != nil {
+	if err := ledger.MkdirAll(path.Dir(dst), DefaultDirPerms); err != nil {
 		return err
 	}
 
@@ -34,13 +39,19 @@ func InstallFile(dst string, src io.Reader, perms fs.FileMode) error {
 	}
 	defer fh.Close()
 
-	_, err = io.Copy(fh, src)
-	return err
+	if _, err := io.Copy(fh, src); err != nil {
+		return err
+	}
+	if err := fh.Close(); err != nil {
+		return err
+	}
+	return ledger.RecordFile(dst, preexisting)
 }
 
-// InstallTarGz untars the src file into the dst directory and deletes the src tgz file
+// InstallTarGz untars the src file into the dst directory and deletes the src tgz file.
+// The extracted files are recorded in the install ledger.
 func InstallTarGz(dst, src string) error {
-	if err := os.MkdirAll(dst, DefaultDirPerms); err != nil {
+	if err := ledger.MkdirAll(dst, DefaultDirPerms); err != nil {
 		return err
 	}
 	reader, err := os.Open(src)
@@ -73,12 +84,13 @@ func InstallTarGz(dst, src string) error {
 		target := filepath.Join(dst, header.Name)
 		info := header.FileInfo()
 		if info.IsDir() {
-			if err := os.MkdirAll(target, info.Mode()); err != nil {
+			if err := ledger.MkdirAll(target, info.Mode()); err != nil {
 				return errors.Wrap(err, "creating directory")
 			}
 			continue
 		}
 
+		preexisting := ledger.Exists(target)
 		f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
 		if err != nil {
 			return errors.Wrap(err, "creating file")
@@ -88,6 +100,12 @@ func InstallTarGz(dst, src string) error {
 		if _, err := io.Copy(f, tr); err != nil {
 			return errors.Wrap(err, "copying file contents")
 		}
+		if err := f.Close(); err != nil {
+			return errors.Wrap(err, "closing file")
+		}
+		if err := ledger.RecordFile(target, preexisting); err != nil {
+			return err
+		}
 	}
 
 	// Remove the tgz file
@@ -97,6 +115,46 @@ func InstallTarGz(dst, src string) error {
 	return nil
 }
 
+// TarGzDigests returns the hex encoded sha256 of every regular file in the src tgz file,
+// keyed by the path the file is extracted to under dst.
+func TarGzDigests(dst, src string) (map[string]string, error) {
+	reader, err := os.Open(src)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening source file")
+	}
+	defer reader.Close()
+	gzr, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating gzip reader")
+	}
+	defer gzr.Close()
+
+	digests := map[string]string{}
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "reading tar file")
+		}
+
+		if !validRelPath(header.Name) {
+			return nil, fmt.Errorf("tar contained invalid name error %q", header.Name)
+		}
+		if !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+
+		digest := sha256.New()
+		if _, err := io.Copy(digest, tr); err != nil {
+			return nil, errors.Wrapf(err, "calculating sha256 for %s", header.Name)
+		}
+		digests[filepath.Join(dst, header.Name)] = hex.EncodeToString(digest.Sum(nil))
+	}
+	return digests, nil
+}
+
 func validRelPath(p string) bool {
 	if p == "" || strings.Contains(p, `\`) || strings.HasPrefix(p, "/") || strings.Contains(p, "../") {
 		return false
@@ -4,6 +4,8 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -326,3 +328,29 @@ func TestInstallTarGz(t *testing.T) {
 		})
 	}
 }
+
+func TestTarGzDigests(t *testing.T) {
+	g := NewWithT(t)
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "test.tar.gz")
+	g.Expect(os.WriteFile(src, tarGzBytes(t, map[string]struct {
+		content string
+		mode    int64
+	}{
+		"bridge":         {"bridge", 0o755},
+		"subdir/portmap": {"portmap", 0o755},
+	}), 0o644)).To(Succeed())
+
+	digests, err := artifact.TarGzDigests("/opt/cni/bin", src)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(digests).To(Equal(map[string]string{
+		"/opt/cni/bin/bridge":         sha256Hex("bridge"),
+		"/opt/cni/bin/subdir/portmap": sha256Hex("portmap"),
+	}))
+	g.Expect(src).To(BeAnExistingFile())
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -28,27 +28,44 @@ type ChecksumVerifier interface {
 	ActualChecksum() []byte
 }
 
-// GzippedWithChecksum creates a gzip reader around rc and a checksum verifier.
-// The returned Source should be used to read the artifact contents.
-func GzippedWithChecksum(rc io.ReadCloser, digest hash.Hash, expect []byte) (Source, error) {
-	gzipReader, err := gzip.NewReader(rc)
+// GzippedWithChecksum creates a gzip reader around rc and a checksum verifier of the
+// decompressed content. The returned Source should be used to read the artifact contents.
+func GzippedWithChecksum(rc io.ReadCloser, expect []byte) (Source, error) {
+	gzipped, err := newGzipReadCloser(rc)
 	if err != nil {
-		return nil, fmt.Errorf("getting gzip reader: %w", err)
+		return nil, err
 	}
-	gzipped := &gzipReadCloser{
-		Reader: gzipReader,
-		body:   rc,
+	return WithChecksum(gzipped, expect)
+}
+
+// GzippedWithDigest is like GzippedWithChecksum but takes the expected checksum already
+// decoded instead of in GNU checksum format.
+func GzippedWithDigest(rc io.ReadCloser, digest hash.Hash, expect []byte) (Source, error) {
+	gzipped, err := newGzipReadCloser(rc)
+	if err != nil {
+		return nil, err
 	}
-	return WithChecksum(gzipped, digest, expect)
+	return WithDigest(gzipped, digest, expect), nil
 }
 
-// WithChecksum creates a checksumVerifier. The digest is used to calculate srcs checksum.
-// The returned Source should be used to read the artifact contents.
-func WithChecksum(rc io.ReadCloser, digest hash.Hash, expect []byte) (Source, error) {
+// WithChecksum creates a checksumVerifier from a checksum in GNU checksum format. The
+// algorithm, sha256 or sha512, is chosen from the length of the checksum. The returned
+// Source should be used to read the artifact contents.
+func WithChecksum(rc io.ReadCloser, expect []byte) (Source, error) {
 	parsedExpectedChecksum, err := ParseGNUChecksum(expect)
 	if err != nil {
 		return nil, fmt.Errorf("parsing expected checksum: %w", err)
 	}
+	algorithm, err := AlgorithmOf(parsedExpectedChecksum)
+	if err != nil {
+		return nil, err
+	}
+	return WithDigest(rc, algorithm.New(), parsedExpectedChecksum), nil
+}
+
+// WithDigest is like WithChecksum but takes the expected checksum already decoded
+// instead of in GNU checksum format.
+func WithDigest(rc io.ReadCloser, digest hash.Hash, expect []byte) Source {
 	return struct {
 		io.Reader
 		io.Closer
@@ -56,8 +73,8 @@ func WithChecksum(rc io.ReadCloser, digest hash.Hash, expect []byte) (Source, er
 	}{
 		Reader:           io.TeeReader(rc, digest),
 		Closer:           rc,
-		ChecksumVerifier: checksumVerifier{expect: parsedExpectedChecksum, digest: digest},
-	}, nil
+		ChecksumVerifier: checksumVerifier{expect: expect, digest: digest},
+	}
 }
 
 // WithNopChecksum turns rc into a Source that nops when the ChecksumVerifier methods are called.
@@ -72,6 +89,14 @@ func WithNopChecksum(rc io.ReadCloser) Source {
 	}
 }
 
+func newGzipReadCloser(rc io.ReadCloser) (*gzipReadCloser, error) {
+	gzipReader, err := gzip.NewReader(rc)
+	if err != nil {
+		return nil, fmt.Errorf("getting gzip reader: %w", err)
+	}
+	return &gzipReadCloser{Reader: gzipReader, body: rc}, nil
+}
+
 type gzipReadCloser struct {
 	*gzip.Reader
 	body io.ReadCloser
@@ -3,7 +3,6 @@ package artifact_test
 import (
 	"bytes"
 	"compress/gzip"
-	"crypto/sha256"
 	"io"
 	"strings"
 	"testing"
@@ -20,7 +19,7 @@ func TestWithChecksum(t *testing.T) {
 	mismatchExpect := []byte("2fe4d4a5963f28b77737c091c436096beee0b74fabb9fcdcd2a4d8859d2099a3  -")
 
 	t.Run("GoodChecksum", func(t *testing.T) {
-		src, err := artifact.WithChecksum(io.NopCloser(bytes.NewBufferString(data)), sha256.New(), expect)
+		src, err := artifact.WithChecksum(io.NopCloser(bytes.NewBufferString(data)), expect)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -36,7 +35,7 @@ func TestWithChecksum(t *testing.T) {
 	})
 
 	t.Run("BadChecksum", func(t *testing.T) {
-		src, err := artifact.WithChecksum(io.NopCloser(bytes.NewBufferString(data)), sha256.New(), mismatchExpect)
+		src, err := artifact.WithChecksum(io.NopCloser(bytes.NewBufferString(data)), mismatchExpect)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -50,6 +49,23 @@ func TestWithChecksum(t *testing.T) {
 			t.Fatalf("Expected true; expect = %x; actual = %x", src.ExpectedChecksum(), src.ActualChecksum())
 		}
 	})
+
+	t.Run("SHA512Checksum", func(t *testing.T) {
+		g := NewWithT(t)
+		sha512Expect := []byte("309ecc489c12d6eb4cc40f50c902f2b4d0ed77ee511a7c7a9bcd3ca86d4cd86f989dd35bc5ff499670da34255b45b0cfd830e81f605dcf7dc5542e93ae9cd76f  -")
+		src, err := artifact.WithChecksum(io.NopCloser(bytes.NewBufferString(data)), sha512Expect)
+		g.Expect(err).NotTo(HaveOccurred())
+		_, err = io.Copy(io.Discard, src)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(src.VerifyChecksum()).To(BeTrue())
+		g.Expect(src.ActualChecksum()).To(HaveLen(64))
+	})
+
+	t.Run("UnsupportedChecksum", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := artifact.WithChecksum(io.NopCloser(bytes.NewBufferString(data)), []byte("5eb63bbbe01eeed093cb22bb8f5acdc3  -"))
+		g.Expect(err).To(MatchError(ContainSubstring("expected a sha256 or sha512 digest")))
+	})
 }
 
 func TestWithNopChecksum(t *testing.T) {
@@ -96,7 +112,7 @@ func TestGzippedWithChecksum(t *testing.T) {
 
 	t.Run("GoodChecksum", func(t *testing.T) {
 		g := NewWithT(t)
-		src, err := artifact.GzippedWithChecksum(io.NopCloser(bytes.NewBuffer(gzippedData)), sha256.New(), expect)
+		src, err := artifact.GzippedWithChecksum(io.NopCloser(bytes.NewBuffer(gzippedData)), expect)
 		g.Expect(err).NotTo(HaveOccurred())
 
 		_, err = io.Copy(io.Discard, src)
@@ -106,7 +122,7 @@ func TestGzippedWithChecksum(t *testing.T) {
 
 	t.Run("BadChecksum", func(t *testing.T) {
 		g := NewWithT(t)
-		src, err := artifact.GzippedWithChecksum(io.NopCloser(bytes.NewBuffer(gzippedData)), sha256.New(), mismatchExpect)
+		src, err := artifact.GzippedWithChecksum(io.NopCloser(bytes.NewBuffer(gzippedData)), mismatchExpect)
 		g.Expect(err).NotTo(HaveOccurred())
 
 		_, err = io.Copy(io.Discard, src)
@@ -116,7 +132,7 @@ func TestGzippedWithChecksum(t *testing.T) {
 
 	t.Run("InvalidGzip", func(t *testing.T) {
 		g := NewWithT(t)
-		_, err := artifact.GzippedWithChecksum(io.NopCloser(bytes.NewBufferString("not gzipped")), sha256.New(), expect)
+		_, err := artifact.GzippedWithChecksum(io.NopCloser(bytes.NewBufferString("not gzipped")), expect)
 		g.Expect(err).To(HaveOccurred())
 		g.Expect(err.Error()).To(ContainSubstring("getting gzip reader"))
 	})
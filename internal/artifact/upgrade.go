@@ -2,7 +2,6 @@ package artifact
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/fs"
@@ -15,17 +14,36 @@ import (
 // checksumMatch compares the checksum of the installed artifact with the expected checksum
 // A mismatch of checksum indicates installed artifacts are due for an upgrade
 func checksumMatch(installedArtifactPath string, src Source) (bool, error) {
-	fh, err := os.Open(installedArtifactPath)
+	algorithm, err := AlgorithmOf(src.ExpectedChecksum())
+	if err != nil {
+		// Without a known checksum the artifact is always upgraded.
+		return false, nil
+	}
+	checksum, err := FileDigest(installedArtifactPath, algorithm)
 	if err != nil {
 		return false, errors.Wrap(err, "checking for checksum match")
 	}
+	return bytes.Equal(checksum, src.ExpectedChecksum()), nil
+}
+
+// FileSha256 returns the sha256 digest of the file at path.
+func FileSha256(path string) ([]byte, error) {
+	return FileDigest(path, SHA256)
+}
+
+// FileDigest returns the digest of the file at path with the given algorithm.
+func FileDigest(path string, algorithm Algorithm) ([]byte, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
 	defer fh.Close()
 
-	digest := sha256.New()
+	digest := algorithm.New()
 	if _, err = io.Copy(digest, fh); err != nil {
-		return false, errors.Wrapf(err, "calculating sha256 for %s", installedArtifactPath)
+		return nil, errors.Wrapf(err, "calculating %s for %s", algorithm, path)
 	}
-	return bytes.Equal(digest.Sum(nil), src.ExpectedChecksum()), nil
+	return digest.Sum(nil), nil
 }
 
 // Upgrade upgrades an artifact from the source only if the expected checksum doesn't match with the
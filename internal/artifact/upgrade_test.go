@@ -2,7 +2,6 @@ package artifact
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"fmt"
 	"io"
 	"os"
@@ -54,7 +53,7 @@ func TestUpgradeAvailable(t *testing.T) {
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
 			g := NewWithT(t)
-			src, err := WithChecksum(dummyFh, sha256.New(), tc.sourceChecksum)
+			src, err := WithChecksum(dummyFh, tc.sourceChecksum)
 			if err != nil {
 				g.Expect(err).To(BeNil())
 			}
@@ -103,7 +102,7 @@ func TestUpgrade(t *testing.T) {
 			_, err = artifact.WriteString(tt.installedData)
 			g.Expect(err).To(BeNil())
 
-			source, err := WithChecksum(io.NopCloser(bytes.NewBufferString(tt.upgradedData)), sha256.New(), tt.sourceChecksum)
+			source, err := WithChecksum(io.NopCloser(bytes.NewBufferString(tt.upgradedData)), tt.sourceChecksum)
 			g.Expect(err).To(BeNil())
 
 			err = Upgrade("dummyArtifact", artifact.Name(), source, 0o755, zap.NewNop())
@@ -2,6 +2,8 @@ package aws
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
 	"strings"
 
@@ -11,7 +13,7 @@ import (
 	"github.com/aws/eks-hybrid/internal/util"
 )
 
-// set build time
+// set build time, may be a comma separated list of mirrors
 var manifestUrl string
 
 // getManifestURL returns the appropriate manifest URL based on the region/partition
@@ -40,6 +42,14 @@ type Manifest struct {
 	IamRolesAnywhereReleases []IamRolesAnywhereRelease `json:"iam_roles_anywhere_releases"`
 	SsmReleases              []SsmRelease              `json:"ssm_releases"`
 	RegionConfig             RegionConfig              `json:"region_config"`
+
+	source ManifestSource
+}
+
+// ManifestSource identifies where a release manifest was read from.
+type ManifestSource struct {
+	URI    string
+	Sha256 string
 }
 
 type SupportedEksRelease struct {
@@ -83,26 +93,112 @@ type Artifact struct {
 	URI         string `json:"uri"`
 	ChecksumURI string `json:"checksum_uri,omitempty"`
 	GzipURI     string `json:"gzip_uri,omitempty"`
+	// Sha256 and Sha512 are hex encoded digests of the artifact, after decompression if
+	// gzipped. When set, they are used instead of the checksum file at ChecksumURI.
+	Sha256 string `json:"sha256,omitempty"`
+	Sha512 string `json:"sha512,omitempty"`
+	// Mirrors are other URIs serving the same file as URI. They are tried in order when the
+	// artifact can't be downloaded from URI, or from GzipURI if set.
+	Mirrors []string `json:"mirrors,omitempty"`
 }
 
 // Read from the manifest file on s3 and parse into Manifest struct
 // region is used to determine the appropriate manifest URL for different partitions (e.g., aws-cn)
-func getReleaseManifest(ctx context.Context, region string) (*Manifest, error) {
-	manifestURL := getManifestURL(region)
-	yamlFileData, err := util.GetHttpFile(ctx, manifestURL)
+func getReleaseManifest(ctx context.Context, region string, opts ...ManifestOption) (*Manifest, error) {
+	yamlFileData, manifestURL, err := readManifestMirrors(ctx, splitMirrors(getManifestURL(region)), util.GetHttpFile)
 	if err != nil {
 		return nil, err
 	}
-	var manifest Manifest
-	err = yaml.Unmarshal(yamlFileData, &manifest)
+	getSignature := func() ([]byte, error) {
+		return util.GetHttpFile(ctx, manifestURL+signatureSuffix)
+	}
+	if err := verifyManifest(ctx, manifestURL, yamlFileData, getSignature, newManifestOptions(opts)); err != nil {
+		return nil, err
+	}
+	return parseManifest(manifestURL, yamlFileData)
+}
+
+// getReleaseManifestFromURI reads from a URI (file://, https:// or s3://) and parses into Manifest struct.
+// The mirrors set with WithManifestMirrors are tried after it.
+func getReleaseManifestFromURI(ctx context.Context, manifestURI string, opts ...ManifestOption) (*Manifest, error) {
+	options := newManifestOptions(opts)
+	yamlFileData, manifestURI, err := readManifestMirrors(ctx, append([]string{manifestURI}, options.mirrors...), readManifestURI)
 	if err != nil {
-		return nil, errors.Wrap(err, "invalid yaml data in release manifest")
+		return nil, err
 	}
-	return &manifest, nil
+	getSignature := func() ([]byte, error) {
+		return readManifestURI(ctx, manifestURI+signatureSuffix)
+	}
+	if err := verifyManifest(ctx, manifestURI, yamlFileData, getSignature, options); err != nil {
+		return nil, err
+	}
+	return parseManifest(manifestURI, yamlFileData)
 }
 
-// getReleaseManifestFromURI reads from a URI (file:// or https://) and parses into Manifest struct
-func getReleaseManifestFromURI(ctx context.Context, manifestURI string) (*Manifest, error) {
+// readManifestMirrors reads the manifest from the first of the mirrors in manifestURIs that
+// serves it and returns the URI it was read from. The signature must be read from the same
+// mirror.
+func readManifestMirrors(ctx context.Context, manifestURIs []string, read func(context.Context, string) ([]byte, error)) ([]byte, string, error) {
+	return tryMirrors(ctx, "manifest", manifestURIs, func(uri string) ([]byte, error) {
+		return read(ctx, uri)
+	})
+}
+
+// ManifestFile is a release manifest as published, along with its detached signature.
+type ManifestFile struct {
+	URI  string
+	Data []byte
+	// Signature is nil if the manifest isn't signed and verification was skipped.
+	Signature []byte
+	Manifest  *Manifest
+
+	signatureErr error
+}
+
+// GetReleaseManifestFile reads the release manifest at manifestURI, or its mirrors set
+// with WithManifestMirrors, or the default manifest for region if manifestURI is empty,
+// verifies its signature and returns it as published.
+func GetReleaseManifestFile(ctx context.Context, manifestURI, region string, opts ...ManifestOption) (*ManifestFile, error) {
+	file, err := ReadReleaseManifestFile(ctx, manifestURI, region, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Verify(ctx, opts...); err != nil {
+		return nil, err
+	}
+	if file.Manifest, err = parseManifest(file.URI, file.Data); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// ReadReleaseManifestFile reads the release manifest like GetReleaseManifestFile, along
+// with its signature if it is signed, without verifying or parsing it. Manifest is nil.
+func ReadReleaseManifestFile(ctx context.Context, manifestURI, region string, opts ...ManifestOption) (*ManifestFile, error) {
+	options := newManifestOptions(opts)
+	manifestURIs := splitMirrors(getManifestURL(region))
+	if manifestURI != "" {
+		manifestURIs = append([]string{manifestURI}, options.mirrors...)
+	}
+	data, manifestURI, err := readManifestMirrors(ctx, manifestURIs, readManifestURI)
+	if err != nil {
+		return nil, err
+	}
+	file := &ManifestFile{URI: manifestURI, Data: data}
+	file.Signature, file.signatureErr = readManifestURI(ctx, manifestURI+signatureSuffix)
+	return file, nil
+}
+
+// Verify checks the signature of the manifest against the trusted keys.
+func (f *ManifestFile) Verify(ctx context.Context, opts ...ManifestOption) error {
+	getSignature := func() ([]byte, error) {
+		return f.Signature, f.signatureErr
+	}
+	return verifyManifest(ctx, f.URI, f.Data, getSignature, newManifestOptions(opts))
+}
+
+// readManifestURI reads a manifest, or its signature, from a file://, https:// or s3:// URI.
+func readManifestURI(ctx context.Context, manifestURI string) ([]byte, error) {
 	var yamlFileData []byte
 	var err error
 
@@ -120,18 +216,32 @@ func getReleaseManifestFromURI(ctx context.Context, manifestURI string) (*Manife
 		if err != nil {
 			return nil, errors.Wrapf(err, "downloading manifest file from https:// URI: %s", manifestURI)
 		}
+	} else if strings.HasPrefix(manifestURI, util.S3Scheme) {
+		// Download from S3 with the node credentials
+		yamlFileData, err = util.GetHttpFile(ctx, manifestURI)
+		if err != nil {
+			return nil, errors.Wrapf(err, "downloading manifest file from s3:// URI: %s", manifestURI)
+		}
 	} else {
 		// For backward compatibility, treat as a plain file path
 		yamlFileData, err = os.ReadFile(manifestURI)
 		if err != nil {
-			return nil, errors.Wrapf(err, "reading manifest file: %s (hint: use file:// or https:// prefix)", manifestURI)
+			return nil, errors.Wrapf(err, "reading manifest file: %s (hint: use file://, https:// or s3:// prefix)", manifestURI)
 		}
 	}
 
+	return yamlFileData, nil
+}
+
+func parseManifest(uri string, yamlFileData []byte) (*Manifest, error) {
 	var manifest Manifest
-	err = yaml.Unmarshal(yamlFileData, &manifest)
-	if err != nil {
+	if err := yaml.Unmarshal(yamlFileData, &manifest); err != nil {
 		return nil, errors.Wrap(err, "invalid yaml data in release manifest")
 	}
+	sum := sha256.Sum256(yamlFileData)
+	manifest.source = ManifestSource{
+		URI:    uri,
+		Sha256: hex.EncodeToString(sum[:]),
+	}
 	return &manifest, nil
 }
@@ -0,0 +1,67 @@
+package aws
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ChangeType is how an artifact changed between two releases.
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeUpdated ChangeType = "updated"
+)
+
+// ArtifactChange is an artifact that differs between two releases. From is nil for added
+// artifacts and To is nil for removed ones.
+type ArtifactChange struct {
+	Name string     `json:"name"`
+	OS   string     `json:"os"`
+	Arch string     `json:"arch"`
+	Type ChangeType `json:"type"`
+	From *Artifact  `json:"from,omitempty"`
+	To   *Artifact  `json:"to,omitempty"`
+}
+
+// DiffEksPatchReleases returns the artifacts that were added, removed or updated, because
+// their URIs or digests changed, between two patch releases, sorted by name and platform.
+func DiffEksPatchReleases(from, to EksPatchRelease) []ArtifactChange {
+	fromArtifacts := map[string]Artifact{}
+	for _, a := range from.Artifacts {
+		fromArtifacts[a.Name+" "+platform(a)] = a
+	}
+
+	var changes []ArtifactChange
+	seen := map[string]bool{}
+	for _, a := range to.Artifacts {
+		key := a.Name + " " + platform(a)
+		seen[key] = true
+		toArtifact := a
+		previous, ok := fromArtifacts[key]
+		switch {
+		case !ok:
+			changes = append(changes, ArtifactChange{Name: a.Name, OS: a.OS, Arch: a.Arch, Type: ChangeAdded, To: &toArtifact})
+		case !reflect.DeepEqual(previous, a):
+			changes = append(changes, ArtifactChange{Name: a.Name, OS: a.OS, Arch: a.Arch, Type: ChangeUpdated, From: &previous, To: &toArtifact})
+		}
+	}
+	for _, a := range from.Artifacts {
+		if !seen[a.Name+" "+platform(a)] {
+			fromArtifact := a
+			changes = append(changes, ArtifactChange{Name: a.Name, OS: a.OS, Arch: a.Arch, Type: ChangeRemoved, From: &fromArtifact})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Name != changes[j].Name {
+			return changes[i].Name < changes[j].Name
+		}
+		if changes[i].OS != changes[j].OS {
+			return changes[i].OS < changes[j].OS
+		}
+		return changes[i].Arch < changes[j].Arch
+	})
+	return changes
+}
@@ -1,12 +1,21 @@
 package aws
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"sigs.k8s.io/yaml"
+
+	"github.com/aws/eks-hybrid/internal/util"
 )
 
 func TestManifestUnmarshaling(t *testing.T) {
@@ -426,3 +435,58 @@ func TestManifestDataConsistency(t *testing.T) {
 		}
 	})
 }
+
+func TestGetReleaseManifestFromURIRecordsSource(t *testing.T) {
+	manifestPath, err := filepath.Abs(filepath.Join("testdata", "manifest.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	yamlData, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatalf("Failed to read test manifest file: %v", err)
+	}
+
+	manifest, err := getReleaseManifestFromURI(context.Background(), "file://"+manifestPath, WithInsecureSkipVerify())
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+
+	sum := sha256.Sum256(yamlData)
+	want := ManifestSource{URI: "file://" + manifestPath, Sha256: hex.EncodeToString(sum[:])}
+	if manifest.source != want {
+		t.Errorf("Expected manifest source %+v, got %+v", want, manifest.source)
+	}
+}
+
+// fakeS3Client serves objects from memory, keyed by bucket/key.
+type fakeS3Client map[string][]byte
+
+func (f fakeS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	data, ok := f[*params.Bucket+"/"+*params.Key]
+	if !ok {
+		return nil, fmt.Errorf("NoSuchKey: %s/%s", *params.Bucket, *params.Key)
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func TestGetReleaseManifestFromS3URI(t *testing.T) {
+	yamlData, err := os.ReadFile(filepath.Join("testdata", "manifest.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read test manifest file: %v", err)
+	}
+	util.SetS3ClientProvider(func(context.Context) (util.S3Client, error) {
+		return fakeS3Client{"private-bucket/eks/manifest.yaml": yamlData}, nil
+	})
+	t.Cleanup(func() { util.SetS3ClientProvider(nil) })
+
+	manifest, err := getReleaseManifestFromURI(context.Background(), "s3://private-bucket/eks/manifest.yaml", WithInsecureSkipVerify())
+	if err != nil {
+		t.Fatalf("Failed to read manifest from S3: %v", err)
+	}
+	if manifest.source.URI != "s3://private-bucket/eks/manifest.yaml" {
+		t.Errorf("Expected the S3 URI to be recorded, got %s", manifest.source.URI)
+	}
+	if len(manifest.SupportedEksReleases) == 0 {
+		t.Error("Expected EKS releases in the manifest read from S3")
+	}
+}
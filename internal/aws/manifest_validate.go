@@ -0,0 +1,313 @@
+package aws
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+	"sigs.k8s.io/yaml"
+)
+
+// requiredEksArtifacts are the artifacts of a patch release that nodeadm installs on every node.
+var requiredEksArtifacts = []string{"kubelet", "kubectl", "cni-plugins", "ecr-credential-provider", "aws-iam-authenticator"}
+
+var (
+	regionPattern       = regexp.MustCompile(`^[a-z]{2,4}(-[a-z]+)+-\d+$`)
+	ecrAccountIDPattern = regexp.MustCompile(`^\d{12}$`)
+)
+
+// supportedURISchemes are the schemes of the artifact URIs nodeadm can download.
+var supportedURISchemes = []string{"https://", "http://", "file://", "s3://", "oci://"}
+
+// supportedCredProviders are the credential providers a region can enable.
+var supportedCredProviders = map[string]bool{"ssm": true, "iam-ra": true}
+
+// ManifestIssue is a problem found in a release manifest.
+type ManifestIssue struct {
+	// Path locates the problem in the manifest, for example
+	// supported_eks_releases[1.31].patch_releases[1.31.2].artifacts[kubelet linux/amd64].
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (i ManifestIssue) String() string {
+	if i.Path == "" {
+		return i.Message
+	}
+	return i.Path + ": " + i.Message
+}
+
+type manifestValidator struct {
+	issues []ManifestIssue
+}
+
+func (v *manifestValidator) add(path, format string, args ...any) {
+	v.issues = append(v.issues, ManifestIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateManifest checks that data is a well formed release manifest: it only has known
+// fields, versions are valid semantic versions, releases, artifacts and regions aren't
+// duplicated, every release covers all the platforms of the manifest and region entries are
+// complete. It returns all the issues found, or nil if the manifest is valid.
+func ValidateManifest(data []byte) []ManifestIssue {
+	v := &manifestValidator{}
+	var manifest Manifest
+	if err := yaml.UnmarshalStrict(data, &manifest); err != nil {
+		// Report unknown fields but keep going if the manifest still parses.
+		if lenientErr := yaml.Unmarshal(data, &manifest); lenientErr != nil {
+			v.add("", "invalid yaml: %v", lenientErr)
+			return v.issues
+		}
+		v.add("", "schema: %v", err)
+	}
+
+	platforms := manifestPlatforms(&manifest)
+	v.validateEksReleases(manifest.SupportedEksReleases, platforms)
+	v.validateIamRolesAnywhereReleases(manifest.IamRolesAnywhereReleases, platforms)
+	for i, release := range manifest.SsmReleases {
+		v.validateArtifacts(fmt.Sprintf("ssm_releases[%d]", i), release.Artifacts)
+	}
+	v.validateRegions(manifest.RegionConfig)
+	return v.issues
+}
+
+func (v *manifestValidator) validateEksReleases(releases []SupportedEksRelease, platforms []string) {
+	if len(releases) == 0 {
+		v.add("supported_eks_releases", "no releases")
+	}
+	seenMinor := map[string]bool{}
+	for i, release := range releases {
+		path := fmt.Sprintf("supported_eks_releases[%d]", i)
+		if release.MajorMinorVersion != "" {
+			path = fmt.Sprintf("supported_eks_releases[%s]", release.MajorMinorVersion)
+		}
+		minor := "v" + release.MajorMinorVersion
+		if !semver.IsValid(minor) || semver.MajorMinor(minor) != minor || strings.Count(release.MajorMinorVersion, ".") != 1 {
+			v.add(path, "major_minor_version %q is not a major.minor version", release.MajorMinorVersion)
+		}
+		if seenMinor[release.MajorMinorVersion] {
+			v.add(path, "duplicate major_minor_version")
+		}
+		seenMinor[release.MajorMinorVersion] = true
+		if len(release.PatchReleases) == 0 {
+			v.add(path, "no patch releases")
+		}
+
+		latestFound := false
+		seenPatch := map[string]bool{}
+		for j, patch := range release.PatchReleases {
+			patchPath := fmt.Sprintf("%s.patch_releases[%d]", path, j)
+			if patch.Version != "" {
+				patchPath = fmt.Sprintf("%s.patch_releases[%s]", path, patch.Version)
+			}
+			version := "v" + patch.Version
+			switch {
+			case !semver.IsValid(version) || strings.Count(patch.Version, ".") != 2:
+				v.add(patchPath, "version %q is not a major.minor.patch version", patch.Version)
+			case semver.MajorMinor(version) != minor:
+				v.add(patchPath, "version %s is not a %s release", patch.Version, release.MajorMinorVersion)
+			case patch.Version != release.MajorMinorVersion+"."+patch.PatchVersion:
+				v.add(patchPath, "patch_version %q doesn't match version %s", patch.PatchVersion, patch.Version)
+			}
+			if _, err := time.Parse("2006-01-02", patch.ReleaseDate); err != nil {
+				v.add(patchPath, "release_date %q is not a YYYY-MM-DD date", patch.ReleaseDate)
+			}
+			// Rebuilds of a patch version are published with a new release date.
+			key := patch.Version + "/" + patch.ReleaseDate
+			if seenPatch[key] {
+				v.add(patchPath, "duplicate release of %s on %s", patch.Version, patch.ReleaseDate)
+			}
+			seenPatch[key] = true
+			if patch.PatchVersion == release.LatestPatchVersion {
+				latestFound = true
+			}
+
+			v.validateArtifacts(patchPath, patch.Artifacts)
+			v.validateCoverage(patchPath, patch.Artifacts, platforms, requiredEksArtifacts)
+		}
+		if !latestFound {
+			v.add(path, "latest_patch_version %q has no patch release", release.LatestPatchVersion)
+		}
+	}
+}
+
+func (v *manifestValidator) validateIamRolesAnywhereReleases(releases []IamRolesAnywhereRelease, platforms []string) {
+	if len(releases) == 0 {
+		v.add("iam_roles_anywhere_releases", "no releases")
+	}
+	seen := map[string]bool{}
+	for i, release := range releases {
+		path := fmt.Sprintf("iam_roles_anywhere_releases[%d]", i)
+		if seen[release.Version] {
+			v.add(path, "duplicate version %s", release.Version)
+		}
+		seen[release.Version] = true
+		v.validateArtifacts(path, release.Artifacts)
+		v.validateCoverage(path, release.Artifacts, platforms, nil)
+	}
+}
+
+// validateArtifacts checks that every artifact has the fields needed to download and
+// verify it, and that none is listed twice for the same platform.
+func (v *manifestValidator) validateArtifacts(path string, artifacts []Artifact) {
+	seen := map[string]bool{}
+	for i, a := range artifacts {
+		artifactPath := fmt.Sprintf("%s.artifacts[%d]", path, i)
+		if a.Name != "" {
+			artifactPath = fmt.Sprintf("%s.artifacts[%s %s]", path, a.Name, platform(a))
+		}
+		if a.Name == "" || a.Arch == "" || a.OS == "" {
+			v.add(artifactPath, "name, arch and os are required")
+		}
+		key := a.Name + " " + platform(a)
+		if seen[key] {
+			v.add(artifactPath, "duplicate artifact")
+		}
+		seen[key] = true
+
+		if a.URI == "" {
+			v.add(artifactPath, "uri is required")
+		}
+		for _, uri := range append([]string{a.URI, a.ChecksumURI, a.GzipURI}, a.Mirrors...) {
+			if uri != "" && !hasSupportedScheme(uri) {
+				v.add(artifactPath, "unsupported URI %s, expected one of %s", uri, strings.Join(supportedURISchemes, ", "))
+			}
+		}
+		for _, mirror := range a.Mirrors {
+			if isOCI(mirror) {
+				if _, _, err := parseOCIArtifactURI(mirror); err != nil {
+					v.add(artifactPath, "mirror %v", err)
+				}
+			}
+		}
+		if isOCI(a.URI) {
+			if _, _, err := parseOCIArtifactURI(a.URI); err != nil {
+				v.add(artifactPath, "%v", err)
+			}
+			continue
+		}
+		_, digest, err := a.InlineDigest()
+		if err != nil {
+			v.add(artifactPath, "%v", err)
+		} else if digest == nil && a.ChecksumURI == "" {
+			v.add(artifactPath, "no checksum, set sha256, sha512 or checksum_uri")
+		} else if digest == nil && len(a.Mirrors) > 0 && len(a.ChecksumURIs()) == 1 {
+			// The checksum file would be a single point of failure for every mirror.
+			v.add(artifactPath, "mirrored artifact needs sha256, sha512 or a checksum_uri that is its uri with a suffix")
+		}
+	}
+}
+
+// validateCoverage checks that every artifact of a release, and every required artifact, is
+// available for all the platforms of the manifest.
+func (v *manifestValidator) validateCoverage(path string, artifacts []Artifact, platforms, required []string) {
+	available := map[string]map[string]bool{}
+	names := append([]string{}, required...)
+	for _, a := range artifacts {
+		if available[a.Name] == nil {
+			available[a.Name] = map[string]bool{}
+			if !slices.Contains(required, a.Name) {
+				names = append(names, a.Name)
+			}
+		}
+		available[a.Name][platform(a)] = true
+	}
+	if len(artifacts) > 0 && len(required) == 0 {
+		// Releases without required artifacts only need to cover every platform with one of them.
+		covered := map[string]bool{}
+		for _, a := range artifacts {
+			covered[platform(a)] = true
+		}
+		for _, p := range platforms {
+			if !covered[p] {
+				v.add(path, "no artifacts for %s", p)
+			}
+		}
+		return
+	}
+	for _, name := range names {
+		var missing []string
+		for _, p := range platforms {
+			if !available[name][p] {
+				missing = append(missing, p)
+			}
+		}
+		if len(missing) > 0 {
+			v.add(path, "%s is missing for %s", name, strings.Join(missing, ", "))
+		}
+	}
+}
+
+func (v *manifestValidator) validateRegions(regions RegionConfig) {
+	if len(regions) == 0 {
+		v.add("region_config", "no regions")
+	}
+	names := make([]string, 0, len(regions))
+	for name := range regions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		region := regions[name]
+		path := fmt.Sprintf("region_config[%s]", name)
+		if !regionPattern.MatchString(name) {
+			v.add(path, "%q is not a region name", name)
+		}
+		if region.Partition == "" {
+			v.add(path, "partition is required")
+		} else if expected := GetPartitionFromRegionFallback(name); region.Partition != expected {
+			v.add(path, "partition %s doesn't match the region, expected %s", region.Partition, expected)
+		}
+		if region.DnsSuffix == "" {
+			v.add(path, "dns_suffix is required")
+		}
+		if !ecrAccountIDPattern.MatchString(region.EcrAccountID) {
+			v.add(path, "ecr_account_id %q is not a 12 digit account id", region.EcrAccountID)
+		}
+		if len(region.CredProviders) == 0 {
+			v.add(path, "cred_providers is required")
+		}
+		for provider := range region.CredProviders {
+			if !supportedCredProviders[provider] {
+				v.add(path, "unknown credential provider %s", provider)
+			}
+		}
+	}
+}
+
+// manifestPlatforms returns the os/arch platforms the EKS releases of a manifest are published for.
+func manifestPlatforms(manifest *Manifest) []string {
+	seen := map[string]bool{}
+	for _, release := range manifest.SupportedEksReleases {
+		for _, patch := range release.PatchReleases {
+			for _, a := range patch.Artifacts {
+				if a.Arch != "" && a.OS != "" {
+					seen[platform(a)] = true
+				}
+			}
+		}
+	}
+	platforms := make([]string, 0, len(seen))
+	for p := range seen {
+		platforms = append(platforms, p)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+func platform(a Artifact) string {
+	return a.OS + "/" + a.Arch
+}
+
+func hasSupportedScheme(uri string) bool {
+	for _, scheme := range supportedURISchemes {
+		if strings.HasPrefix(uri, scheme) {
+			return true
+		}
+	}
+	return false
+}
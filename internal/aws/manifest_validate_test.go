@@ -0,0 +1,229 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func validTestManifest() Manifest {
+	artifacts := func(base string, names ...string) []Artifact {
+		var artifacts []Artifact
+		for _, arch := range []string{"amd64", "arm64"} {
+			for _, name := range names {
+				artifacts = append(artifacts, Artifact{
+					Name:        name,
+					Arch:        arch,
+					OS:          "linux",
+					URI:         base + "/" + arch + "/" + name,
+					ChecksumURI: base + "/" + arch + "/" + name + ".sha256",
+				})
+			}
+		}
+		return artifacts
+	}
+	return Manifest{
+		SupportedEksReleases: []SupportedEksRelease{{
+			MajorMinorVersion:  "1.31",
+			LatestPatchVersion: "2",
+			PatchReleases: []EksPatchRelease{
+				{Version: "1.31.1", PatchVersion: "1", ReleaseDate: "2025-01-10", Artifacts: artifacts("https://example.com/1.31.1", requiredEksArtifacts...)},
+				{Version: "1.31.2", PatchVersion: "2", ReleaseDate: "2025-02-10", Artifacts: artifacts("https://example.com/1.31.2", requiredEksArtifacts...)},
+			},
+		}},
+		IamRolesAnywhereReleases: []IamRolesAnywhereRelease{
+			{Version: "1.4.0", Artifacts: artifacts("https://example.com/iam-ra", "aws_signing_helper")},
+		},
+		RegionConfig: RegionConfig{
+			"us-west-2":  {EcrAccountID: "602401143452", Partition: "aws", DnsSuffix: "amazonaws.com", CredProviders: map[string]bool{"ssm": true, "iam-ra": true}},
+			"cn-north-1": {EcrAccountID: "918309763551", Partition: "aws-cn", DnsSuffix: "amazonaws.com.cn", CredProviders: map[string]bool{"ssm": true}},
+		},
+	}
+}
+
+func TestValidateManifest(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Manifest)
+		want   []string
+	}{
+		{
+			name:   "valid",
+			modify: func(*Manifest) {},
+		},
+		{
+			name: "invalid versions",
+			modify: func(m *Manifest) {
+				m.SupportedEksReleases[0].PatchReleases[0].Version = "1.31"
+				m.SupportedEksReleases[0].PatchReleases[1].Version = "1.30.2"
+			},
+			want: []string{
+				`supported_eks_releases[1.31].patch_releases[1.31]: version "1.31" is not a major.minor.patch version`,
+				"supported_eks_releases[1.31].patch_releases[1.30.2]: version 1.30.2 is not a 1.31 release",
+			},
+		},
+		{
+			name: "patch version mismatch and bad date",
+			modify: func(m *Manifest) {
+				m.SupportedEksReleases[0].PatchReleases[0].PatchVersion = "3"
+				m.SupportedEksReleases[0].PatchReleases[0].ReleaseDate = "01/10/2025"
+			},
+			want: []string{
+				`supported_eks_releases[1.31].patch_releases[1.31.1]: patch_version "3" doesn't match version 1.31.1`,
+				`supported_eks_releases[1.31].patch_releases[1.31.1]: release_date "01/10/2025" is not a YYYY-MM-DD date`,
+			},
+		},
+		{
+			name: "missing latest patch",
+			modify: func(m *Manifest) {
+				m.SupportedEksReleases[0].LatestPatchVersion = "5"
+			},
+			want: []string{`supported_eks_releases[1.31]: latest_patch_version "5" has no patch release`},
+		},
+		{
+			name: "duplicates",
+			modify: func(m *Manifest) {
+				m.SupportedEksReleases = append(m.SupportedEksReleases, SupportedEksRelease{
+					MajorMinorVersion:  "1.31",
+					LatestPatchVersion: "1",
+					PatchReleases:      m.SupportedEksReleases[0].PatchReleases[:1],
+				})
+				patch := &m.SupportedEksReleases[0].PatchReleases[1]
+				patch.Artifacts = append(patch.Artifacts, patch.Artifacts[0])
+			},
+			want: []string{
+				"supported_eks_releases[1.31].patch_releases[1.31.2].artifacts[kubelet linux/amd64]: duplicate artifact",
+				"supported_eks_releases[1.31]: duplicate major_minor_version",
+			},
+		},
+		{
+			name: "missing platform",
+			modify: func(m *Manifest) {
+				patch := &m.SupportedEksReleases[0].PatchReleases[1]
+				patch.Artifacts = patch.Artifacts[:len(patch.Artifacts)-1]
+				m.IamRolesAnywhereReleases[0].Artifacts = m.IamRolesAnywhereReleases[0].Artifacts[:1]
+			},
+			want: []string{
+				"supported_eks_releases[1.31].patch_releases[1.31.2]: aws-iam-authenticator is missing for linux/arm64",
+				"iam_roles_anywhere_releases[0]: no artifacts for linux/arm64",
+			},
+		},
+		{
+			name: "artifact without checksum",
+			modify: func(m *Manifest) {
+				patch := &m.SupportedEksReleases[0].PatchReleases[1]
+				patch.Artifacts[0].ChecksumURI = ""
+				patch.Artifacts[1].ChecksumURI = ""
+				patch.Artifacts[1].Sha256 = strings.Repeat("ab", 32)
+				patch.Artifacts[2].URI = "ftp://example.com/cni-plugins"
+			},
+			want: []string{
+				"supported_eks_releases[1.31].patch_releases[1.31.2].artifacts[kubelet linux/amd64]: no checksum, set sha256, sha512 or checksum_uri",
+				"supported_eks_releases[1.31].patch_releases[1.31.2].artifacts[cni-plugins linux/amd64]: unsupported URI ftp://example.com/cni-plugins, expected one of https://, http://, file://, s3://, oci://",
+			},
+		},
+		{
+			name: "mirrored artifact with a checksum file elsewhere",
+			modify: func(m *Manifest) {
+				patch := &m.SupportedEksReleases[0].PatchReleases[1]
+				patch.Artifacts[0].Mirrors = []string{"https://mirror.example.com/kubelet"}
+				patch.Artifacts[0].ChecksumURI = "https://example.com/checksums/kubelet.sha256"
+				patch.Artifacts[1].Mirrors = []string{"https://mirror.example.com/kubectl"}
+			},
+			want: []string{
+				"supported_eks_releases[1.31].patch_releases[1.31.2].artifacts[kubelet linux/amd64]: mirrored artifact needs sha256, sha512 or a checksum_uri that is its uri with a suffix",
+			},
+		},
+		{
+			name: "regions",
+			modify: func(m *Manifest) {
+				m.RegionConfig["us-gov-west-1"] = RegionData{EcrAccountID: "123", Partition: "aws", CredProviders: map[string]bool{"password": true}}
+			},
+			want: []string{
+				"region_config[us-gov-west-1]: partition aws doesn't match the region, expected aws-us-gov",
+				"region_config[us-gov-west-1]: dns_suffix is required",
+				`region_config[us-gov-west-1]: ecr_account_id "123" is not a 12 digit account id`,
+				"region_config[us-gov-west-1]: unknown credential provider password",
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			manifest := validTestManifest()
+			tc.modify(&manifest)
+			data, err := yaml.Marshal(manifest)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, issue := range ValidateManifest(data) {
+				got = append(got, issue.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("Expected issues:\n%s\ngot:\n%s", strings.Join(tc.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidateManifestSchema(t *testing.T) {
+	data, err := yaml.Marshal(validTestManifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues := ValidateManifest(append(data, []byte("unknown_field: true\n")...))
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, `unknown field "unknown_field"`) {
+		t.Errorf("Expected an unknown field issue, got %v", issues)
+	}
+
+	issues = ValidateManifest([]byte("supported_eks_releases: {"))
+	if len(issues) != 1 || !strings.HasPrefix(issues[0].Message, "invalid yaml") {
+		t.Errorf("Expected an invalid yaml issue, got %v", issues)
+	}
+}
+
+func TestDiffEksPatchReleases(t *testing.T) {
+	manifest := validTestManifest()
+	from := manifest.SupportedEksReleases[0].PatchReleases[0]
+	to := manifest.SupportedEksReleases[0].PatchReleases[1]
+	// Keep kubectl identical, drop kubelet and add a new artifact.
+	for i, a := range to.Artifacts {
+		if a.Name == "kubectl" {
+			to.Artifacts[i] = from.Artifacts[i]
+		}
+	}
+	to.Artifacts = append(to.Artifacts[1:], Artifact{Name: "nodeadm", Arch: "amd64", OS: "linux", URI: "https://example.com/nodeadm"})
+	to.Artifacts = removeArtifact(to.Artifacts, "kubelet", "arm64")
+
+	var got []string
+	for _, change := range DiffEksPatchReleases(from, to) {
+		got = append(got, string(change.Type)+" "+change.Name+" "+change.OS+"/"+change.Arch)
+	}
+	want := []string{
+		"updated aws-iam-authenticator linux/amd64",
+		"updated aws-iam-authenticator linux/arm64",
+		"updated cni-plugins linux/amd64",
+		"updated cni-plugins linux/arm64",
+		"updated ecr-credential-provider linux/amd64",
+		"updated ecr-credential-provider linux/arm64",
+		"removed kubelet linux/amd64",
+		"removed kubelet linux/arm64",
+		"added nodeadm linux/amd64",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected changes:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func removeArtifact(artifacts []Artifact, name, arch string) []Artifact {
+	var kept []Artifact
+	for _, a := range artifacts {
+		if a.Name != name || a.Arch != arch {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
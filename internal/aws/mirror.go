@@ -0,0 +1,147 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/artifact"
+	"github.com/aws/eks-hybrid/internal/logger"
+)
+
+// mirrorSeparator separates the mirrors of the manifest in the build time manifest URL,
+// which is set with a linker flag and can only be a single string.
+const mirrorSeparator = ","
+
+// splitMirrors returns the mirrors of a comma separated list of URIs, in order.
+func splitMirrors(uris string) []string {
+	var mirrors []string
+	for _, uri := range strings.Split(uris, mirrorSeparator) {
+		if uri = strings.TrimSpace(uri); uri != "" {
+			mirrors = append(mirrors, uri)
+		}
+	}
+	return mirrors
+}
+
+// mirrorHealth counts the failures of each mirror host since the last success. Hosts that
+// failed are demoted behind the healthy ones for the rest of the command, so an outage
+// only slows down the first download that hits it.
+type mirrorHealth struct {
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+var mirrors = &mirrorHealth{failures: map[string]int{}}
+
+// order returns uris sorted by the health of their hosts. Mirrors that are equally
+// healthy keep the order they are listed in.
+func (h *mirrorHealth) order(uris []string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ordered := slices.Clone(uris)
+	slices.SortStableFunc(ordered, func(a, b string) int {
+		return h.failures[mirrorHost(a)] - h.failures[mirrorHost(b)]
+	})
+	return ordered
+}
+
+func (h *mirrorHealth) failed(uri string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures[mirrorHost(uri)]++
+}
+
+func (h *mirrorHealth) succeeded(uri string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.failures, mirrorHost(uri))
+}
+
+// mirrorHost returns the scheme and host a mirror is tracked by.
+func mirrorHost(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+// tryMirrors opens the file called name from the healthiest of uris, trying the others in
+// order when it fails, and returns the URI that served it.
+func tryMirrors[T any](ctx context.Context, name string, uris []string, open func(uri string) (T, error)) (T, string, error) {
+	var zero T
+	if len(uris) == 0 {
+		return zero, "", fmt.Errorf("no URI to read %s from", name)
+	}
+	log := logger.FromContext(ctx)
+	var errs []error
+	for _, uri := range mirrors.order(uris) {
+		file, err := open(uri)
+		if err == nil {
+			mirrors.succeeded(uri)
+			if len(uris) > 1 {
+				log.Info("Using mirror", zap.String("file", name), zap.String("mirror", uri))
+			}
+			return file, uri, nil
+		}
+		if ctx.Err() != nil {
+			return zero, "", err
+		}
+		mirrors.failed(uri)
+		errs = append(errs, err)
+		if len(uris) > 1 {
+			log.Warn("Mirror failed, trying the next one", zap.String("file", name), zap.String("mirror", uri), zap.Error(err))
+		}
+	}
+	if len(errs) == 1 {
+		return zero, "", errs[0]
+	}
+	return zero, "", fmt.Errorf("all %d mirrors of %s failed: %w", len(uris), name, errors.Join(errs...))
+}
+
+// watchMirror demotes the mirror at uri if reading rc fails after it was opened.
+func watchMirror(uri string, rc io.ReadCloser) io.ReadCloser {
+	return &mirrorReader{ReadCloser: rc, uri: uri}
+}
+
+type mirrorReader struct {
+	io.ReadCloser
+	uri string
+}
+
+func (m *mirrorReader) Read(p []byte) (int, error) {
+	n, err := m.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		mirrors.failed(m.uri)
+	}
+	return n, err
+}
+
+// verifyMirror demotes the host that served src if src doesn't match its checksum, so the
+// next download of the artifact tries another mirror first.
+func verifyMirror(uri string, src artifact.Source) artifact.Source {
+	return &mirrorSource{Source: src, uri: uri}
+}
+
+type mirrorSource struct {
+	artifact.Source
+	uri     string
+	demoted bool
+}
+
+func (m *mirrorSource) VerifyChecksum() bool {
+	ok := m.Source.VerifyChecksum()
+	if !ok && !m.demoted {
+		m.demoted = true
+		mirrors.failed(m.uri)
+	}
+	return ok
+}
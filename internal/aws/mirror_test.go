@@ -0,0 +1,223 @@
+package aws
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func resetMirrorHealth(t *testing.T) {
+	previous := mirrors
+	mirrors = &mirrorHealth{failures: map[string]int{}}
+	t.Cleanup(func() { mirrors = previous })
+}
+
+func TestGetSourceFromMirror(t *testing.T) {
+	resetMirrorHealth(t)
+	var outageRequests int
+	outage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		outageRequests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer outage.Close()
+	source := newStagingTestSource(t, map[string]string{"kubelet": "kubelet binary"}, nil)
+	mirror := source.Eks.Artifacts[0].URI
+	source.Eks.Artifacts[0].URI = outage.URL + "/kubelet"
+	source.Eks.Artifacts[0].ChecksumURI = ""
+	source.Eks.Artifacts[0].Sha256 = sha256Hex("kubelet binary")
+	source.Eks.Artifacts[0].Mirrors = []string{mirror}
+
+	for range 2 {
+		kubelet, err := source.GetKubelet(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to get kubelet from mirror: %v", err)
+		}
+		data, err := io.ReadAll(kubelet)
+		kubelet.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "kubelet binary" || !kubelet.VerifyChecksum() {
+			t.Errorf("Expected kubelet from the mirror, got %q", data)
+		}
+	}
+
+	// The failed host is demoted, so the second download goes straight to the mirror.
+	if outageRequests != 3 {
+		t.Errorf("Expected only the first download to try the failed host, got %d requests", outageRequests)
+	}
+}
+
+func TestGetSourceAllMirrorsFail(t *testing.T) {
+	resetMirrorHealth(t)
+	source := newStagingTestSource(t, map[string]string{"kubelet": "kubelet binary"}, nil)
+	source.Eks.Artifacts[0].ChecksumURI = ""
+	source.Eks.Artifacts[0].Sha256 = sha256Hex("kubelet binary")
+	source.Eks.Artifacts[0].URI += "-missing"
+	source.Eks.Artifacts[0].Mirrors = []string{"file:///nonexistent/kubelet"}
+
+	_, err := source.GetKubelet(context.Background())
+	if err == nil {
+		t.Fatal("Expected an error when no mirror serves the artifact")
+	}
+	if want := "all 2 mirrors of kubelet failed"; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("Expected %q, got %v", want, err)
+	}
+}
+
+func TestGetReleaseManifestFromMirrors(t *testing.T) {
+	resetMirrorHealth(t)
+	manifestPath, err := filepath.Abs(filepath.Join("testdata", "manifest.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(t.TempDir(), "manifest.yaml")
+
+	manifest, err := getReleaseManifestFromURI(context.Background(), "file://"+missing, WithManifestMirrors("file://"+manifestPath), WithInsecureSkipVerify())
+	if err != nil {
+		t.Fatalf("Failed to read manifest from mirrors: %v", err)
+	}
+	if manifest.source.URI != "file://"+manifestPath {
+		t.Errorf("Expected the mirror serving the manifest to be recorded, got %s", manifest.source.URI)
+	}
+
+	// URIs are never split, they can contain commas.
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commaPath := filepath.Join(t.TempDir(), "manifest,1.31.yaml")
+	if err := os.WriteFile(commaPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getReleaseManifestFromURI(context.Background(), "file://"+commaPath, WithInsecureSkipVerify()); err != nil {
+		t.Errorf("Failed to read manifest with a comma in its URI: %v", err)
+	}
+}
+
+func TestGetSourceChecksumFromMirror(t *testing.T) {
+	resetMirrorHealth(t)
+	outage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer outage.Close()
+	source := newStagingTestSource(t, map[string]string{"kubelet": "kubelet binary"}, map[string]string{
+		"kubelet.sha256": sha256Hex("kubelet binary"),
+	})
+	mirror := source.Eks.Artifacts[0].URI
+	source.Eks.Artifacts[0].URI = outage.URL + "/kubelet"
+	source.Eks.Artifacts[0].ChecksumURI = outage.URL + "/kubelet.sha256"
+	source.Eks.Artifacts[0].Mirrors = []string{mirror}
+
+	kubelet, err := source.GetKubelet(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get kubelet and its checksum from mirror: %v", err)
+	}
+	defer kubelet.Close()
+	if _, err := io.Copy(io.Discard, kubelet); err != nil {
+		t.Fatal(err)
+	}
+	if !kubelet.VerifyChecksum() {
+		t.Error("Expected kubelet to match the checksum read from the mirror")
+	}
+}
+
+func TestStageRetriesMirrorOnChecksumMismatch(t *testing.T) {
+	resetMirrorHealth(t)
+	tampered := newStagingTestSource(t, map[string]string{"kubelet": "tampered"}, nil)
+	source := newStagingTestSource(t, map[string]string{"kubelet": "kubelet binary"}, nil)
+	mirror := source.Eks.Artifacts[0].URI
+	source.Eks.Artifacts[0].URI = tampered.Eks.Artifacts[0].URI
+	source.Eks.Artifacts[0].ChecksumURI = ""
+	source.Eks.Artifacts[0].Sha256 = sha256Hex("kubelet binary")
+	source.Eks.Artifacts[0].Mirrors = []string{mirror}
+
+	staged, err := source.Stage(context.Background(), filepath.Join(t.TempDir(), "staging"), []string{"kubelet"}, 1, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Expected the download to be retried on the mirror: %v", err)
+	}
+	defer staged.Remove()
+	kubelet, err := staged.GetKubelet(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kubelet.Close()
+	data, err := io.ReadAll(kubelet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "kubelet binary" {
+		t.Errorf("Expected kubelet from the mirror, got %q", data)
+	}
+}
+
+func TestDownloadFailsOverOnBrokenMirrors(t *testing.T) {
+	resetMirrorHealth(t)
+	var gzipped bytes.Buffer
+	writer := gzip.NewWriter(&gzipped)
+	if _, err := writer.Write([]byte(strings.Repeat("kubelet binary", 1024))); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The gzipped file breaks off mid-stream and the uncompressed one is tampered with.
+	truncated := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(gzipped.Bytes()[:gzipped.Len()/2])
+	}))
+	defer truncated.Close()
+	tampered := newStagingTestSource(t, map[string]string{"kubelet": "tampered"}, nil)
+	source := newStagingTestSource(t, map[string]string{"kubelet": strings.Repeat("kubelet binary", 1024)}, nil)
+	mirror := source.Eks.Artifacts[0].URI
+	source.Eks.Artifacts[0].GzipURI = truncated.URL + "/kubelet.gz"
+	source.Eks.Artifacts[0].URI = tampered.Eks.Artifacts[0].URI
+	source.Eks.Artifacts[0].ChecksumURI = ""
+	source.Eks.Artifacts[0].Sha256 = sha256Hex(strings.Repeat("kubelet binary", 1024))
+	source.Eks.Artifacts[0].Mirrors = []string{mirror}
+
+	// A single download goes on to the next mirror until one serves the artifact.
+	path := filepath.Join(t.TempDir(), "kubelet")
+	if _, err := source.download(context.Background(), "kubelet", path, zap.NewNop()); err != nil {
+		t.Fatalf("Expected the download to fail over to the last mirror: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != strings.Repeat("kubelet binary", 1024) {
+		t.Errorf("Expected kubelet from the last mirror, got %d bytes", len(data))
+	}
+}
+
+func TestMirrorHealthOrder(t *testing.T) {
+	health := &mirrorHealth{failures: map[string]int{}}
+	uris := []string{"https://local.example.com/a", "s3://regional/a", "https://cdn.example.com/a"}
+
+	if got := health.order(uris); !reflect.DeepEqual(got, uris) {
+		t.Errorf("Expected healthy mirrors in listed order, got %v", got)
+	}
+
+	health.failed("https://local.example.com/b")
+	health.failed("https://local.example.com/c")
+	health.failed("s3://regional/b")
+	want := []string{"https://cdn.example.com/a", "s3://regional/a", "https://local.example.com/a"}
+	if got := health.order(uris); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected failed hosts demoted, got %v", got)
+	}
+
+	health.succeeded("https://local.example.com/d")
+	want = []string{"https://local.example.com/a", "https://cdn.example.com/a", "s3://regional/a"}
+	if got := health.order(uris); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected a host to recover after a success, got %v", got)
+	}
+}
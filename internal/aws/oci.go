@@ -0,0 +1,67 @@
+package aws
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/eks-hybrid/internal/oci"
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+// registryAuth finds the settings and credentials of the registries serving oci:// artifacts.
+var registryAuth = oci.DefaultAuth()
+
+// isOCI returns true if uri references a blob in an OCI registry.
+func isOCI(uri string) bool {
+	return strings.HasPrefix(uri, oci.Scheme)
+}
+
+// parseOCIArtifactURI parses an oci://registry/repository@sha256:<hex> artifact URI and
+// returns the sha256 checksum of the artifact, which is the digest of the blob.
+func parseOCIArtifactURI(uri string) (oci.Reference, []byte, error) {
+	ref, err := oci.ParseReference(uri)
+	if err != nil {
+		return oci.Reference{}, nil, err
+	}
+	if ref.Digest == "" {
+		return oci.Reference{}, nil, fmt.Errorf("OCI artifact %s must be referenced by digest", uri)
+	}
+	checksum, err := hex.DecodeString(strings.TrimPrefix(ref.Digest, "sha256:"))
+	if err != nil {
+		return oci.Reference{}, nil, fmt.Errorf("invalid digest in %s: %w", uri, err)
+	}
+	return ref, checksum, nil
+}
+
+// OpenArtifactURI opens the file at an artifact URI: a blob for oci:// URIs, or else
+// the file served at the URI.
+func OpenArtifactURI(ctx context.Context, uri string) (io.ReadCloser, error) {
+	if isOCI(uri) {
+		return openOCIBlob(ctx, uri)
+	}
+	return util.GetHttpFileReader(ctx, uri)
+}
+
+// openOCIBlob pulls the blob referenced by an oci:// artifact URI. When reading from an
+// offline mirror, such as a bundle, the blob is read from the mirror instead.
+func openOCIBlob(ctx context.Context, uri string) (io.ReadCloser, error) {
+	if util.Offline() {
+		return util.GetHttpFileReader(ctx, uri)
+	}
+	ref, _, err := parseOCIArtifactURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	client, err := registryAuth.NewClient(ref)
+	if err != nil {
+		return nil, fmt.Errorf("configuring registry client: %w", err)
+	}
+	blob, err := client.GetBlob(ctx, ref.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("getting artifact blob: %w", err)
+	}
+	return blob, nil
+}
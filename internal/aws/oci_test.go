@@ -0,0 +1,105 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/aws/eks-hybrid/internal/artifact"
+	"github.com/aws/eks-hybrid/internal/oci"
+	"github.com/aws/eks-hybrid/internal/oci/ocitest"
+)
+
+// newOCITestSource pushes content to a registry requiring credentials, read from a docker
+// config, and returns a source referencing it as the kubelet artifact.
+func newOCITestSource(t *testing.T, content string) (Source, *ocitest.Registry) {
+	t.Helper()
+	registry := ocitest.NewRegistry(t)
+	registry.Username = "robot"
+	registry.Password = "secret"
+	digest := registry.AddBlob("eks/artifacts", []byte(content))
+
+	hostsDir := t.TempDir()
+	hostDir := filepath.Join(hostsDir, strings.ReplaceAll(registry.Host(), ":", "_"))
+	if err := os.MkdirAll(hostDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	hosts := fmt.Sprintf("server = %q\n", registry.URL)
+	if err := os.WriteFile(filepath.Join(hostDir, "hosts.toml"), []byte(hosts), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dockerConfig := filepath.Join(t.TempDir(), "config.json")
+	auth := base64.StdEncoding.EncodeToString([]byte("robot:secret"))
+	if err := os.WriteFile(dockerConfig, []byte(fmt.Sprintf(`{"auths":{%q:{"auth":%q}}}`, registry.Host(), auth)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	defaultAuth := registryAuth
+	registryAuth = oci.Auth{HostsDir: hostsDir, DockerConfig: dockerConfig}
+	t.Cleanup(func() { registryAuth = defaultAuth })
+
+	return Source{Eks: EksPatchRelease{Artifacts: []Artifact{{
+		Name: "kubelet",
+		Arch: runtime.GOARCH,
+		OS:   runtime.GOOS,
+		URI:  "oci://" + registry.Host() + "/eks/artifacts@" + digest,
+	}}}}, registry
+}
+
+func TestGetOCISource(t *testing.T) {
+	source, _ := newOCITestSource(t, "kubelet binary")
+
+	kubelet, err := source.GetKubelet(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get kubelet from registry: %v", err)
+	}
+	defer kubelet.Close()
+	data, err := io.ReadAll(kubelet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "kubelet binary" {
+		t.Errorf("Expected kubelet content, got %q", data)
+	}
+	if !kubelet.VerifyChecksum() {
+		t.Error("Expected the blob to match its digest")
+	}
+
+	algorithm, checksum, err := source.GetArtifactChecksum(context.Background(), "kubelet")
+	if err != nil {
+		t.Fatalf("Failed to get kubelet checksum: %v", err)
+	}
+	if algorithm != artifact.SHA256 {
+		t.Errorf("Expected a sha256 checksum, got %s", algorithm)
+	}
+	if hex.EncodeToString(checksum) != sha256Hex("kubelet binary") {
+		t.Errorf("Expected the checksum to be the blob digest, got %x", checksum)
+	}
+}
+
+func TestGetOCISourceRequiresDigest(t *testing.T) {
+	source, registry := newOCITestSource(t, "kubelet binary")
+	source.Eks.Artifacts[0].URI = "oci://" + registry.Host() + "/eks/artifacts:1.31"
+
+	_, err := source.GetKubelet(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "must be referenced by digest") {
+		t.Errorf("Expected a digest error, got %v", err)
+	}
+}
+
+func TestGetOCISourceMissingCredentials(t *testing.T) {
+	source, _ := newOCITestSource(t, "kubelet binary")
+	registryAuth.DockerConfig = ""
+
+	_, err := source.GetKubelet(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "requires credentials") {
+		t.Errorf("Expected a credentials error, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package aws
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SigningHelperArtifact is the name of the IAM Roles Anywhere signing helper in the manifest.
+const SigningHelperArtifact = "aws_signing_helper"
+
+// pinnableComponents are the artifacts that can be held back to a version other than the
+// one of the Kubernetes release being installed. kubelet and kubectl follow the Kubernetes
+// version and can't be pinned.
+var pinnableComponents = []string{SigningHelperArtifact, "cni-plugins", "aws-iam-authenticator", "ecr-credential-provider"}
+
+// PinnableComponents returns the manifest names of the artifacts that can be pinned.
+func PinnableComponents() []string {
+	return append([]string{}, pinnableComponents...)
+}
+
+// ParsePins parses pins in the component=version format.
+func ParsePins(values []string) (map[string]string, error) {
+	pins := map[string]string{}
+	for _, value := range values {
+		component, version, ok := strings.Cut(value, "=")
+		if !ok || component == "" || version == "" {
+			return nil, fmt.Errorf("invalid pin %q, expected component=version", value)
+		}
+		if err := ValidatePinnable(component); err != nil {
+			return nil, err
+		}
+		pins[component] = version
+	}
+	return pins, nil
+}
+
+// ValidatePinnable returns an error if component can't be pinned.
+func ValidatePinnable(component string) error {
+	if !slices.Contains(pinnableComponents, component) {
+		return fmt.Errorf("component %s can't be pinned, allowed components: [%s]", component, strings.Join(pinnableComponents, ", "))
+	}
+	return nil
+}
+
+// ApplyPins holds components back to the versions in pins, keyed by manifest artifact name.
+// The signing helper is pinned to the version of an IAM Roles Anywhere release. Components
+// shipped with the EKS releases are pinned to the Kubernetes patch version of the release
+// they are taken from, for example cni-plugins=1.31.2. Pins are resolved against the
+// manifest the source was read from and recorded in Pins.
+func (as *Source) ApplyPins(pins map[string]string) error {
+	if len(pins) == 0 {
+		return nil
+	}
+	if as.manifest == nil {
+		return fmt.Errorf("can't pin components of a source without a manifest")
+	}
+
+	components := make([]string, 0, len(pins))
+	for component := range pins {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	for _, component := range components {
+		version := pins[component]
+		if err := ValidatePinnable(component); err != nil {
+			return err
+		}
+		if component == SigningHelperArtifact {
+			release, ok := findIamRolesAnywhereRelease(as.manifest, version)
+			if !ok {
+				return fmt.Errorf("pinned %s version %s not found in manifest", component, version)
+			}
+			if _, ok := findArtifact(component, release.Artifacts); !ok {
+				return fmt.Errorf("pinned %s version %s has no artifact for %s arch and %s os", component, version, runtime.GOARCH, runtime.GOOS)
+			}
+			as.Iam = release
+			continue
+		}
+
+		release, err := findEksPatchRelease(as.manifest, version)
+		if err != nil {
+			return fmt.Errorf("pinned %s version %s: %w", component, version, err)
+		}
+		if _, ok := findArtifact(component, release.Artifacts); !ok {
+			return fmt.Errorf("pinned %s version %s has no artifact for %s arch and %s os", component, version, runtime.GOARCH, runtime.GOOS)
+		}
+		if as.pinned == nil {
+			as.pinned = map[string]EksPatchRelease{}
+		}
+		as.pinned[component] = release
+	}
+
+	as.Pins = make(map[string]string, len(pins))
+	for component, version := range pins {
+		as.Pins[component] = version
+	}
+	return nil
+}
+
+// eksRelease returns the EKS release an artifact is taken from.
+func (as Source) eksRelease(artifactName string) EksPatchRelease {
+	if release, ok := as.pinned[artifactName]; ok {
+		return release
+	}
+	return as.Eks
+}
+
+func findIamRolesAnywhereRelease(manifest *Manifest, version string) (IamRolesAnywhereRelease, bool) {
+	for _, release := range manifest.IamRolesAnywhereReleases {
+		if release.Version == version {
+			return release, true
+		}
+	}
+	return IamRolesAnywhereRelease{}, false
+}
+
+// findEksPatchRelease returns the patch release with the given major.minor.patch version.
+// If it was published more than once, the latest release is returned.
+func findEksPatchRelease(manifest *Manifest, version string) (EksPatchRelease, error) {
+	var matched []EksPatchRelease
+	for _, release := range manifest.SupportedEksReleases {
+		for _, patch := range release.PatchReleases {
+			if patch.Version == version {
+				matched = append(matched, patch)
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return EksPatchRelease{}, fmt.Errorf("no Kubernetes %s release found in manifest", version)
+	}
+	return getLatestDateEksPatchRelease(matched)
+}
@@ -0,0 +1,125 @@
+package aws
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func pinTestManifest() *Manifest {
+	artifacts := func(version string, names ...string) []Artifact {
+		var artifacts []Artifact
+		for _, name := range names {
+			artifacts = append(artifacts, Artifact{
+				Name:   name,
+				Arch:   runtime.GOARCH,
+				OS:     runtime.GOOS,
+				URI:    "https://example.com/" + version + "/" + name,
+				Sha256: strings.Repeat("ab", 32),
+			})
+		}
+		return artifacts
+	}
+	return &Manifest{
+		SupportedEksReleases: []SupportedEksRelease{{
+			MajorMinorVersion:  "1.31",
+			LatestPatchVersion: "2",
+			PatchReleases: []EksPatchRelease{
+				{Version: "1.31.1", PatchVersion: "1", ReleaseDate: "2025-01-10", Artifacts: artifacts("1.31.1", "kubelet", "cni-plugins")},
+				{Version: "1.31.2", PatchVersion: "2", ReleaseDate: "2025-02-10", Artifacts: artifacts("1.31.2", "kubelet", "cni-plugins")},
+			},
+		}},
+		IamRolesAnywhereReleases: []IamRolesAnywhereRelease{
+			{Version: "1.5.0", Artifacts: artifacts("1.5.0", SigningHelperArtifact)},
+			{Version: "1.4.0", Artifacts: artifacts("1.4.0", SigningHelperArtifact)},
+		},
+	}
+}
+
+func TestApplyPins(t *testing.T) {
+	source, err := GetReleaseSourceFromManifest("1.31", pinTestManifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source.Iam.Version != "1.5.0" {
+		t.Fatalf("Expected the latest signing helper without pins, got %s", source.Iam.Version)
+	}
+
+	pins := map[string]string{"cni-plugins": "1.31.1", SigningHelperArtifact: "1.4.0"}
+	if err := source.ApplyPins(pins); err != nil {
+		t.Fatalf("Failed to apply pins: %v", err)
+	}
+
+	cni, version, ok := source.LookupArtifact("cni-plugins")
+	if !ok || version != "1.31.1" || cni.URI != "https://example.com/1.31.1/cni-plugins" {
+		t.Errorf("Expected cni-plugins from 1.31.1, got %s from %s", cni.URI, version)
+	}
+	kubelet, version, ok := source.LookupArtifact("kubelet")
+	if !ok || version != "1.31.2" || kubelet.URI != "https://example.com/1.31.2/kubelet" {
+		t.Errorf("Expected kubelet to follow the Kubernetes release, got %s from %s", kubelet.URI, version)
+	}
+	helper, version, ok := source.LookupArtifact(SigningHelperArtifact)
+	if !ok || version != "1.4.0" || helper.URI != "https://example.com/1.4.0/aws_signing_helper" {
+		t.Errorf("Expected the pinned signing helper, got %s from %s", helper.URI, version)
+	}
+	if len(source.Pins) != 2 || source.Pins["cni-plugins"] != "1.31.1" {
+		t.Errorf("Expected the pins to be recorded, got %v", source.Pins)
+	}
+}
+
+func TestApplyPinsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		pins map[string]string
+		want string
+	}{
+		{
+			name: "unknown eks release",
+			pins: map[string]string{"cni-plugins": "1.30.9"},
+			want: "pinned cni-plugins version 1.30.9: no Kubernetes 1.30.9 release found in manifest",
+		},
+		{
+			name: "unknown signing helper release",
+			pins: map[string]string{SigningHelperArtifact: "0.1.0"},
+			want: "pinned aws_signing_helper version 0.1.0 not found in manifest",
+		},
+		{
+			name: "component missing from release",
+			pins: map[string]string{"aws-iam-authenticator": "1.31.1"},
+			want: "pinned aws-iam-authenticator version 1.31.1 has no artifact",
+		},
+		{
+			name: "not pinnable",
+			pins: map[string]string{"kubelet": "1.31.1"},
+			want: "component kubelet can't be pinned",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			source, err := GetReleaseSourceFromManifest("1.31", pinTestManifest())
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = source.ApplyPins(tc.pins)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestParsePins(t *testing.T) {
+	pins, err := ParsePins([]string{"cni-plugins=1.31.1", "aws_signing_helper=1.4.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pins) != 2 || pins["cni-plugins"] != "1.31.1" || pins[SigningHelperArtifact] != "1.4.0" {
+		t.Errorf("Unexpected pins %v", pins)
+	}
+
+	for _, invalid := range []string{"cni-plugins", "cni-plugins=", "=1.31.1", "kubectl=1.31.1"} {
+		if _, err := ParsePins([]string{invalid}); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ProtonMail/gopenpgp/v3/crypto"
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/logger"
+)
+
+const (
+	// TrustedKeysDir holds the armored OpenPGP public keys trusted to sign release manifests.
+	// Every *.asc file in it is loaded.
+	TrustedKeysDir = "/etc/eks/nodeadm/trusted-keys.d"
+
+	// signatureSuffix is appended to a manifest URI to get its detached signature.
+	signatureSuffix = ".sig"
+)
+
+// ManifestOption configures how release manifests are read.
+type ManifestOption func(*manifestOptions)
+
+type manifestOptions struct {
+	trustedKeysDir  string
+	trustedKeyFiles []string
+	insecure        bool
+	mirrors         []string
+}
+
+func newManifestOptions(opts []ManifestOption) manifestOptions {
+	options := manifestOptions{trustedKeysDir: TrustedKeysDir}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithTrustedKeyFiles trusts the armored OpenPGP public keys in the given files to sign
+// manifests, in addition to the keys in TrustedKeysDir.
+func WithTrustedKeyFiles(paths ...string) ManifestOption {
+	return func(o *manifestOptions) {
+		o.trustedKeyFiles = append(o.trustedKeyFiles, paths...)
+	}
+}
+
+// WithTrustedKeysDir overrides the directory trusted keys are loaded from.
+func WithTrustedKeysDir(dir string) ManifestOption {
+	return func(o *manifestOptions) {
+		o.trustedKeysDir = dir
+	}
+}
+
+// WithManifestMirrors tries the given URIs in order when an overridden manifest can't be
+// read from its URI.
+func WithManifestMirrors(uris ...string) ManifestOption {
+	return func(o *manifestOptions) {
+		o.mirrors = append(o.mirrors, uris...)
+	}
+}
+
+// WithInsecureSkipVerify accepts manifests without verifying their signature.
+func WithInsecureSkipVerify() ManifestOption {
+	return func(o *manifestOptions) {
+		o.insecure = true
+	}
+}
+
+// SignatureError is returned when a release manifest can't be verified against the
+// trusted keys. Unlike failing to read the manifest, it is never ignored.
+type SignatureError struct {
+	URI string
+	Err error
+}
+
+func (e *SignatureError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// verifyManifest checks the detached signature of a manifest against the trusted keys.
+// getSignature is only called when verification is enabled. The default manifest and
+// overrides are verified alike, a manifest is only accepted without signature if
+// verification is skipped.
+func verifyManifest(ctx context.Context, uri string, manifest []byte, getSignature func() ([]byte, error), options manifestOptions) error {
+	if options.insecure {
+		logger.FromContext(ctx).Warn("Skipping release manifest signature verification", zap.String("manifest", uri))
+		return nil
+	}
+
+	keyPaths, err := options.keyPaths()
+	if err != nil {
+		return err
+	}
+	keyRing, err := loadKeyRing(keyPaths)
+	if err != nil {
+		return &SignatureError{URI: uri, Err: err}
+	}
+	signature, err := getSignature()
+	if err != nil {
+		return &SignatureError{URI: uri, Err: fmt.Errorf("getting signature for manifest %s, unsigned manifests are refused unless signature verification is skipped: %w", uri, err)}
+	}
+	if err := verifySignature(manifest, signature, keyRing); err != nil {
+		return &SignatureError{URI: uri, Err: fmt.Errorf("verifying signature of manifest %s: %w", uri, err)}
+	}
+	return nil
+}
+
+func verifySignature(data, signature []byte, keyRing *crypto.KeyRing) error {
+	verifier, err := crypto.PGP().Verify().
+		VerificationKeys(keyRing).
+		New()
+	if err != nil {
+		return err
+	}
+	verifyResult, err := verifier.VerifyDetached(data, signature, crypto.Auto)
+	if err != nil {
+		return err
+	}
+	return verifyResult.SignatureError()
+}
+
+// keyPaths returns the trusted keys in the keys directory and the configured key files.
+func (o manifestOptions) keyPaths() ([]string, error) {
+	var paths []string
+	if o.trustedKeysDir != "" {
+		dirKeys, err := filepath.Glob(filepath.Join(o.trustedKeysDir, "*.asc"))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, dirKeys...)
+	}
+	return append(paths, o.trustedKeyFiles...), nil
+}
+
+// loadKeyRing loads the armored keys at paths.
+func loadKeyRing(paths []string) (*crypto.KeyRing, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no keys are trusted to verify the release manifest signature, add them to %s or pass them with --manifest-key, or skip verification with --insecure-skip-manifest-verification", TrustedKeysDir)
+	}
+
+	keyRing, err := crypto.NewKeyRing(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		armored, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading trusted key: %w", err)
+		}
+		key, err := crypto.NewKeyFromArmored(string(armored))
+		if err != nil {
+			return nil, fmt.Errorf("parsing trusted key %s: %w", path, err)
+		}
+		if err := keyRing.AddKey(key); err != nil {
+			return nil, fmt.Errorf("adding trusted key %s: %w", path, err)
+		}
+	}
+	return keyRing, nil
+}
+
+// ManifestVerificationOptions returns the options for the trusted key files and insecure
+// flag given on the command line.
+func ManifestVerificationOptions(trustedKeyFiles []string, insecure bool) []ManifestOption {
+	opts := []ManifestOption{WithTrustedKeyFiles(trustedKeyFiles...)}
+	if insecure {
+		opts = append(opts, WithInsecureSkipVerify())
+	}
+	return opts
+}
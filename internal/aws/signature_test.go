@@ -0,0 +1,261 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v3/crypto"
+)
+
+func generateSigningKey(t *testing.T) *crypto.Key {
+	t.Helper()
+	key, err := crypto.PGP().KeyGeneration().AddUserId("nodeadm test", "test@example.com").New().GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return key
+}
+
+func writePublicKey(t *testing.T, dir string, key *crypto.Key) string {
+	t.Helper()
+	armored, err := key.GetArmoredPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "key.asc")
+	if err := os.WriteFile(path, []byte(armored), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func signFile(t *testing.T, key *crypto.Key, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := crypto.PGP().Sign().SigningKey(key).Detached().New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := signer.Sign(data, crypto.Armor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+signatureSuffix, signature, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func copyTestManifest(t *testing.T, dir string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "manifest.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "manifest.yaml")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGetReleaseManifestFromURISignature(t *testing.T) {
+	signingKey := generateSigningKey(t)
+	untrustedKey := generateSigningKey(t)
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, manifestPath string)
+		opts    func(keysDir string) []ManifestOption
+		wantErr string
+	}{
+		{
+			name: "signed by trusted key in keys dir",
+			prepare: func(t *testing.T, manifestPath string) {
+				signFile(t, signingKey, manifestPath)
+			},
+			opts: func(keysDir string) []ManifestOption {
+				writePublicKey(t, keysDir, signingKey)
+				return []ManifestOption{WithTrustedKeysDir(keysDir)}
+			},
+		},
+		{
+			name: "signed by trusted key file",
+			prepare: func(t *testing.T, manifestPath string) {
+				signFile(t, signingKey, manifestPath)
+			},
+			opts: func(keysDir string) []ManifestOption {
+				return []ManifestOption{WithTrustedKeysDir(""), WithTrustedKeyFiles(writePublicKey(t, keysDir, signingKey))}
+			},
+		},
+		{
+			name: "signed by untrusted key",
+			prepare: func(t *testing.T, manifestPath string) {
+				signFile(t, untrustedKey, manifestPath)
+			},
+			opts: func(keysDir string) []ManifestOption {
+				writePublicKey(t, keysDir, signingKey)
+				return []ManifestOption{WithTrustedKeysDir(keysDir)}
+			},
+			wantErr: "verifying signature of manifest",
+		},
+		{
+			name: "tampered after signing",
+			prepare: func(t *testing.T, manifestPath string) {
+				signFile(t, signingKey, manifestPath)
+				f, err := os.OpenFile(manifestPath, os.O_APPEND|os.O_WRONLY, 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+				if _, err := f.WriteString("\n# tampered\n"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			opts: func(keysDir string) []ManifestOption {
+				writePublicKey(t, keysDir, signingKey)
+				return []ManifestOption{WithTrustedKeysDir(keysDir)}
+			},
+			wantErr: "verifying signature of manifest",
+		},
+		{
+			name:    "unsigned",
+			prepare: func(t *testing.T, manifestPath string) {},
+			opts: func(keysDir string) []ManifestOption {
+				writePublicKey(t, keysDir, signingKey)
+				return []ManifestOption{WithTrustedKeysDir(keysDir)}
+			},
+			wantErr: "unsigned manifests are refused",
+		},
+		{
+			name:    "no trusted keys",
+			prepare: func(t *testing.T, manifestPath string) {},
+			opts: func(keysDir string) []ManifestOption {
+				return []ManifestOption{WithTrustedKeysDir(keysDir)}
+			},
+			wantErr: "no keys are trusted",
+		},
+		{
+			name:    "unsigned with verification skipped",
+			prepare: func(t *testing.T, manifestPath string) {},
+			opts: func(keysDir string) []ManifestOption {
+				return []ManifestOption{WithTrustedKeysDir(keysDir), WithInsecureSkipVerify()}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifestPath := copyTestManifest(t, t.TempDir())
+			tt.prepare(t, manifestPath)
+
+			_, err := getReleaseManifestFromURI(context.Background(), "file://"+manifestPath, tt.opts(t.TempDir())...)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Expected manifest to be accepted, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGetReleaseManifestDefaultSignature(t *testing.T) {
+	signingKey := generateSigningKey(t)
+	untrustedKey := generateSigningKey(t)
+
+	tests := []struct {
+		name    string
+		signer  *crypto.Key
+		trusted bool
+		wantErr bool
+	}{
+		{
+			name:    "signed by trusted key",
+			signer:  signingKey,
+			trusted: true,
+		},
+		{
+			name:    "signed by untrusted key",
+			signer:  untrustedKey,
+			trusted: true,
+			wantErr: true,
+		},
+		{
+			name:    "unsigned with a trusted key",
+			trusted: true,
+			wantErr: true,
+		},
+		{
+			name:    "no trusted keys",
+			signer:  signingKey,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serveDir := t.TempDir()
+			manifestPath := copyTestManifest(t, serveDir)
+			if tt.signer != nil {
+				signFile(t, tt.signer, manifestPath)
+			}
+			server := httptest.NewServer(http.FileServer(http.Dir(serveDir)))
+			defer server.Close()
+
+			previous := manifestUrl
+			manifestUrl = server.URL + "/manifest.yaml"
+			defer func() { manifestUrl = previous }()
+
+			keysDir := t.TempDir()
+			if tt.trusted {
+				writePublicKey(t, keysDir, signingKey)
+			}
+
+			manifest, err := getReleaseManifest(context.Background(), "us-west-2", WithTrustedKeysDir(keysDir))
+			var signatureErr *SignatureError
+			if tt.wantErr && !errors.As(err, &signatureErr) {
+				t.Fatalf("Expected a signature error, got %v", err)
+			}
+			if !tt.wantErr && (err != nil || manifest == nil) {
+				t.Fatalf("Expected manifest to be accepted, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReadReleaseManifestFileUnverified(t *testing.T) {
+	signingKey := generateSigningKey(t)
+	manifestPath := copyTestManifest(t, t.TempDir())
+	keysDir := t.TempDir()
+	writePublicKey(t, keysDir, signingKey)
+
+	file, err := ReadReleaseManifestFile(context.Background(), "file://"+manifestPath, "")
+	if err != nil {
+		t.Fatalf("Expected unsigned manifest to be read, got %v", err)
+	}
+	if file.Manifest != nil || file.Signature != nil {
+		t.Fatalf("Expected manifest to be neither parsed nor signed, got %+v", file)
+	}
+	var signatureErr *SignatureError
+	if err := file.Verify(context.Background(), WithTrustedKeysDir(keysDir)); !errors.As(err, &signatureErr) {
+		t.Fatalf("Expected a signature error, got %v", err)
+	}
+
+	signFile(t, signingKey, manifestPath)
+	file, err = ReadReleaseManifestFile(context.Background(), "file://"+manifestPath, "")
+	if err != nil {
+		t.Fatalf("Expected signed manifest to be read, got %v", err)
+	}
+	if err := file.Verify(context.Background(), WithTrustedKeysDir(keysDir)); err != nil {
+		t.Fatalf("Expected signature to be verified, got %v", err)
+	}
+}
@@ -1,17 +1,22 @@
 package aws
 
 import (
+	"bytes"
 	"context"
-	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"runtime"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 	"golang.org/x/mod/semver"
 
 	"github.com/aws/eks-hybrid/internal/artifact"
+	"github.com/aws/eks-hybrid/internal/cache"
+	"github.com/aws/eks-hybrid/internal/logger"
 	"github.com/aws/eks-hybrid/internal/util"
 )
 
@@ -20,12 +25,23 @@ type Source struct {
 	Eks        EksPatchRelease
 	Iam        IamRolesAnywhereRelease
 	RegionInfo RegionData
+	// Manifest is the release manifest the source was read from.
+	Manifest ManifestSource
+	// Cache, if set, is consulted before downloading an artifact and stores the artifacts
+	// that are downloaded.
+	Cache *cache.Cache
+	// Pins holds the versions components are pinned to by ApplyPins, keyed by artifact name.
+	Pins map[string]string
+
+	manifest *Manifest
+	// pinned holds the EKS releases pinned components are taken from.
+	pinned map[string]EksPatchRelease
 }
 
 // GetLatestSource gets the source for latest version of aws provided artifacts from the
 // hybrid nodes CDN manifest https://hybrid-assets.eks.amazonaws.com/manifest.yaml
-func GetLatestSource(ctx context.Context, eksVersion, region string) (Source, error) {
-	manifest, err := getReleaseManifest(ctx, region)
+func GetLatestSource(ctx context.Context, eksVersion, region string, opts ...ManifestOption) (Source, error) {
+	manifest, err := getReleaseManifest(ctx, region, opts...)
 	if err != nil {
 		return Source{}, err
 	}
@@ -34,9 +50,9 @@ func GetLatestSource(ctx context.Context, eksVersion, region string) (Source, er
 }
 
 // GetLatestSourceFromManifest gets the source for latest version of aws provided artifacts
-// from a manifest URI (supports file:// and https:// protocols)
-func GetLatestSourceFromManifest(ctx context.Context, eksVersion, region, manifestURI string) (Source, error) {
-	manifest, err := getReleaseManifestFromURI(ctx, manifestURI)
+// from a manifest URI (supports file://, https:// and s3:// protocols)
+func GetLatestSourceFromManifest(ctx context.Context, eksVersion, region, manifestURI string, opts ...ManifestOption) (Source, error) {
+	manifest, err := getReleaseManifestFromURI(ctx, manifestURI, opts...)
 	if err != nil {
 		return Source{}, err
 	}
@@ -44,8 +60,47 @@ func GetLatestSourceFromManifest(ctx context.Context, eksVersion, region, manife
 	return getSourceFromManifest(eksVersion, region, manifest)
 }
 
+// GetReleaseSource gets the source for the artifacts of a Kubernetes version without
+// region information. It reads the manifest from manifestURI or, if empty, from the
+// default manifest URL.
+func GetReleaseSource(ctx context.Context, eksVersion, manifestURI string, opts ...ManifestOption) (Source, error) {
+	var manifest *Manifest
+	var err error
+	if manifestURI != "" {
+		manifest, err = getReleaseManifestFromURI(ctx, manifestURI, opts...)
+	} else {
+		manifest, err = getReleaseManifest(ctx, "", opts...)
+	}
+	if err != nil {
+		return Source{}, err
+	}
+
+	return getReleaseSourceFromManifest(eksVersion, manifest)
+}
+
 // getSourceFromManifest is a common function to create Source from a manifest
 func getSourceFromManifest(eksVersion, region string, manifest *Manifest) (Source, error) {
+	source, err := getReleaseSourceFromManifest(eksVersion, manifest)
+	if err != nil {
+		return Source{}, err
+	}
+
+	regionCfg, ok := manifest.RegionConfig[region]
+	if !ok {
+		return Source{}, fmt.Errorf("region %s not found in manifest", region)
+	}
+	source.RegionInfo = regionCfg
+
+	return source, nil
+}
+
+// GetReleaseSourceFromManifest gets the source for the artifacts of a Kubernetes version
+// from an already read manifest, without region information.
+func GetReleaseSourceFromManifest(eksVersion string, manifest *Manifest) (Source, error) {
+	return getReleaseSourceFromManifest(eksVersion, manifest)
+}
+
+func getReleaseSourceFromManifest(eksVersion string, manifest *Manifest) (Source, error) {
 	eksPatchRelease, err := getLatestEksSource(eksVersion, manifest)
 	if err != nil {
 		return Source{}, errors.Wrap(err, "getting latest eks release")
@@ -61,15 +116,11 @@ func getSourceFromManifest(eksVersion, region string, manifest *Manifest) (Sourc
 		return Source{}, errors.Wrap(err, "getting iam roles anywhere release")
 	}
 
-	regionCfg, ok := manifest.RegionConfig[region]
-	if !ok {
-		return Source{}, fmt.Errorf("region %s not found in manifest", region)
-	}
-
 	return Source{
-		Eks:        eksPatchRelease,
-		Iam:        iamRolesAnywhereRelease,
-		RegionInfo: regionCfg,
+		Eks:      eksPatchRelease,
+		Iam:      iamRolesAnywhereRelease,
+		Manifest: manifest.source,
+		manifest: manifest,
 	}, nil
 }
 
@@ -160,8 +211,8 @@ func getLatestDateEksPatchRelease(patchReleases []EksPatchRelease) (EksPatchRele
 	return latestRelease, nil
 }
 
-func GetRegionConfig(ctx context.Context, region string) (*RegionData, error) {
-	manifest, err := getReleaseManifest(ctx, region)
+func GetRegionConfig(ctx context.Context, region string, opts ...ManifestOption) (*RegionData, error) {
+	manifest, err := getReleaseManifest(ctx, region, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -174,8 +225,8 @@ func GetRegionConfig(ctx context.Context, region string) (*RegionData, error) {
 	return &regionCfg, nil
 }
 
-func GetRegionConfigFromManifest(ctx context.Context, region, manifestURI string) (*RegionData, error) {
-	manifest, err := getReleaseManifestFromURI(ctx, manifestURI)
+func GetRegionConfigFromManifest(ctx context.Context, region, manifestURI string, opts ...ManifestOption) (*RegionData, error) {
+	manifest, err := getReleaseManifestFromURI(ctx, manifestURI, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -214,49 +265,251 @@ func (as Source) GetCniPlugins(ctx context.Context) (artifact.Source, error) {
 }
 
 func (as Source) getEksSource(ctx context.Context, artifactName string) (artifact.Source, error) {
-	return getSource(ctx, artifactName, as.Eks.Artifacts)
+	return getSource(ctx, artifactName, as.eksRelease(artifactName).Artifacts, as.Cache)
 }
 
 // GetSingingHelper satisfies iamrolesanywhere.SigningHelperSource
 func (as Source) GetSigningHelper(ctx context.Context) (artifact.Source, error) {
-	return getSource(ctx, "aws_signing_helper", as.Iam.Artifacts)
+	return getSource(ctx, SigningHelperArtifact, as.Iam.Artifacts, as.Cache)
+}
+
+// LookupArtifact returns the artifact with the given name for the current platform and
+// the version of the release it belongs to, which is the pinned release for pinned components.
+func (as Source) LookupArtifact(artifactName string) (Artifact, string, bool) {
+	eks := as.eksRelease(artifactName)
+	if releaseArtifact, ok := findArtifact(artifactName, eks.Artifacts); ok {
+		return releaseArtifact, eks.Version, true
+	}
+	if releaseArtifact, ok := findArtifact(artifactName, as.Iam.Artifacts); ok {
+		return releaseArtifact, as.Iam.Version, true
+	}
+	return Artifact{}, "", false
+}
+
+// GetArtifactChecksum returns the expected checksum of the artifact with the given name for
+// the current platform and the algorithm it was computed with.
+func (as Source) GetArtifactChecksum(ctx context.Context, artifactName string) (artifact.Algorithm, []byte, error) {
+	releaseArtifact, _, ok := as.LookupArtifact(artifactName)
+	if !ok {
+		return "", nil, fmt.Errorf("could not find artifact %s for %s arch and %s os", artifactName, runtime.GOARCH, runtime.GOOS)
+	}
+	return releaseArtifact.ExpectedChecksum(ctx)
+}
+
+// ExpectedChecksum returns the checksum the artifact is verified with and its algorithm:
+// the strongest digest inline in the manifest, the digest of an oci:// URI or else the
+// checksum file at ChecksumURI.
+func (a Artifact) ExpectedChecksum(ctx context.Context) (artifact.Algorithm, []byte, error) {
+	if algorithm, checksum, err := a.Digest(); err != nil || checksum != nil {
+		return algorithm, checksum, err
+	}
+
+	if a.ChecksumURI == "" {
+		return "", nil, fmt.Errorf("artifact %s has neither an inline digest nor a checksum_uri", a.Name)
+	}
+	checksumFile, _, err := tryMirrors(ctx, a.Name+" checksum", a.ChecksumURIs(), func(uri string) ([]byte, error) {
+		checksumFile, err := util.GetHttpFile(ctx, uri)
+		if err != nil {
+			return nil, fmt.Errorf("getting artifact checksum: %w", err)
+		}
+		return checksumFile, nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	checksum, err := artifact.ParseGNUChecksum(checksumFile)
+	if err != nil {
+		return "", nil, fmt.Errorf("parsing artifact checksum: %w", err)
+	}
+	algorithm, err := artifact.AlgorithmOf(checksum)
+	if err != nil {
+		return "", nil, fmt.Errorf("parsing artifact checksum: %w", err)
+	}
+	return algorithm, checksum, nil
+}
+
+// ChecksumURIs returns every URI the checksum file of the artifact can be read from:
+// ChecksumURI, then the checksum file of each mirror when ChecksumURI is URI followed by a
+// suffix, such as .sha256. OCI mirrors have no checksum file.
+func (a Artifact) ChecksumURIs() []string {
+	if a.ChecksumURI == "" {
+		return nil
+	}
+	uris := []string{a.ChecksumURI}
+	suffix, ok := strings.CutPrefix(a.ChecksumURI, a.URI)
+	if !ok || suffix == "" {
+		return uris
+	}
+	for _, mirror := range a.Mirrors {
+		if !isOCI(mirror) {
+			uris = append(uris, mirror+suffix)
+		}
+	}
+	return uris
 }
 
-func getSource(ctx context.Context, artifactName string, availableArtifacts []Artifact) (artifact.Source, error) {
+// Digest returns the digest the artifact is verified with when it is known without reading
+// the checksum file: the strongest digest inline in the manifest or else the digest of an
+// oci:// URI. The digest is nil if the artifact is only verified with its checksum file.
+func (a Artifact) Digest() (artifact.Algorithm, []byte, error) {
+	if algorithm, checksum, err := a.InlineDigest(); err != nil || checksum != nil {
+		return algorithm, checksum, err
+	}
+	if isOCI(a.URI) {
+		_, checksum, err := parseOCIArtifactURI(a.URI)
+		return artifact.SHA256, checksum, err
+	}
+	return "", nil, nil
+}
+
+// InlineDigest returns the strongest digest of the artifact inline in the manifest and its
+// algorithm, or a nil digest if the manifest only references a checksum file.
+func (a Artifact) InlineDigest() (artifact.Algorithm, []byte, error) {
+	for _, inline := range []struct {
+		algorithm artifact.Algorithm
+		digest    string
+	}{
+		{artifact.SHA512, a.Sha512},
+		{artifact.SHA256, a.Sha256},
+	} {
+		if inline.digest == "" {
+			continue
+		}
+		checksum, err := artifact.ParseDigest(inline.algorithm, inline.digest)
+		if err != nil {
+			return "", nil, fmt.Errorf("artifact %s: %w", a.Name, err)
+		}
+		return inline.algorithm, checksum, nil
+	}
+	return "", nil, nil
+}
+
+func findArtifact(artifactName string, availableArtifacts []Artifact) (Artifact, bool) {
 	for _, releaseArtifact := range availableArtifacts {
 		if releaseArtifact.Name == artifactName && releaseArtifact.Arch == runtime.GOARCH && releaseArtifact.OS == runtime.GOOS {
-			uri := releaseArtifact.URI
-			if releaseArtifact.GzipURI != "" {
-				// the same checksum will be used for both gzip and non-gzip uri
-				// gzip decompression will happen before checksum verification
-				uri = releaseArtifact.GzipURI
-			}
-			obj, err := util.GetHttpFileReader(ctx, uri)
-			if err != nil {
-				return nil, fmt.Errorf("getting artifact file reader: %w", err)
-			}
+			return releaseArtifact, true
+		}
+	}
+	return Artifact{}, false
+}
 
-			artifactChecksum, err := util.GetHttpFile(ctx, releaseArtifact.ChecksumURI)
-			if err != nil {
-				obj.Close()
-				return nil, fmt.Errorf("getting artifact checksum file reader: %w", err)
-			}
+// DownloadURI returns the URI the artifact is downloaded from.
+func (a Artifact) DownloadURI() string {
+	if a.GzipURI != "" {
+		// the same checksum will be used for both gzip and non-gzip uri
+		// gzip decompression will happen before checksum verification
+		return a.GzipURI
+	}
+	return a.URI
+}
 
-			var source artifact.Source
-			if releaseArtifact.GzipURI != "" {
-				source, err = artifact.GzippedWithChecksum(obj, sha256.New(), artifactChecksum)
-			} else {
-				source, err = artifact.WithChecksum(obj, sha256.New(), artifactChecksum)
-			}
+// DownloadURIs returns every URI the artifact can be downloaded from, in the order they
+// are listed in the manifest: DownloadURI, then URI if the artifact is gzipped, then the
+// mirrors.
+func (a Artifact) DownloadURIs() []string {
+	uris := []string{a.DownloadURI()}
+	if a.GzipURI != "" {
+		uris = append(uris, a.URI)
+	}
+	return append(uris, a.Mirrors...)
+}
+
+func getSource(ctx context.Context, artifactName string, availableArtifacts []Artifact, artifactCache *cache.Cache) (artifact.Source, error) {
+	return openSource(ctx, artifactName, availableArtifacts, artifactCache, util.GetHttpFileReader)
+}
 
+// openSource is getSource reading the files that aren't OCI blobs with openFile. The source
+// is returned unread, a mirror that fails while it is read or serves content that doesn't
+// match the checksum is only demoted for the next download.
+func openSource(ctx context.Context, artifactName string, availableArtifacts []Artifact, artifactCache *cache.Cache, openFile func(ctx context.Context, uri string) (io.ReadCloser, error)) (artifact.Source, error) {
+	var source artifact.Source
+	err := fetchSource(ctx, artifactName, availableArtifacts, artifactCache, openFile, func(src artifact.Source) error {
+		source = src
+		return nil
+	})
+	return source, err
+}
+
+// fetchSource opens the artifact like openSource and calls fetch with its source, which
+// fetch must close. If fetch fails, the artifact is fetched again from the next mirror, so a
+// mirror that fails while it is read or serves content that doesn't match the checksum
+// doesn't fail the download as long as another mirror serves the artifact.
+func fetchSource(ctx context.Context, artifactName string, availableArtifacts []Artifact, artifactCache *cache.Cache, openFile func(ctx context.Context, uri string) (io.ReadCloser, error), fetch func(artifact.Source) error) error {
+	releaseArtifact, ok := findArtifact(artifactName, availableArtifacts)
+	if !ok {
+		return fmt.Errorf("could not find artifact for %s arch and %s os", runtime.GOARCH, runtime.GOOS)
+	}
+	if isOCI(releaseArtifact.GzipURI) {
+		return fmt.Errorf("artifact %s: gzip_uri can't reference an OCI blob, use uri instead", artifactName)
+	}
+
+	algorithm, checksum, err := releaseArtifact.ExpectedChecksum(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The cache is addressed by sha256, artifacts only verified with another algorithm bypass it.
+	if algorithm != artifact.SHA256 {
+		artifactCache = nil
+	}
+	if cached, ok := openCached(ctx, artifactName, checksum, artifactCache); ok {
+		return fetch(cached)
+	}
+
+	_, _, err = tryMirrors(ctx, artifactName, releaseArtifact.DownloadURIs(), func(uri string) (struct{}, error) {
+		obj, err := openMirror(ctx, artifactName, uri, algorithm, checksum, openFile)
+		if err != nil {
+			return struct{}{}, err
+		}
+		obj = watchMirror(uri, obj)
+
+		var source artifact.Source
+		if uri == releaseArtifact.GzipURI {
+			source, err = artifact.GzippedWithDigest(obj, algorithm.New(), checksum)
 			if err != nil {
 				obj.Close()
-				return nil, fmt.Errorf("getting artifact with checksum: %w", err)
+				return struct{}{}, fmt.Errorf("getting artifact with checksum: %w", err)
 			}
-			return source, nil
+		} else {
+			source = artifact.WithDigest(obj, algorithm.New(), checksum)
+		}
+		source = verifyMirror(uri, source)
+		if artifactCache != nil {
+			source = artifactCache.Wrap(source)
+		}
+		return struct{}{}, fetch(source)
+	})
+	return err
+}
+
+// openMirror opens the file of an artifact at one of its URIs.
+func openMirror(ctx context.Context, artifactName, uri string, algorithm artifact.Algorithm, checksum []byte, openFile func(ctx context.Context, uri string) (io.ReadCloser, error)) (io.ReadCloser, error) {
+	if !isOCI(uri) {
+		obj, err := openFile(ctx, uri)
+		if err != nil {
+			return nil, fmt.Errorf("getting artifact file reader: %w", err)
 		}
+		return obj, nil
+	}
+	// The digest of a blob is its sha256, a mirror with another digest serves another file.
+	if _, digest, err := parseOCIArtifactURI(uri); err != nil {
+		return nil, err
+	} else if algorithm == artifact.SHA256 && !bytes.Equal(digest, checksum) {
+		return nil, fmt.Errorf("mirror %s doesn't match the checksum of artifact %s", uri, artifactName)
+	}
+	return openOCIBlob(ctx, uri)
+}
+
+// openCached returns the cached artifact with the given checksum, if any.
+func openCached(ctx context.Context, artifactName string, checksum []byte, artifactCache *cache.Cache) (artifact.Source, bool) {
+	if artifactCache == nil {
+		return nil, false
+	}
+	cached, ok := artifactCache.Open(checksum)
+	if ok {
+		logger.FromContext(ctx).Info("Using cached artifact", zap.String("artifact", artifactName), zap.String("sha256", hex.EncodeToString(checksum)))
 	}
-	return nil, fmt.Errorf("could not find artifact for %s arch and %s os", runtime.GOARCH, runtime.GOOS)
+	return cached, ok
 }
 
 // validateKubernetesVersionMatch validates that the requested Kubernetes version is compatible with the manifest version
@@ -0,0 +1,276 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/aws/eks-hybrid/internal/artifact"
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+const (
+	// StagingDir is where artifacts are downloaded and verified before being installed.
+	StagingDir = "/opt/nodeadm/staging"
+
+	// DefaultDownloadParallelism is the default maximum number of artifacts downloaded at the same time.
+	DefaultDownloadParallelism = 4
+
+	stagingAttempts = 3
+	// partialSuffix ends the name of the files of artifacts being downloaded.
+	partialSuffix    = ".partial"
+	progressInterval = 10 * time.Second
+)
+
+// StagedSource serves the artifacts downloaded by Stage from the staging directory, so
+// nothing is downloaded while they are installed. Artifacts that are already installed are
+// served with their checksum only, see Keep, and any other artifact is an error.
+type StagedSource struct {
+	Source
+
+	dir    string
+	staged map[string]stagedArtifact
+	// kept holds the checksums of the artifacts kept as installed, keyed by name.
+	kept map[string][]byte
+}
+
+type stagedArtifact struct {
+	path     string
+	checksum []byte
+}
+
+// Stage downloads the given artifacts into dir, at most parallelism at a time, and verifies
+// their checksums. If any download fails the staged artifacts are removed and an error is
+// returned, so nothing is installed from a partially downloaded set of artifacts. The data
+// of interrupted downloads is kept in dir and the next Stage resumes them.
+func (as Source) Stage(ctx context.Context, dir string, artifactNames []string, parallelism int, log *zap.Logger) (*StagedSource, error) {
+	if parallelism <= 0 {
+		parallelism = DefaultDownloadParallelism
+	}
+	if err := clearStaging(dir); err != nil {
+		return nil, fmt.Errorf("removing previous staging directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating staging directory: %w", err)
+	}
+
+	staged := &StagedSource{
+		Source: as,
+		dir:    dir,
+		staged: make(map[string]stagedArtifact, len(artifactNames)),
+		kept:   map[string][]byte{},
+	}
+	var mu sync.Mutex
+	var completed atomic.Int32
+
+	log.Info("Downloading artifacts...", zap.Strings("artifacts", artifactNames), zap.Int("parallelism", parallelism))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(parallelism)
+	for _, name := range artifactNames {
+		group.Go(func() error {
+			var checksum []byte
+			var err error
+			path := filepath.Join(dir, name)
+			for range stagingAttempts {
+				checksum, err = as.download(groupCtx, name, path, log)
+				if err == nil || groupCtx.Err() != nil {
+					break
+				}
+				log.Error("Downloading artifact failed. Retrying...", zap.String("artifact", name), zap.Error(err))
+			}
+			if err != nil {
+				return fmt.Errorf("downloading %s: %w", name, err)
+			}
+
+			mu.Lock()
+			staged.staged[name] = stagedArtifact{path: path, checksum: checksum}
+			mu.Unlock()
+			log.Info("Downloaded artifact", zap.String("artifact", name), zap.Int32("completed", completed.Add(1)), zap.Int("total", len(artifactNames)))
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		_ = clearStaging(dir)
+		// Remove the directory unless partial downloads are left in it.
+		_ = os.Remove(dir)
+		return nil, err
+	}
+	return staged, nil
+}
+
+// PartialPath is where a file with the given name is downloaded to in the staging directory
+// dir. The next Stage in dir keeps it, so an interrupted download can be resumed.
+func PartialPath(dir, name string) string {
+	return partialPath(dir, name)
+}
+
+// partialPath is where the file of the artifact with the given name is downloaded to.
+func partialPath(dir, name string) string {
+	return filepath.Join(dir, "."+name+partialSuffix)
+}
+
+// clearStaging removes everything in dir but partial downloads.
+func clearStaging(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") && strings.Contains(entry.Name(), partialSuffix) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// download writes the artifact to path and returns its checksum once verified. The file
+// of the artifact is first downloaded next to path, so an interrupted download can be resumed.
+// A mirror that fails mid-stream or serves content that doesn't match the checksum is
+// retried on the next mirror.
+func (as Source) download(ctx context.Context, name, path string, log *zap.Logger) ([]byte, error) {
+	partial := partialPath(filepath.Dir(path), name)
+	openFile := func(ctx context.Context, uri string) (io.ReadCloser, error) {
+		return util.DownloadFile(ctx, uri, partial)
+	}
+	var checksum []byte
+	err := as.fetchArtifact(ctx, name, openFile, func(src artifact.Source) error {
+		defer src.Close()
+		fh, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+
+		start := time.Now()
+		progress := &progressWriter{log: log, name: name, last: start}
+		if _, err := io.Copy(fh, io.TeeReader(src, progress)); err != nil {
+			return err
+		}
+		if err := fh.Close(); err != nil {
+			return err
+		}
+		// The downloaded file is complete, resuming it again would only serve the same content.
+		_ = os.Remove(partial)
+		if !src.VerifyChecksum() {
+			return artifact.NewChecksumError(src)
+		}
+		log.Debug("Verified artifact checksum", zap.String("artifact", name), zap.Int64("bytes", progress.written), zap.Duration("duration", time.Since(start)))
+		checksum = src.ExpectedChecksum()
+		return nil
+	})
+	return checksum, err
+}
+
+func (as Source) fetchArtifact(ctx context.Context, name string, openFile func(ctx context.Context, uri string) (io.ReadCloser, error), fetch func(artifact.Source) error) error {
+	if _, ok := findArtifact(name, as.eksRelease(name).Artifacts); ok {
+		return fetchSource(ctx, name, as.eksRelease(name).Artifacts, as.Cache, openFile, fetch)
+	}
+	return fetchSource(ctx, name, as.Iam.Artifacts, as.Cache, openFile, fetch)
+}
+
+// progressWriter periodically logs how much of an artifact has been downloaded.
+type progressWriter struct {
+	log     *zap.Logger
+	name    string
+	written int64
+	last    time.Time
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	if now := time.Now(); now.Sub(p.last) >= progressInterval {
+		p.last = now
+		p.log.Info("Downloading artifact...", zap.String("artifact", p.name), zap.Int64("bytes", p.written))
+	}
+	return len(b), nil
+}
+
+// Remove deletes the staging directory.
+func (s *StagedSource) Remove() error {
+	return os.RemoveAll(s.dir)
+}
+
+// Keep records that the artifact with the given name is installed with the expected
+// checksum and isn't staged. It is served with the checksum only, so the installed file
+// is kept without downloading the artifact again.
+func (s *StagedSource) Keep(name string, checksum []byte) {
+	s.kept[name] = checksum
+}
+
+// GetKubelet satisfies kubelet.Source.
+func (s *StagedSource) GetKubelet(ctx context.Context) (artifact.Source, error) {
+	return s.get("kubelet")
+}
+
+// GetKubectl satisfies kubectl.Source.
+func (s *StagedSource) GetKubectl(ctx context.Context) (artifact.Source, error) {
+	return s.get("kubectl")
+}
+
+// GetIAMAuthenticator satisfies iamauthenticator.IAMAuthenticatorSource.
+func (s *StagedSource) GetIAMAuthenticator(ctx context.Context) (artifact.Source, error) {
+	return s.get("aws-iam-authenticator")
+}
+
+// GetImageCredentialProvider satisfies imagecredentialprovider.Source.
+func (s *StagedSource) GetImageCredentialProvider(ctx context.Context) (artifact.Source, error) {
+	return s.get("ecr-credential-provider")
+}
+
+// GetCniPlugins satisfies cni.Source.
+func (s *StagedSource) GetCniPlugins(ctx context.Context) (artifact.Source, error) {
+	return s.get("cni-plugins")
+}
+
+// GetSigningHelper satisfies iamrolesanywhere.SigningHelperSource.
+func (s *StagedSource) GetSigningHelper(ctx context.Context) (artifact.Source, error) {
+	return s.get("aws_signing_helper")
+}
+
+func (s *StagedSource) get(name string) (artifact.Source, error) {
+	if checksum, ok := s.kept[name]; ok {
+		algorithm, err := artifact.AlgorithmOf(checksum)
+		if err != nil {
+			return nil, fmt.Errorf("kept %s: %w", name, err)
+		}
+		return artifact.WithDigest(io.NopCloser(notStagedReader{name: name}), algorithm.New(), checksum), nil
+	}
+	staged, ok := s.staged[name]
+	if !ok {
+		return nil, fmt.Errorf("artifact %s was not staged", name)
+	}
+	fh, err := os.Open(staged.path)
+	if err != nil {
+		return nil, fmt.Errorf("opening staged %s: %w", name, err)
+	}
+	algorithm, err := artifact.AlgorithmOf(staged.checksum)
+	if err != nil {
+		fh.Close()
+		return nil, fmt.Errorf("staged %s: %w", name, err)
+	}
+	return artifact.WithDigest(fh, algorithm.New(), staged.checksum), nil
+}
+
+// notStagedReader is the content of a kept artifact. It is only compared by checksum with
+// the installed file, reading it means the installed file doesn't match after all.
+type notStagedReader struct {
+	name string
+}
+
+func (r notStagedReader) Read([]byte) (int, error) {
+	return 0, fmt.Errorf("artifact %s was not staged, the installed file doesn't match its checksum", r.name)
+}
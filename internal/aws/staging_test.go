@@ -0,0 +1,260 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/cache"
+)
+
+func newStagingTestSource(t *testing.T, files, checksums map[string]string) Source {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := filepath.Base(r.URL.Path)
+		if content, ok := files[name]; ok {
+			fmt.Fprint(w, content)
+			return
+		}
+		if checksum, ok := checksums[name]; ok {
+			fmt.Fprintf(w, "%s  %s", checksum, name)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	var artifacts []Artifact
+	for name := range files {
+		artifacts = append(artifacts, Artifact{
+			Name:        name,
+			Arch:        runtime.GOARCH,
+			OS:          runtime.GOOS,
+			URI:         server.URL + "/" + name,
+			ChecksumURI: server.URL + "/" + name + ".sha256",
+		})
+	}
+	return Source{Eks: EksPatchRelease{Artifacts: artifacts}}
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestStage(t *testing.T) {
+	files := map[string]string{"kubelet": "kubelet binary", "kubectl": "kubectl binary"}
+	source := newStagingTestSource(t, files, map[string]string{
+		"kubelet.sha256": sha256Hex("kubelet binary"),
+		"kubectl.sha256": sha256Hex("kubectl binary"),
+	})
+	dir := filepath.Join(t.TempDir(), "staging")
+
+	staged, err := source.Stage(context.Background(), dir, []string{"kubelet", "kubectl"}, 2, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to stage artifacts: %v", err)
+	}
+
+	kubelet, err := staged.GetKubelet(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get staged kubelet: %v", err)
+	}
+	defer kubelet.Close()
+	data, err := io.ReadAll(kubelet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "kubelet binary" {
+		t.Errorf("Expected staged kubelet content, got %q", data)
+	}
+	if !kubelet.VerifyChecksum() {
+		t.Errorf("Expected staged kubelet checksum to match")
+	}
+
+	if err := staged.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected staging directory to be removed, got %v", err)
+	}
+}
+
+func TestStageInlineSha512(t *testing.T) {
+	files := map[string]string{"kubelet": "kubelet binary"}
+	// No checksum files are served, the digest comes from the manifest.
+	source := newStagingTestSource(t, files, nil)
+	sum := sha512.Sum512([]byte("kubelet binary"))
+	source.Eks.Artifacts[0].Sha512 = hex.EncodeToString(sum[:])
+
+	staged, err := source.Stage(context.Background(), filepath.Join(t.TempDir(), "staging"), []string{"kubelet"}, 1, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to stage artifacts: %v", err)
+	}
+	defer staged.Remove()
+
+	kubelet, err := staged.GetKubelet(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get staged kubelet: %v", err)
+	}
+	defer kubelet.Close()
+	if _, err := io.Copy(io.Discard, kubelet); err != nil {
+		t.Fatal(err)
+	}
+	if !kubelet.VerifyChecksum() {
+		t.Errorf("Expected staged kubelet to match its inline sha512 digest")
+	}
+
+	source.Eks.Artifacts[0].Sha512 = "not-hex"
+	if _, err := source.Stage(context.Background(), filepath.Join(t.TempDir(), "staging"), []string{"kubelet"}, 1, zap.NewNop()); err == nil {
+		t.Error("Expected staging to fail with an invalid inline digest")
+	}
+}
+
+func TestStageChecksumMismatch(t *testing.T) {
+	files := map[string]string{"kubelet": "kubelet binary", "kubectl": "tampered"}
+	source := newStagingTestSource(t, files, map[string]string{
+		"kubelet.sha256": sha256Hex("kubelet binary"),
+		"kubectl.sha256": sha256Hex("kubectl binary"),
+	})
+	dir := filepath.Join(t.TempDir(), "staging")
+
+	_, err := source.Stage(context.Background(), dir, []string{"kubelet", "kubectl"}, 1, zap.NewNop())
+	if err == nil {
+		t.Fatal("Expected staging to fail with a checksum mismatch")
+	}
+	if _, statErr := os.Stat(dir); !os.IsNotExist(statErr) {
+		t.Errorf("Expected staging directory to be removed after a failed download, got %v", statErr)
+	}
+}
+
+func TestStageFromCache(t *testing.T) {
+	files := map[string]string{"kubelet": "kubelet binary"}
+	source := newStagingTestSource(t, files, map[string]string{
+		"kubelet.sha256": sha256Hex("kubelet binary"),
+	})
+	source.Cache = cache.New(t.TempDir())
+
+	staged, err := source.Stage(context.Background(), filepath.Join(t.TempDir(), "staging"), []string{"kubelet"}, 1, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to stage artifacts: %v", err)
+	}
+	_ = staged.Remove()
+
+	// The artifact is served from the cache once the server stops serving it.
+	delete(files, "kubelet")
+	staged, err = source.Stage(context.Background(), filepath.Join(t.TempDir(), "staging"), []string{"kubelet"}, 1, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to stage artifacts from cache: %v", err)
+	}
+	defer staged.Remove()
+
+	kubelet, err := staged.GetKubelet(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get staged kubelet: %v", err)
+	}
+	defer kubelet.Close()
+	data, err := io.ReadAll(kubelet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "kubelet binary" {
+		t.Errorf("Expected cached kubelet content, got %q", data)
+	}
+}
+
+func TestStageResumesPartialDownload(t *testing.T) {
+	content := "kubelet binary"
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "kubelet", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+	uri := server.URL + "/kubelet"
+	source := Source{Eks: EksPatchRelease{Artifacts: []Artifact{
+		{Name: "kubelet", Arch: runtime.GOARCH, OS: runtime.GOOS, URI: uri, Sha256: sha256Hex(content)},
+	}}}
+
+	// A previous run was interrupted after downloading the first half of kubelet.
+	dir := filepath.Join(t.TempDir(), "staging")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	partial := partialPath(dir, "kubelet")
+	if err := os.WriteFile(partial, []byte(content[:7]), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(partial+".validator", []byte(uri+"\n"+`"v1"`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	staged, err := source.Stage(context.Background(), dir, []string{"kubelet"}, 1, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to stage artifacts: %v", err)
+	}
+	defer staged.Remove()
+	if len(ranges) != 1 || ranges[0] != "bytes=7-" {
+		t.Errorf("Expected the download to resume from the partial file, got ranges %q", ranges)
+	}
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Errorf("Expected the partial download to be removed, got %v", err)
+	}
+
+	kubelet, err := staged.GetKubelet(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get staged kubelet: %v", err)
+	}
+	defer kubelet.Close()
+	data, err := io.ReadAll(kubelet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content || !kubelet.VerifyChecksum() {
+		t.Errorf("Expected staged kubelet content, got %q", data)
+	}
+}
+
+func TestStagedSourceKeepsInstalledArtifacts(t *testing.T) {
+	// The release can't be reached, kept artifacts must be served without it.
+	source := Source{Eks: EksPatchRelease{Artifacts: []Artifact{{
+		Name: "kubectl", Arch: runtime.GOARCH, OS: runtime.GOOS,
+		URI: "https://127.0.0.1:1/kubectl", ChecksumURI: "https://127.0.0.1:1/kubectl.sha256",
+	}}}}
+	staged, err := source.Stage(context.Background(), filepath.Join(t.TempDir(), "staging"), nil, 1, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer staged.Remove()
+	checksum := sha256.Sum256([]byte("kubelet binary"))
+	staged.Keep("kubelet", checksum[:])
+
+	kubelet, err := staged.GetKubelet(context.Background())
+	if err != nil {
+		t.Fatalf("Expected the kept kubelet to be served, got %v", err)
+	}
+	defer kubelet.Close()
+	if string(kubelet.ExpectedChecksum()) != string(checksum[:]) {
+		t.Errorf("Expected the kept checksum, got %x", kubelet.ExpectedChecksum())
+	}
+	if _, err := io.ReadAll(kubelet); err == nil || !strings.Contains(err.Error(), "was not staged") {
+		t.Errorf("Expected reading a kept artifact to fail, got %v", err)
+	}
+
+	if _, err := staged.GetKubectl(context.Background()); err == nil || !strings.Contains(err.Error(), "kubectl was not staged") {
+		t.Errorf("Expected an artifact neither staged nor kept to fail without a download, got %v", err)
+	}
+}
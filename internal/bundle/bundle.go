@@ -0,0 +1,312 @@
+package bundle
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/artifact"
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/ssm"
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+const (
+	// ExtractDir is where bundles are extracted while nodeadm runs from them.
+	ExtractDir = "/opt/nodeadm/bundle"
+
+	// ManifestFile is the release manifest in a bundle, exactly as it was published so its
+	// signature can be verified. ManifestFile + ".sig" is its detached signature.
+	ManifestFile = "manifest.yaml"
+
+	// filesDir holds every other file in a bundle, laid out as files/<host>/<path> of the
+	// URL it was downloaded from.
+	filesDir = "files"
+
+	signatureSuffix = ".sig"
+)
+
+// Options selects what goes in a bundle.
+type Options struct {
+	KubernetesVersion string
+	// Arches are the architectures to include artifacts for.
+	Arches []string
+	// Region selects the SSM installer endpoint. The same region must be used when installing.
+	Region string
+	// SkipSSM leaves the SSM installer out, for hosts that use IAM Roles Anywhere.
+	SkipSSM bool
+}
+
+// Create writes a bundle with the release manifest, the artifacts of the selected Kubernetes
+// version and architectures, their checksum files and the SSM installer with its signature.
+// Artifacts are verified against their checksums before being added.
+func Create(ctx context.Context, w io.Writer, manifest *aws.ManifestFile, opts Options, log *zap.Logger) error {
+	source, err := aws.GetReleaseSourceFromManifest(opts.KubernetesVersion, manifest.Manifest)
+	if err != nil {
+		return err
+	}
+	files, err := bundleFiles(source, manifest.Manifest, opts)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	if err := addFile(tw, ManifestFile, manifest.Data); err != nil {
+		return err
+	}
+	if manifest.Signature != nil {
+		if err := addFile(tw, ManifestFile+signatureSuffix, manifest.Signature); err != nil {
+			return err
+		}
+	} else {
+		log.Warn("Release manifest isn't signed, hosts installing from the bundle must skip manifest verification")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "nodeadm-bundle-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for i, file := range files {
+		log.Info("Adding file to bundle", zap.String("url", file.uri), zap.Int("file", i+1), zap.Int("total", len(files)))
+		if err := addDownload(ctx, tw, tmpDir, file); err != nil {
+			return fmt.Errorf("adding %s to bundle: %w", file.uri, err)
+		}
+	}
+	return tw.Close()
+}
+
+type bundleFile struct {
+	uri string
+	// checksumURI is the GNU checksum of the file, after decompression if gzipped.
+	checksumURI string
+	// digest is the checksum of the file inline in the manifest or in its oci:// URI, used
+	// instead of checksumURI.
+	digest    []byte
+	algorithm artifact.Algorithm
+	gzipped   bool
+}
+
+func bundleFiles(source aws.Source, manifest *aws.Manifest, opts Options) ([]bundleFile, error) {
+	dnsSuffix := aws.GetPartitionDNSSuffix(aws.GetPartitionFromRegionFallback(opts.Region))
+	if regionData, ok := manifest.RegionConfig[opts.Region]; ok && regionData.DnsSuffix != "" {
+		dnsSuffix = regionData.DnsSuffix
+	}
+
+	var files []bundleFile
+	for _, arch := range opts.Arches {
+		found := false
+		for _, releaseArtifact := range slices.Concat(source.Eks.Artifacts, source.Iam.Artifacts) {
+			if releaseArtifact.Arch != arch || releaseArtifact.OS != "linux" {
+				continue
+			}
+			found = true
+			algorithm, digest, err := releaseArtifact.Digest()
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, bundleFile{
+				uri:         releaseArtifact.DownloadURI(),
+				checksumURI: releaseArtifact.ChecksumURI,
+				digest:      digest,
+				algorithm:   algorithm,
+				gzipped:     releaseArtifact.GzipURI != "",
+			})
+			// Checksum files are only read when the manifest doesn't carry the digest.
+			if digest == nil && releaseArtifact.ChecksumURI != "" {
+				files = append(files, bundleFile{uri: releaseArtifact.ChecksumURI})
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no artifacts found for architecture %s", arch)
+		}
+
+		if opts.SkipSSM {
+			continue
+		}
+		for _, variant := range ssm.InstallerVariants {
+			installerURL := ssm.InstallerURL(opts.Region, dnsSuffix, variant, arch)
+			files = append(files, bundleFile{uri: installerURL}, bundleFile{uri: installerURL + signatureSuffix})
+		}
+	}
+	return files, nil
+}
+
+// addDownload downloads a file to dir, verifies its checksum and adds it to the bundle.
+// The download is needed to know its size before writing the tar header.
+func addDownload(ctx context.Context, tw *tar.Writer, dir string, file bundleFile) error {
+	name, err := util.OfflinePath(filesDir, file.uri)
+	if err != nil {
+		return err
+	}
+
+	body, err := aws.OpenArtifactURI(ctx, file.uri)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp(dir, "download-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := copyVerified(ctx, tmp, body, file); err != nil {
+		return err
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: filepath.ToSlash(name), Mode: 0o644, Size: info.Size(), Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, tmp)
+	return err
+}
+
+// copyVerified copies body to dst and, if the file has a checksum, verifies it.
+func copyVerified(ctx context.Context, dst io.Writer, body io.Reader, file bundleFile) error {
+	if file.digest == nil && file.checksumURI == "" {
+		_, err := io.Copy(dst, body)
+		return err
+	}
+
+	raw := io.NopCloser(io.TeeReader(body, dst))
+	var src artifact.Source
+	var err error
+	switch {
+	case file.digest != nil && file.gzipped:
+		src, err = artifact.GzippedWithDigest(raw, file.algorithm.New(), file.digest)
+	case file.digest != nil:
+		src = artifact.WithDigest(raw, file.algorithm.New(), file.digest)
+	default:
+		var checksum []byte
+		if checksum, err = util.GetHttpFile(ctx, file.checksumURI); err != nil {
+			return fmt.Errorf("getting checksum: %w", err)
+		}
+		if file.gzipped {
+			src, err = artifact.GzippedWithChecksum(raw, checksum)
+		} else {
+			src, err = artifact.WithChecksum(raw, checksum)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(io.Discard, src); err != nil {
+		return err
+	}
+	// Drain anything after the end of the gzip stream so the copy is complete.
+	if _, err := io.Copy(io.Discard, raw); err != nil {
+		return err
+	}
+	if !src.VerifyChecksum() {
+		return artifact.NewChecksumError(src)
+	}
+	return nil
+}
+
+func addFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// Bundle is a bundle extracted on the host.
+type Bundle struct {
+	dir string
+}
+
+// Mount extracts the bundle at uri, a file:// URI or a path, into dir and redirects every
+// download to the files in it. Unmount must be called once done.
+func Mount(uri, dir string) (*Bundle, error) {
+	path := strings.TrimPrefix(uri, "file://")
+	if strings.Contains(path, "://") {
+		return nil, fmt.Errorf("unsupported bundle URI %s, bundles must be local files", uri)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("removing previous bundle: %w", err)
+	}
+	if err := extract(path, dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("extracting bundle %s: %w", path, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ManifestFile)); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("bundle %s doesn't contain a release manifest: %w", path, err)
+	}
+
+	util.SetOfflineRoot(filepath.Join(dir, filesDir))
+	return &Bundle{dir: dir}, nil
+}
+
+// ManifestURI returns the URI of the release manifest in the bundle.
+func (b *Bundle) ManifestURI() string {
+	return "file://" + filepath.Join(b.dir, ManifestFile)
+}
+
+// Unmount restores downloads and removes the extracted bundle.
+func (b *Bundle) Unmount() error {
+	util.SetOfflineRoot("")
+	return os.RemoveAll(b.dir)
+}
+
+func extract(path, dir string) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	tr := tar.NewReader(fh)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := filepath.FromSlash(header.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("invalid file path in bundle: %s", header.Name)
+		}
+		if err := extractFile(tr, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+}
+
+func extractFile(r io.Reader, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	fh, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	if _, err := io.Copy(fh, r); err != nil {
+		return err
+	}
+	return fh.Close()
+}
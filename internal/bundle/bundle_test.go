@@ -0,0 +1,126 @@
+package bundle_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/bundle"
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+func newTestManifest(serverURL string) *aws.ManifestFile {
+	artifacts := []aws.Artifact{}
+	for _, arch := range []string{"amd64", "arm64"} {
+		artifacts = append(artifacts, aws.Artifact{
+			Name:        "kubelet",
+			Arch:        arch,
+			OS:          "linux",
+			URI:         fmt.Sprintf("%s/%s/kubelet", serverURL, arch),
+			ChecksumURI: fmt.Sprintf("%s/%s/kubelet.sha256", serverURL, arch),
+		})
+	}
+	return &aws.ManifestFile{
+		URI:       serverURL + "/manifest.yaml",
+		Data:      []byte("manifest"),
+		Signature: []byte("signature"),
+		Manifest: &aws.Manifest{
+			SupportedEksReleases: []aws.SupportedEksRelease{
+				{
+					MajorMinorVersion:  "1.31",
+					LatestPatchVersion: "2",
+					PatchReleases: []aws.EksPatchRelease{
+						{Version: "1.31.2", PatchVersion: "2", ReleaseDate: "2024-11-01", Artifacts: artifacts},
+					},
+				},
+			},
+			IamRolesAnywhereReleases: []aws.IamRolesAnywhereRelease{{Version: "v1.0.0"}},
+		},
+	}
+}
+
+func newTestServer(t *testing.T, kubelet, kubeletChecksum string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch filepath.Base(r.URL.Path) {
+		case "kubelet":
+			fmt.Fprint(w, kubelet)
+		case "kubelet.sha256":
+			fmt.Fprintf(w, "%s  kubelet", kubeletChecksum)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCreateAndMount(t *testing.T) {
+	g := NewWithT(t)
+	sum := sha256.Sum256([]byte("kubelet binary"))
+	server := newTestServer(t, "kubelet binary", hex.EncodeToString(sum[:]))
+	manifest := newTestManifest(server.URL)
+
+	bundlePath := filepath.Join(t.TempDir(), "bundle.tar")
+	var buf bytes.Buffer
+	g.Expect(bundle.Create(context.Background(), &buf, manifest, bundle.Options{
+		KubernetesVersion: "1.31",
+		Arches:            []string{"amd64", "arm64"},
+		Region:            "us-west-2",
+		SkipSSM:           true,
+	}, zap.NewNop())).To(Succeed())
+	g.Expect(os.WriteFile(bundlePath, buf.Bytes(), 0o644)).To(Succeed())
+	server.Close()
+
+	extractDir := filepath.Join(t.TempDir(), "bundle")
+	offlineBundle, err := bundle.Mount("file://"+bundlePath, extractDir)
+	g.Expect(err).NotTo(HaveOccurred())
+	defer offlineBundle.Unmount()
+
+	g.Expect(offlineBundle.ManifestURI()).To(Equal("file://" + filepath.Join(extractDir, bundle.ManifestFile)))
+	g.Expect(os.ReadFile(filepath.Join(extractDir, bundle.ManifestFile))).To(Equal([]byte("manifest")))
+	g.Expect(os.ReadFile(filepath.Join(extractDir, bundle.ManifestFile+".sig"))).To(Equal([]byte("signature")))
+
+	// Downloads are served from the bundle now that the server is gone.
+	for _, arch := range []string{"amd64", "arm64"} {
+		data, err := util.GetHttpFile(context.Background(), fmt.Sprintf("%s/%s/kubelet", server.URL, arch))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(string(data)).To(Equal("kubelet binary"))
+	}
+	_, err = util.GetHttpFile(context.Background(), server.URL+"/missing")
+	g.Expect(err).To(HaveOccurred())
+
+	g.Expect(offlineBundle.Unmount()).To(Succeed())
+	g.Expect(extractDir).NotTo(BeADirectory())
+}
+
+func TestCreateChecksumMismatch(t *testing.T) {
+	g := NewWithT(t)
+	sum := sha256.Sum256([]byte("kubelet binary"))
+	server := newTestServer(t, "tampered", hex.EncodeToString(sum[:]))
+	manifest := newTestManifest(server.URL)
+
+	err := bundle.Create(context.Background(), &bytes.Buffer{}, manifest, bundle.Options{
+		KubernetesVersion: "1.31",
+		Arches:            []string{"amd64"},
+		Region:            "us-west-2",
+		SkipSSM:           true,
+	}, zap.NewNop())
+	g.Expect(err).To(MatchError(ContainSubstring("checksum")))
+}
+
+func TestMountRejectsRemoteBundles(t *testing.T) {
+	g := NewWithT(t)
+	_, err := bundle.Mount("https://example.com/bundle.tar", t.TempDir())
+	g.Expect(err).To(MatchError(ContainSubstring("bundles must be local files")))
+}
@@ -0,0 +1,249 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aws/eks-hybrid/internal/artifact"
+)
+
+// DefaultDir is where nodeadm caches downloaded artifacts.
+const DefaultDir = "/var/cache/nodeadm"
+
+const algorithm = "sha256"
+
+// partialGracePeriod is how long a partial download is kept since it was last written.
+// More recent ones may belong to a download still in progress.
+const partialGracePeriod = time.Hour
+
+// Cache is a content-addressed store of artifacts keyed by their sha256 checksum.
+// Artifacts are stored under <dir>/sha256/<hex checksum>.
+type Cache struct {
+	dir string
+}
+
+// Entry is an artifact stored in the cache.
+type Entry struct {
+	Sha256  string
+	Size    int64
+	ModTime time.Time
+}
+
+// New returns a Cache rooted at dir. The directory is created on the first write.
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+func (c *Cache) objectsDir() string {
+	return filepath.Join(c.dir, algorithm)
+}
+
+func (c *Cache) path(checksum string) string {
+	return filepath.Join(c.objectsDir(), checksum)
+}
+
+// Open returns the cached artifact with the given sha256 checksum as a Source that verifies
+// the checksum as it's read. It returns false if the artifact isn't cached.
+func (c *Cache) Open(checksum []byte) (artifact.Source, bool) {
+	path := c.path(hex.EncodeToString(checksum))
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	// Bump the modification time so prune --older-than keeps artifacts that are still used.
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return artifact.WithDigest(fh, sha256.New(), checksum), true
+}
+
+// Wrap returns a Source that stores src in the cache as it's read. The artifact is only
+// added once src has been read in its entirety and its checksum verified. Failing to write
+// to the cache never fails the read.
+func (c *Cache) Wrap(src artifact.Source) artifact.Source {
+	if err := os.MkdirAll(c.objectsDir(), 0o755); err != nil {
+		return src
+	}
+	tmp, err := os.CreateTemp(c.objectsDir(), ".download-")
+	if err != nil {
+		return src
+	}
+	return &cachingSource{Source: src, cache: c, tmp: tmp}
+}
+
+// Import copies the file at path into the cache and returns its entry.
+func (c *Cache) Import(path string) (Entry, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(c.objectsDir(), 0o755); err != nil {
+		return Entry{}, fmt.Errorf("creating cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(c.objectsDir(), ".import-")
+	if err != nil {
+		return Entry{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	digest := sha256.New()
+	if _, err := io.Copy(tmp, io.TeeReader(src, digest)); err != nil {
+		return Entry{}, fmt.Errorf("copying %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return Entry{}, err
+	}
+	checksum := hex.EncodeToString(digest.Sum(nil))
+	if err := c.commit(tmp.Name(), checksum); err != nil {
+		return Entry{}, err
+	}
+	return c.stat(checksum)
+}
+
+// List returns the cached artifacts sorted by checksum.
+func (c *Cache) List() ([]Entry, error) {
+	dirEntries, err := os.ReadDir(c.objectsDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.Type().IsRegular() || !isChecksum(dirEntry.Name()) {
+			continue
+		}
+		entry, err := c.stat(dirEntry.Name())
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Sha256 < entries[j].Sha256
+	})
+	return entries, nil
+}
+
+// Prune removes every cached artifact for which keep returns false, along with any
+// leftover partial downloads not written for partialGracePeriod, and returns the removed
+// entries.
+func (c *Cache) Prune(keep func(Entry) bool) ([]Entry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []Entry
+	for _, entry := range entries {
+		if keep(entry) {
+			continue
+		}
+		if err := os.Remove(c.path(entry.Sha256)); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed = append(removed, entry)
+	}
+
+	partials, err := filepath.Glob(filepath.Join(c.objectsDir(), ".*"))
+	if err != nil {
+		return removed, err
+	}
+	for _, partial := range partials {
+		info, err := os.Stat(partial)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return removed, err
+		}
+		if time.Since(info.ModTime()) < partialGracePeriod {
+			continue
+		}
+		if err := os.Remove(partial); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+func (c *Cache) stat(checksum string) (Entry, error) {
+	info, err := os.Stat(c.path(checksum))
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{Sha256: checksum, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// commit moves a fully written temporary file to its place in the cache.
+func (c *Cache) commit(tmp, checksum string) error {
+	if err := os.Chmod(tmp, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path(checksum))
+}
+
+func isChecksum(name string) bool {
+	decoded, err := hex.DecodeString(name)
+	return err == nil && len(decoded) == sha256.Size
+}
+
+// cachingSource copies everything read from Source into tmp and adds it to the cache
+// when closed, provided the checksum matched.
+type cachingSource struct {
+	artifact.Source
+	cache *Cache
+	tmp   *os.File
+	eof   bool
+}
+
+func (s *cachingSource) Read(p []byte) (int, error) {
+	n, err := s.Source.Read(p)
+	if n > 0 && s.tmp != nil {
+		if _, werr := s.tmp.Write(p[:n]); werr != nil {
+			s.discard()
+		}
+	}
+	if err == io.EOF {
+		s.eof = true
+	}
+	return n, err
+}
+
+func (s *cachingSource) Close() error {
+	err := s.Source.Close()
+	if s.tmp == nil {
+		return err
+	}
+	if !s.eof || !s.VerifyChecksum() || len(s.ExpectedChecksum()) != sha256.Size {
+		s.discard()
+		return err
+	}
+	if closeErr := s.tmp.Close(); closeErr != nil {
+		s.discard()
+		return err
+	}
+	if commitErr := s.cache.commit(s.tmp.Name(), hex.EncodeToString(s.ExpectedChecksum())); commitErr != nil {
+		s.discard()
+	}
+	s.tmp = nil
+	return err
+}
+
+// discard stops caching and removes the partially written file.
+func (s *cachingSource) discard() {
+	if s.tmp == nil {
+		return
+	}
+	s.tmp.Close()
+	os.Remove(s.tmp.Name())
+	s.tmp = nil
+}
@@ -0,0 +1,126 @@
+package cache_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/eks-hybrid/internal/artifact"
+	"github.com/aws/eks-hybrid/internal/cache"
+)
+
+func TestWrapAndOpen(t *testing.T) {
+	g := NewWithT(t)
+	c := cache.New(t.TempDir())
+	content := []byte("kubelet binary")
+	checksum := sha256.Sum256(content)
+
+	_, ok := c.Open(checksum[:])
+	g.Expect(ok).To(BeFalse())
+
+	src := c.Wrap(artifact.WithDigest(io.NopCloser(bytes.NewReader(content)), sha256.New(), checksum[:]))
+	g.Expect(io.ReadAll(src)).To(Equal(content))
+	g.Expect(src.Close()).To(Succeed())
+
+	cached, ok := c.Open(checksum[:])
+	g.Expect(ok).To(BeTrue())
+	g.Expect(io.ReadAll(cached)).To(Equal(content))
+	g.Expect(cached.VerifyChecksum()).To(BeTrue())
+	g.Expect(cached.Close()).To(Succeed())
+
+	entries, err := c.List()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(entries).To(HaveLen(1))
+	g.Expect(entries[0].Size).To(BeEquivalentTo(len(content)))
+}
+
+func TestWrapChecksumMismatch(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	c := cache.New(dir)
+	checksum := sha256.Sum256([]byte("kubelet binary"))
+
+	src := c.Wrap(artifact.WithDigest(io.NopCloser(bytes.NewReader([]byte("tampered"))), sha256.New(), checksum[:]))
+	_, err := io.ReadAll(src)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(src.Close()).To(Succeed())
+
+	_, ok := c.Open(checksum[:])
+	g.Expect(ok).To(BeFalse())
+	files, err := os.ReadDir(filepath.Join(dir, "sha256"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(BeEmpty())
+}
+
+func TestWrapPartialRead(t *testing.T) {
+	g := NewWithT(t)
+	c := cache.New(t.TempDir())
+	content := []byte("kubelet binary")
+	checksum := sha256.Sum256(content)
+
+	src := c.Wrap(artifact.WithDigest(io.NopCloser(bytes.NewReader(content)), sha256.New(), checksum[:]))
+	_, err := src.Read(make([]byte, 4))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(src.Close()).To(Succeed())
+
+	_, ok := c.Open(checksum[:])
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestImportAndPrune(t *testing.T) {
+	g := NewWithT(t)
+	c := cache.New(t.TempDir())
+	artifactsDir := t.TempDir()
+	kubelet := filepath.Join(artifactsDir, "kubelet")
+	kubectl := filepath.Join(artifactsDir, "kubectl")
+	g.Expect(os.WriteFile(kubelet, []byte("kubelet binary"), 0o755)).To(Succeed())
+	g.Expect(os.WriteFile(kubectl, []byte("kubectl binary"), 0o755)).To(Succeed())
+
+	kubeletEntry, err := c.Import(kubelet)
+	g.Expect(err).NotTo(HaveOccurred())
+	kubectlEntry, err := c.Import(kubectl)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	kubeletChecksum := sha256.Sum256([]byte("kubelet binary"))
+	cached, ok := c.Open(kubeletChecksum[:])
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cached.Close()).To(Succeed())
+
+	removed, err := c.Prune(func(entry cache.Entry) bool {
+		return entry.Sha256 == kubeletEntry.Sha256
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(removed).To(HaveLen(1))
+	g.Expect(removed[0].Sha256).To(Equal(kubectlEntry.Sha256))
+
+	entries, err := c.List()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(entries).To(HaveLen(1))
+	g.Expect(entries[0].Sha256).To(Equal(kubeletEntry.Sha256))
+}
+
+func TestPruneKeepsRecentPartials(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	c := cache.New(dir)
+	objectsDir := filepath.Join(dir, "sha256")
+	g.Expect(os.MkdirAll(objectsDir, 0o755)).To(Succeed())
+
+	inProgress := filepath.Join(objectsDir, ".download-1")
+	stale := filepath.Join(objectsDir, ".download-2")
+	g.Expect(os.WriteFile(inProgress, []byte("partial"), 0o600)).To(Succeed())
+	g.Expect(os.WriteFile(stale, []byte("partial"), 0o600)).To(Succeed())
+	lastWrite := time.Now().Add(-2 * time.Hour)
+	g.Expect(os.Chtimes(stale, lastWrite, lastWrite)).To(Succeed())
+
+	_, err := c.Prune(func(cache.Entry) bool { return false })
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(inProgress).To(BeAnExistingFile())
+	g.Expect(stale).NotTo(BeAnExistingFile())
+}
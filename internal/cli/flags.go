@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/integrii/flaggy"
+)
+
+// SubcommandFlag returns every value given to a string flag of cmd, in order, read from the
+// arguments of cmd with RepeatedFlag. flaggy splits repeated string flags on commas, which
+// URIs can contain, so flags repeated with URIs are read with SubcommandFlag instead.
+func SubcommandFlag(cmd *flaggy.Subcommand, shortName, longName string) []string {
+	return RepeatedFlag(subcommandArgs(cmd, os.Args[1:]), shortName, longName)
+}
+
+// ManifestOverrides sets override to the first --manifest-override given to cmd and mirrors
+// to the others, which are tried in order when the manifest can't be read from override.
+func ManifestOverrides(cmd *flaggy.Subcommand, override *string, mirrors *[]string) {
+	if overrides := SubcommandFlag(cmd, "m", "manifest-override"); len(overrides) > 0 {
+		*override, *mirrors = overrides[0], overrides[1:]
+	}
+}
+
+// ConfigSources sets sources to the --config-source values given to cmd, in order, when
+// any is given.
+func ConfigSources(cmd *flaggy.Subcommand, sources *[]string) {
+	if values := SubcommandFlag(cmd, "c", "config-source"); len(values) > 0 {
+		*sources = values
+	}
+}
+
+// subcommandArgs returns the arguments following the name of cmd in args, which are the
+// arguments of cmd and of its subcommands.
+func subcommandArgs(cmd *flaggy.Subcommand, args []string) []string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == cmd.Name || (cmd.ShortName != "" && arg == cmd.ShortName) {
+			return args[i+1:]
+		}
+	}
+	return nil
+}
+
+// RepeatedFlag returns every value given to a string flag in args, in order. Unlike
+// flaggy's StringSlice, values aren't split on commas, so the flag can be repeated with
+// values such as URIs. Arguments after -- are not flags.
+func RepeatedFlag(args []string, shortName, longName string) []string {
+	var names []string
+	if shortName != "" {
+		names = append(names, "-"+shortName)
+	}
+	if longName != "" {
+		names = append(names, "--"+longName)
+	}
+
+	var values []string
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			break
+		}
+		for _, name := range names {
+			if args[i] == name && i+1 < len(args) {
+				i++
+				values = append(values, args[i])
+				break
+			}
+			if value, ok := strings.CutPrefix(args[i], name+"="); ok {
+				values = append(values, value)
+				break
+			}
+		}
+	}
+	return values
+}
+
+// JoinFlagValues rewrites the flags in longNames given as "--name value" to
+// "--name=value". flaggy mistakes a flag named like a subcommand, such as --manifest, for
+// that subcommand when checking for unknown arguments and rejects its value. Arguments
+// after -- are not flags.
+func JoinFlagValues(args []string, longNames ...string) []string {
+	joined := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			return append(joined, args[i:]...)
+		}
+		name, ok := strings.CutPrefix(args[i], "--")
+		if ok && slices.Contains(longNames, name) && i+1 < len(args) {
+			joined = append(joined, args[i]+"="+args[i+1])
+			i++
+			continue
+		}
+		joined = append(joined, args[i])
+	}
+	return joined
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/integrii/flaggy"
+	. "github.com/onsi/gomega"
+)
+
+func TestRepeatedFlag(t *testing.T) {
+	g := NewWithT(t)
+	args := []string{
+		"install", "1.31",
+		"-m", "https://example.com/manifest.yaml?versions=1,2",
+		"--credential-provider", "ssm",
+		"--manifest-override=s3://bucket/manifest.yaml",
+		"--", "--manifest-override", "ignored",
+	}
+
+	g.Expect(RepeatedFlag(args, "m", "manifest-override")).To(Equal([]string{
+		"https://example.com/manifest.yaml?versions=1,2",
+		"s3://bucket/manifest.yaml",
+	}))
+	g.Expect(RepeatedFlag(args, "b", "bundle")).To(BeEmpty())
+}
+
+func TestJoinFlagValues(t *testing.T) {
+	g := NewWithT(t)
+	args := []string{
+		"manifest", "validate",
+		"--manifest", "file:///root/manifest.yaml",
+		"--manifest-key", "/root/key.asc",
+		"--", "--manifest", "ignored",
+	}
+
+	g.Expect(JoinFlagValues(args, "manifest")).To(Equal([]string{
+		"manifest", "validate",
+		"--manifest=file:///root/manifest.yaml",
+		"--manifest-key", "/root/key.asc",
+		"--", "--manifest", "ignored",
+	}))
+}
+
+func TestSubcommandArgs(t *testing.T) {
+	g := NewWithT(t)
+	cmd := flaggy.NewSubcommand("show")
+	args := []string{
+		"-d", "config", "show",
+		"--config-source", "file:///etc/eks/nodeadm.d?a=1,b=2",
+		"-m", "https://example.com/manifest.yaml",
+	}
+
+	g.Expect(subcommandArgs(cmd, args)).To(Equal(args[3:]))
+	g.Expect(subcommandArgs(cmd, []string{"config", "check", "--", "show"})).To(BeEmpty())
+}
+
+func TestConfigSources(t *testing.T) {
+	g := NewWithT(t)
+	previous := os.Args
+	t.Cleanup(func() { os.Args = previous })
+	os.Args = []string{
+		"nodeadm", "init",
+		"-c", "file:///etc/eks/nodeadm.d/",
+		"--config-source=https://example.com/config.yaml?a=1,b=2",
+	}
+	cmd := flaggy.NewSubcommand("init")
+
+	// flaggy split the second source on its comma.
+	sources := []string{"file:///etc/eks/nodeadm.d/", "https://example.com/config.yaml?a=1", "b=2"}
+	ConfigSources(cmd, &sources)
+	g.Expect(sources).To(Equal([]string{"file:///etc/eks/nodeadm.d/", "https://example.com/config.yaml?a=1,b=2"}))
+}
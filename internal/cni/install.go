@@ -2,8 +2,10 @@ package cni
 
 import (
 	"context"
+	"encoding/hex"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -54,10 +56,33 @@ func installFromSource(ctx context.Context, opts InstallOptions) error {
 		return errors.Wrap(err, "installing cni-plugins")
 	}
 
-	if err := artifact.InstallTarGz(filepath.Join(opts.InstallRoot, BinPath), filepath.Join(opts.InstallRoot, TgzPath)); err != nil {
+	binPath := filepath.Join(opts.InstallRoot, BinPath)
+	tgzPath := filepath.Join(opts.InstallRoot, TgzPath)
+
+	// The archive is removed after extracting it, so the checksums of the archive and the
+	// files in it are recorded beforehand.
+	checksum, err := artifact.FileSha256(tgzPath)
+	if err != nil {
+		return errors.Wrap(err, "calculating cni-plugins archive checksum")
+	}
+	digests, err := artifact.TarGzDigests(binPath, tgzPath)
+	if err != nil {
+		return errors.Wrap(err, "calculating cni-plugins checksums")
+	}
+
+	if err := artifact.InstallTarGz(binPath, tgzPath); err != nil {
 		return errors.Wrap(err, "extracting and installing cni-plugins")
 	}
 
+	if opts.Tracker != nil {
+		opts.Tracker.Record(artifact.CniPlugins, tracker.ArtifactRecord{
+			Sha256:      hex.EncodeToString(checksum),
+			Path:        binPath,
+			Files:       digests,
+			InstalledAt: time.Now().UTC(),
+		})
+	}
+
 	return nil
 }
 
@@ -99,10 +124,11 @@ func Uninstall() error {
 // Upgrade re-installs the cni-plugins available from the source
 // Since cni-plugins is delivered as a tarball, its not possible to check if they are due for an upgrade
 // todo: (@vignesh-goutham) check if we can publish cni-plugins independently with their checksum on our manifest
-func Upgrade(ctx context.Context, src Source, log *zap.Logger) error {
+func Upgrade(ctx context.Context, src Source, tr *tracker.Tracker, log *zap.Logger) error {
 	opts := InstallOptions{
-		Source: src,
-		Logger: log,
+		Source:  src,
+		Logger:  log,
+		Tracker: tr,
 	}
 	if err := installFromSource(ctx, opts); err != nil {
 		return errors.Wrapf(err, "upgrading cni-plugins")
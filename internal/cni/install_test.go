@@ -11,6 +11,7 @@ import (
 	. "github.com/onsi/gomega"
 	"go.uber.org/zap"
 
+	"github.com/aws/eks-hybrid/internal/artifact"
 	"github.com/aws/eks-hybrid/internal/aws"
 	"github.com/aws/eks-hybrid/internal/cni"
 	"github.com/aws/eks-hybrid/internal/test"
@@ -59,6 +60,12 @@ func TestInstall(t *testing.T) {
 		},
 		Verify: func(g *GomegaWithT, tempDir string, tr *tracker.Tracker) {
 			g.Expect(tr.Artifacts.CniPlugins).To(BeTrue())
+			g.Expect(tr.Records).To(HaveKey(artifact.CniPlugins))
+			// sha256 of an empty file
+			g.Expect(tr.Records[artifact.CniPlugins].Files).To(HaveKeyWithValue(
+				filepath.Join(tempDir, cni.BinPath, "fake-plugin"),
+				"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			))
 		},
 		VerifyFilePaths: []string{filepath.Join(cni.BinPath, "fake-plugin")},
 	})
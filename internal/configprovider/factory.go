@@ -3,16 +3,50 @@ package configprovider
 import (
 	"fmt"
 	"net/url"
+
+	"github.com/aws/eks-hybrid/internal/util"
 )
 
+// Option configures how config sources are read.
+type Option func(*options)
+
+type options struct {
+	caBundles   []string
+	skipSecrets bool
+}
+
+// WithCABundles trusts the PEM encoded CA certificates in the given files, in addition to
+// the system roots, to serve https:// and s3:// config sources and secret references.
+func WithCABundles(paths ...string) Option {
+	return func(o *options) {
+		o.caBundles = append(o.caBundles, paths...)
+	}
+}
+
+// WithoutSecretResolution leaves the secret references of the config as they are written,
+// so it is read without credentials and without writing the secret files.
+func WithoutSecretResolution() Option {
+	return func(o *options) {
+		o.skipSecrets = true
+	}
+}
+
 // BuildConfigProvider returns a ConfigProvider appropriate for the given source URL.
 // The source URL must have a scheme, and the supported schemes are:
 // - `file`. To use configuration from the filesystem: `file:///path/to/file/or/directory`.
 // - `imds`. To use configuration from the instance's user data: `imds://user-data`.
-func BuildConfigProvider(rawConfigSourceURL string) (ConfigProvider, error) {
+// - `https`. To fetch configuration from a provisioning service: `https://host/path`.
+// - `s3`. To read configuration from a bucket with the default credentials: `s3://bucket/key`.
+//
+// https and s3 sources can pin the digest of the configuration with a `#sha256=<hex>` fragment.
+// Host variables such as ${hostname} are substituted in the configuration before it is
+// decoded, see interpolate.
+func BuildConfigProvider(rawConfigSourceURL string, opts ...Option) (ConfigProvider, error) {
+	options := buildOptions(opts)
 	parsedURL, err := url.Parse(rawConfigSourceURL)
 	if err != nil {
-		return nil, err
+		// The error of url.Parse quotes the whole source, which can carry credentials.
+		return nil, fmt.Errorf("invalid config source %s", util.RedactURI(rawConfigSourceURL))
 	}
 	switch parsedURL.Scheme {
 	case "imds":
@@ -20,11 +54,49 @@ func BuildConfigProvider(rawConfigSourceURL string) (ConfigProvider, error) {
 	case "file":
 		source := getURLWithoutScheme(parsedURL)
 		return NewFileConfigProvider(source), nil
+	case "https", "s3":
+		return newRemoteConfigProvider(parsedURL, options)
 	default:
 		return nil, fmt.Errorf("unsupported scheme: %s", parsedURL.Scheme)
 	}
 }
 
+// BuildLayeredConfigProvider returns a ConfigProvider merging the configuration of every
+// source URL in the given order. A source takes precedence over the sources before it, for
+// example site-wide defaults, then rack overrides, then host specific settings. Secret
+// references in the merged configuration are resolved, see resolveSecrets, unless
+// WithoutSecretResolution is given.
+func BuildLayeredConfigProvider(rawConfigSourceURLs []string, opts ...Option) (ConfigProvider, error) {
+	if len(rawConfigSourceURLs) == 0 {
+		return nil, fmt.Errorf("no config source")
+	}
+	layered := &layeredConfigProvider{sources: rawConfigSourceURLs}
+	for _, source := range rawConfigSourceURLs {
+		provider, err := BuildConfigProvider(source, opts...)
+		if err != nil {
+			return nil, err
+		}
+		layered.providers = append(layered.providers, provider)
+	}
+	var provider ConfigProvider = layered
+	if len(layered.providers) == 1 {
+		provider = layered.providers[0]
+	}
+	options := buildOptions(opts)
+	if options.skipSecrets {
+		return provider, nil
+	}
+	return &secretResolvingProvider{provider: provider, options: options}, nil
+}
+
+func buildOptions(opts []Option) options {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
 func getURLWithoutScheme(url *url.URL) string {
 	return fmt.Sprintf("%s%s", url.Host, url.Path)
 }
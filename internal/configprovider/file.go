@@ -1,18 +1,32 @@
 package configprovider
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
 
 	internalapi "github.com/aws/eks-hybrid/internal/api"
 	apibridge "github.com/aws/eks-hybrid/internal/api/bridge"
 )
 
+// dropInExtensions are the extensions of the files read from a drop-in configuration directory.
+var dropInExtensions = []string{".yaml", ".yml", ".json"}
+
 type fileConfigProvider struct {
+	interpolator
 	path string
 }
 
+// NewFileConfigProvider returns a ConfigProvider reading the NodeConfig at path. If path is
+// a directory, every document of its .yaml, .yml and .json files is merged in lexical
+// order of the file names, so later files override earlier ones.
 func NewFileConfigProvider(path string) ConfigProvider {
 	return &fileConfigProvider{
 		path: path,
@@ -20,6 +34,7 @@ func NewFileConfigProvider(path string) ConfigProvider {
 }
 
 func (fcs *fileConfigProvider) Provide() (*internalapi.NodeConfig, error) {
+	fcs.interpolator = interpolator{}
 	file, err := os.Open(fcs.path)
 	if err != nil {
 		return nil, err
@@ -30,15 +45,85 @@ func (fcs *fileConfigProvider) Provide() (*internalapi.NodeConfig, error) {
 		return nil, err
 	}
 	if info.IsDir() {
-		return nil, fmt.Errorf("path is a directory, which is not currently supported: %s", fcs.path)
+		return fcs.provideDir()
 	}
 	data, err := io.ReadAll(file)
 	if err != nil {
 		return nil, err
 	}
+	data, err = fcs.interpolate(data)
+	if err != nil {
+		return nil, fmt.Errorf("interpolating %s: %w", fcs.path, err)
+	}
 	config, err := apibridge.DecodeStrictNodeConfig(data)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkCertificateCN(config, fcs.certificateCNPaths); err != nil {
+		return nil, fmt.Errorf("interpolating %s: %w", fcs.path, err)
+	}
+	return config, nil
+}
+
+// provideDir merges the documents of the drop-in files in the directory.
+func (fcs *fileConfigProvider) provideDir() (*internalapi.NodeConfig, error) {
+	entries, err := os.ReadDir(fcs.path)
+	if err != nil {
+		return nil, err
+	}
+	var configs []*internalapi.NodeConfig
+	// ReadDir returns the entries sorted by file name.
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || !slices.Contains(dropInExtensions, filepath.Ext(entry.Name())) {
+			continue
+		}
+		path := filepath.Join(fcs.path, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		data, err = fcs.interpolate(data)
+		if err != nil {
+			return nil, fmt.Errorf("interpolating %s: %w", path, err)
+		}
+		documents, err := decodeStrictDocuments(data)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", path, err)
+		}
+		configs = append(configs, documents...)
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no NodeConfig found in directory %s", fcs.path)
+	}
+	config, err := mergeNodeConfigs(configs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCertificateCN(config, fcs.certificateCNPaths); err != nil {
+		return nil, fmt.Errorf("interpolating %s: %w", fcs.path, err)
+	}
 	return config, nil
 }
+
+// decodeStrictDocuments decodes every document of a multi-document YAML file.
+func decodeStrictDocuments(data []byte) ([]*internalapi.NodeConfig, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	var configs []*internalapi.NodeConfig
+	for {
+		document, err := reader.Read()
+		if err == io.EOF {
+			return configs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(document)) == 0 {
+			continue
+		}
+		config, err := apibridge.DecodeStrictNodeConfig(document)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+}
@@ -0,0 +1,122 @@
+package configprovider
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const rackNodeConfig = `---
+apiVersion: node.eks.aws/v1alpha1
+kind: NodeConfig
+spec:
+  cluster:
+    name: rack-cluster
+---
+# the second document of a file is merged after the first one
+apiVersion: node.eks.aws/v1alpha1
+kind: NodeConfig
+spec:
+  kubelet:
+    flags:
+      - --node-labels=rack=r12
+`
+
+func writeDropIns(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestFileConfigProviderDirectory(t *testing.T) {
+	dir := writeDropIns(t, map[string]string{
+		"00-site.yaml":      completeNodeConfig,
+		"10-rack.yml":       rackNodeConfig,
+		"20-host.yaml":      partialNodeConfig,
+		"README":            "not a config",
+		".30-editor.yaml~":  "not a config",
+		".40-disabled.yaml": "not a config",
+	})
+
+	config, err := NewFileConfigProvider(dir).Provide()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Spec.Cluster.Name != "rack-cluster" {
+		t.Errorf("expected later files to override the cluster name, got %s", config.Spec.Cluster.Name)
+	}
+	if config.Spec.Cluster.CIDR != "10.100.0.0/16" {
+		t.Errorf("expected fields only set by earlier files to be kept, got %s", config.Spec.Cluster.CIDR)
+	}
+	wantFlags := []string{"--v=2", "--node-labels=foo=bar,nodegroup=test", "--node-labels=rack=r12", "--v=5", "--node-labels=foo=baz"}
+	if !reflect.DeepEqual(config.Spec.Kubelet.Flags, wantFlags) {
+		t.Errorf("expected kubelet flags appended in lexical order\nexpected: %v\ngot:      %v", wantFlags, config.Spec.Kubelet.Flags)
+	}
+	if maxPods := string(config.Spec.Kubelet.Config["maxPods"].Raw); maxPods != "150" {
+		t.Errorf("expected kubelet config merged key by key, got maxPods %s", maxPods)
+	}
+	if port := string(config.Spec.Kubelet.Config["port"].Raw); port != "1010" {
+		t.Errorf("expected kubelet config merged key by key, got port %s", port)
+	}
+}
+
+func TestFileConfigProviderDirectoryErrors(t *testing.T) {
+	if _, err := NewFileConfigProvider(writeDropIns(t, map[string]string{"README": "nothing"})).Provide(); err == nil || !strings.Contains(err.Error(), "no NodeConfig found") {
+		t.Errorf("expected an error for a directory without configs, got %v", err)
+	}
+
+	dir := writeDropIns(t, map[string]string{
+		"00-site.yaml": completeNodeConfig,
+		"10-typo.yaml": "spec:\n  clustr:\n    name: typo\n",
+	})
+	_, err := NewFileConfigProvider(dir).Provide()
+	if err == nil || !strings.Contains(err.Error(), filepath.Join(dir, "10-typo.yaml")) {
+		t.Errorf("expected a strict decoding error naming the file, got %v", err)
+	}
+}
+
+func TestBuildLayeredConfigProvider(t *testing.T) {
+	dir := writeDropIns(t, map[string]string{
+		"site.yaml": completeNodeConfig,
+		"host.yaml": partialNodeConfig,
+	})
+
+	provider, err := BuildLayeredConfigProvider([]string{"file://" + filepath.Join(dir, "host.yaml"), "file://" + filepath.Join(dir, "site.yaml")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := provider.Provide()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The site defaults come last and take precedence over the host file.
+	if maxPods := string(config.Spec.Kubelet.Config["maxPods"].Raw); maxPods != "120" {
+		t.Errorf("expected the last source to take precedence, got maxPods %s", maxPods)
+	}
+	wantFlags := []string{"--v=5", "--node-labels=foo=baz", "--v=2", "--node-labels=foo=bar,nodegroup=test"}
+	if !reflect.DeepEqual(config.Spec.Kubelet.Flags, wantFlags) {
+		t.Errorf("expected kubelet flags appended in source order\nexpected: %v\ngot:      %v", wantFlags, config.Spec.Kubelet.Flags)
+	}
+
+	provider, err = BuildLayeredConfigProvider([]string{"file://" + filepath.Join(dir, "site.yaml"), "file://" + filepath.Join(dir, "missing.yaml")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Provide(); err == nil || !strings.Contains(err.Error(), "missing.yaml") {
+		t.Errorf("expected an error naming the missing source, got %v", err)
+	}
+
+	if _, err := BuildLayeredConfigProvider(nil); err == nil {
+		t.Error("expected an error without config sources")
+	}
+	if _, err := BuildLayeredConfigProvider([]string{"file://" + dir, "ftp://example.com/config.yaml"}); err == nil {
+		t.Error("expected an error for an unsupported scheme")
+	}
+}
@@ -0,0 +1,312 @@
+package configprovider
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/go-ini/ini"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	internalapi "github.com/aws/eks-hybrid/internal/api"
+)
+
+// Host variables a NodeConfig can reference as ${name}, so one config serves a whole
+// fleet. They are substituted in the string values of the documents, before they are
+// decoded into NodeConfigs. The set is fixed: nothing is run and no other file or
+// environment variable is read. $${ is a literal ${, and a reference to an undefined
+// variable is an error.
+const (
+	// hostnameVariable is the hostname of the machine.
+	hostnameVariable = "hostname"
+	// macVariable is the MAC address of the interface with the default route.
+	macVariable = "mac"
+	// certificateCNVariable is the subject common name of the machine's certificate, at
+	// the IAM Roles Anywhere certificate path of the config or else the default one. The
+	// path must be the one of the merged config, see checkCertificateCN.
+	certificateCNVariable = "cert.cn"
+	// osReleasePrefix starts the variables for the keys of /etc/os-release, as in
+	// ${os-release.VERSION_ID}.
+	osReleasePrefix = "os-release."
+)
+
+var (
+	osReleasePath          = "/etc/os-release"
+	machineCertificatePath = "/etc/iam/pki/server.pem"
+	routesPath             = "/proc/net/route"
+	hostname               = os.Hostname
+)
+
+// interpolator substitutes the host variables of the config a provider reads, and records
+// the certificates ${cert.cn} is read from.
+type interpolator struct {
+	certificateCNPaths []string
+}
+
+// certificateCNReader is implemented by the providers substituting host variables, so the
+// layered provider can check the certificates of all its sources.
+type certificateCNReader interface {
+	certificateCNReads() []string
+}
+
+func (i *interpolator) certificateCNReads() []string {
+	return i.certificateCNPaths
+}
+
+// interpolate substitutes the host variables referenced in the string values of the YAML
+// or JSON documents in data, which are encoded again as YAML. Comments and keys are left
+// alone, and substituted values are quoted as needed. Variables are only looked up when
+// referenced.
+func (i *interpolator) interpolate(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte("${")) {
+		return data, nil
+	}
+	var documents []any
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		document, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var tree any
+		if err := yaml.Unmarshal(document, &tree, useNumber); err != nil {
+			return nil, err
+		}
+		if tree != nil {
+			documents = append(documents, tree)
+		}
+	}
+
+	variables := &hostVariables{certificatePath: machineCertificatePath}
+	for _, tree := range documents {
+		if path, ok := lookupString(tree, "spec", "hybrid", "iamRolesAnywhere", "certificatePath"); ok {
+			// The path can reference other variables, but not the CN of the certificate.
+			path, err := (&hostVariables{}).expand(path)
+			if err != nil {
+				return nil, fmt.Errorf("certificatePath: %w", err)
+			}
+			variables.certificatePath = path
+		}
+	}
+	var out bytes.Buffer
+	for i, tree := range documents {
+		tree, err := variables.substitute(tree)
+		if err != nil {
+			return nil, err
+		}
+		document, err := yaml.Marshal(tree)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			out.WriteString("---\n")
+		}
+		out.Write(document)
+	}
+	if variables.certificateCNRead {
+		i.certificateCNPaths = append(i.certificateCNPaths, variables.certificatePath)
+	}
+	return out.Bytes(), nil
+}
+
+// checkCertificateCN returns an error if ${cert.cn} was read from another certificate than
+// the one at the certificatePath of the merged config. Each source reads the path from its
+// own documents, so the path set or overridden by another file or source isn't seen.
+func checkCertificateCN(config *internalapi.NodeConfig, paths []string) error {
+	certificatePath := machineCertificatePath
+	if config.Spec.Hybrid != nil && config.Spec.Hybrid.IAMRolesAnywhere != nil && config.Spec.Hybrid.IAMRolesAnywhere.CertificatePath != "" {
+		certificatePath = config.Spec.Hybrid.IAMRolesAnywhere.CertificatePath
+	}
+	for _, path := range paths {
+		if path != certificatePath {
+			return fmt.Errorf("variable ${%s} was read from the certificate %s, but the certificatePath of the merged config is %s, set certificatePath in the same file as ${%s}",
+				certificateCNVariable, path, certificatePath, certificateCNVariable)
+		}
+	}
+	return nil
+}
+
+// useNumber keeps the numbers of a document as they are written.
+func useNumber(decoder *json.Decoder) *json.Decoder {
+	decoder.UseNumber()
+	return decoder
+}
+
+// lookupString returns the string at the path of keys in a decoded document.
+func lookupString(tree any, keys ...string) (string, bool) {
+	for _, key := range keys {
+		object, ok := tree.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		tree = object[key]
+	}
+	value, ok := tree.(string)
+	return value, ok
+}
+
+// hostVariables looks up the host variables referenced in a document.
+type hostVariables struct {
+	// certificatePath is the certificate ${cert.cn} is read from. The variable is
+	// undefined if it is empty.
+	certificatePath string
+	// certificateCNRead is set once ${cert.cn} is read.
+	certificateCNRead bool
+}
+
+// substitute expands the variables referenced in every string value of a decoded document.
+func (v *hostVariables) substitute(tree any) (any, error) {
+	switch tree := tree.(type) {
+	case map[string]any:
+		for key, value := range tree {
+			value, err := v.substitute(value)
+			if err != nil {
+				return nil, err
+			}
+			tree[key] = value
+		}
+	case []any:
+		for i, value := range tree {
+			value, err := v.substitute(value)
+			if err != nil {
+				return nil, err
+			}
+			tree[i] = value
+		}
+	case string:
+		return v.expand(tree)
+	}
+	return tree, nil
+}
+
+// expand substitutes the variables referenced in value.
+func (v *hostVariables) expand(value string) (string, error) {
+	var out strings.Builder
+	for {
+		i := strings.IndexByte(value, '$')
+		if i < 0 {
+			out.WriteString(value)
+			return out.String(), nil
+		}
+		out.WriteString(value[:i])
+		value = value[i:]
+		switch {
+		case strings.HasPrefix(value, "$${"):
+			out.WriteString("${")
+			value = value[3:]
+		case strings.HasPrefix(value, "${"):
+			end := strings.IndexAny(value, "}\n")
+			if end < 0 || value[end] != '}' {
+				return "", fmt.Errorf("unterminated variable reference %q", firstLine(value))
+			}
+			name := value[2:end]
+			substitute, err := v.lookup(name)
+			if err != nil {
+				return "", fmt.Errorf("variable ${%s}: %w", name, err)
+			}
+			out.WriteString(substitute)
+			value = value[end+1:]
+		default:
+			out.WriteByte('$')
+			value = value[1:]
+		}
+	}
+}
+
+func firstLine(value string) string {
+	line, _, _ := strings.Cut(value, "\n")
+	return line
+}
+
+func (v *hostVariables) lookup(name string) (string, error) {
+	switch {
+	case name == hostnameVariable:
+		return hostname()
+	case name == macVariable:
+		return primaryMAC()
+	case name == certificateCNVariable:
+		if v.certificatePath == "" {
+			return "", fmt.Errorf("the certificate can't be named after its own common name")
+		}
+		if IsSecretReference(v.certificatePath) {
+			return "", fmt.Errorf("certificatePath %s is a secret reference, which is only resolved after the host variables", v.certificatePath)
+		}
+		v.certificateCNRead = true
+		return certificateCN(v.certificatePath)
+	case strings.HasPrefix(name, osReleasePrefix):
+		return osReleaseValue(strings.TrimPrefix(name, osReleasePrefix))
+	default:
+		return "", fmt.Errorf("undefined variable, the supported variables are %s, %s, %s and %s<KEY>",
+			hostnameVariable, macVariable, certificateCNVariable, osReleasePrefix)
+	}
+}
+
+// primaryMAC returns the MAC address of the interface with the default IPv4 route.
+func primaryMAC() (string, error) {
+	file, err := os.Open(routesPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// The columns are Iface, Destination, Gateway and so on, with the header first.
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[1] != "00000000" {
+			continue
+		}
+		iface, err := net.InterfaceByName(fields[0])
+		if err != nil {
+			return "", err
+		}
+		if len(iface.HardwareAddr) == 0 {
+			return "", fmt.Errorf("interface %s of the default route has no MAC address", iface.Name)
+		}
+		return iface.HardwareAddr.String(), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no default route")
+}
+
+func certificateCN(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return "", fmt.Errorf("no PEM encoded certificate in %s", path)
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	if certificate.Subject.CommonName == "" {
+		return "", fmt.Errorf("certificate %s has no common name", path)
+	}
+	return certificate.Subject.CommonName, nil
+}
+
+func osReleaseValue(key string) (string, error) {
+	osRelease, err := ini.Load(osReleasePath)
+	if err != nil {
+		return "", err
+	}
+	if !osRelease.Section("").HasKey(key) {
+		return "", fmt.Errorf("%s has no key %s", osReleasePath, key)
+	}
+	return osRelease.Section("").Key(key).String(), nil
+}
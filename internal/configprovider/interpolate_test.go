@@ -0,0 +1,245 @@
+package configprovider
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+const templateNodeConfig = `---
+apiVersion: node.eks.aws/v1alpha1
+kind: NodeConfig
+spec:
+  cluster:
+    name: my-cluster
+    region: us-west-2
+  hybrid:
+    iamRolesAnywhere:
+      nodeName: ${cert.cn}
+      trustAnchorArn: arn:aws:rolesanywhere:us-west-2:123456789012:trust-anchor/ta
+      profileArn: arn:aws:rolesanywhere:us-west-2:123456789012:profile/p
+      roleArn: arn:aws:iam::123456789012:role/hybrid
+      certificatePath: /etc/iam/pki/${hostname}.pem
+  kubelet:
+    flags:
+      - --node-labels=os=${os-release.ID},os-version=${os-release.VERSION_ID},host=${hostname}
+      - --register-with-taints=literal=$${not-a-variable}:NoSchedule
+`
+
+// withHostFacts points the host variables at test fixtures in the returned directory.
+func withHostFacts(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	previousOSRelease, previousCertificate, previousRoutes, previousHostname := osReleasePath, machineCertificatePath, routesPath, hostname
+	t.Cleanup(func() {
+		osReleasePath, machineCertificatePath, routesPath, hostname = previousOSRelease, previousCertificate, previousRoutes, previousHostname
+	})
+
+	osReleasePath = filepath.Join(dir, "os-release")
+	if err := os.WriteFile(osReleasePath, []byte("NAME=\"Ubuntu\"\nID=ubuntu\nVERSION_ID=\"24.04\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	machineCertificatePath = filepath.Join(dir, "server.pem")
+	writeCertificate(t, machineCertificatePath, "default-node")
+	writeCertificate(t, filepath.Join(dir, "r12-n07.pem"), "rack12-node07")
+	routesPath = filepath.Join(dir, "route")
+	hostname = func() (string, error) { return "r12-n07", nil }
+	return dir
+}
+
+func writeCertificate(t *testing.T, path, commonName string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInterpolateHostVariables(t *testing.T) {
+	facts := withHostFacts(t)
+	dir := writeDropIns(t, map[string]string{"config.yaml": strings.ReplaceAll(templateNodeConfig, "/etc/iam/pki", facts)})
+
+	config, err := NewFileConfigProvider(filepath.Join(dir, "config.yaml")).Provide()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Spec.Hybrid.IAMRolesAnywhere.NodeName; got != "rack12-node07" {
+		t.Errorf("expected the node name from the CN of the configured certificate, got %s", got)
+	}
+	if got := config.Spec.Hybrid.IAMRolesAnywhere.CertificatePath; got != filepath.Join(facts, "r12-n07.pem") {
+		t.Errorf("expected the certificate path with the hostname, got %s", got)
+	}
+	want := []string{
+		"--node-labels=os=ubuntu,os-version=24.04,host=r12-n07",
+		"--register-with-taints=literal=${not-a-variable}:NoSchedule",
+	}
+	if !reflect.DeepEqual(config.Spec.Kubelet.Flags, want) {
+		t.Errorf("expected interpolated kubelet flags\nexpected: %v\ngot:      %v", want, config.Spec.Kubelet.Flags)
+	}
+}
+
+func TestInterpolateErrors(t *testing.T) {
+	withHostFacts(t)
+	for name, tc := range map[string]struct {
+		data    string
+		wantErr string
+	}{
+		"undefined variable":     {data: "name: ${hostnme}", wantErr: "variable ${hostnme}: undefined variable"},
+		"environment variable":   {data: "name: ${HOME}", wantErr: "variable ${HOME}: undefined variable"},
+		"missing os-release key": {data: "name: ${os-release.BUILD_ID}", wantErr: "has no key BUILD_ID"},
+		"unterminated reference": {data: "name: ${hostname\nid: 1", wantErr: `unterminated variable reference "${hostname"`},
+		"no default route":       {data: "mac: ${mac}", wantErr: "no default route"},
+		"recursive certificate path": {
+			data:    "spec:\n  hybrid:\n    iamRolesAnywhere:\n      certificatePath: /etc/iam/pki/${cert.cn}.pem",
+			wantErr: "certificatePath: variable ${cert.cn}",
+		},
+		"certificate secret reference": {
+			data:    "name: ${cert.cn}\nspec:\n  hybrid:\n    iamRolesAnywhere:\n      certificatePath: ssm-parameter:///eks/hybrid/certificate",
+			wantErr: "certificatePath ssm-parameter:///eks/hybrid/certificate is a secret reference",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := os.WriteFile(routesPath, []byte("Iface\tDestination\tGateway\nlo\t0000007F\t00000000\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := (&interpolator{}).interpolate([]byte(tc.data))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	// Strict decoding is never reached with an undefined variable.
+	dir := writeDropIns(t, map[string]string{"config.yaml": strings.Replace(templateNodeConfig, "${hostname}", "${host}", 1)})
+	if _, err := NewFileConfigProvider(filepath.Join(dir, "config.yaml")).Provide(); err == nil || !strings.Contains(err.Error(), "interpolating") {
+		t.Errorf("expected an interpolation error naming the file, got %v", err)
+	}
+}
+
+func TestInterpolatePrimaryMAC(t *testing.T) {
+	withHostFacts(t)
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, iface := range interfaces {
+		if len(iface.HardwareAddr) == 0 {
+			continue
+		}
+		route := "Iface\tDestination\tGateway\n" + iface.Name + "\t00000000\t0102A8C0\n"
+		if err := os.WriteFile(routesPath, []byte(route), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		data, err := (&interpolator{}).interpolate([]byte("mac: ${mac}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]string
+		if err := yaml.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if want := iface.HardwareAddr.String(); got["mac"] != want {
+			t.Errorf("expected %q, got %q", want, got["mac"])
+		}
+		return
+	}
+	t.Skip("no interface with a MAC address")
+}
+
+func TestInterpolateDefaultCertificate(t *testing.T) {
+	withHostFacts(t)
+	data, err := (&interpolator{}).interpolate([]byte("name: ${cert.cn}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "name: default-node\n" {
+		t.Errorf("expected the CN of the default certificate without a configured path, got %q", data)
+	}
+}
+
+func TestInterpolateCertificateOfMergedConfig(t *testing.T) {
+	facts := withHostFacts(t)
+	node := strings.ReplaceAll(templateNodeConfig, "      certificatePath: /etc/iam/pki/${hostname}.pem\n", "")
+	override := "---\napiVersion: node.eks.aws/v1alpha1\nkind: NodeConfig\nspec:\n  hybrid:\n    iamRolesAnywhere:\n      certificatePath: " + filepath.Join(facts, "r12-n07.pem") + "\n"
+
+	// ${cert.cn} of 20-node.yaml would be read from the default certificate, not the one of 30-override.yaml.
+	dir := writeDropIns(t, map[string]string{"20-node.yaml": node, "30-override.yaml": override})
+	if _, err := NewFileConfigProvider(dir).Provide(); err == nil || !strings.Contains(err.Error(), "set certificatePath in the same file as ${cert.cn}") {
+		t.Errorf("expected an error about the certificate path of another file, got %v", err)
+	}
+
+	provider, err := BuildLayeredConfigProvider([]string{"file://" + writeDropIns(t, map[string]string{"node.yaml": node}), "file://" + writeDropIns(t, map[string]string{"override.yaml": override})}, WithoutSecretResolution())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Provide(); err == nil || !strings.Contains(err.Error(), "set certificatePath in the same file as ${cert.cn}") {
+		t.Errorf("expected an error about the certificate path of another source, got %v", err)
+	}
+
+	// The path of the same file is the one of the merged config.
+	dir = writeDropIns(t, map[string]string{"10-base.yaml": override, "20-node.yaml": strings.ReplaceAll(templateNodeConfig, "/etc/iam/pki", facts)})
+	config, err := NewFileConfigProvider(dir).Provide()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Spec.Hybrid.IAMRolesAnywhere.NodeName; got != "rack12-node07" {
+		t.Errorf("expected the node name from the CN of the configured certificate, got %s", got)
+	}
+}
+
+func TestInterpolateQuotesValuesAndSkipsComments(t *testing.T) {
+	withHostFacts(t)
+	hostname = func() (string, error) { return "r12: 'n07' #1", nil }
+	data, err := (&interpolator{}).interpolate([]byte(`# Set by ${unknown} tooling.
+name: ${hostname} # ${also-unknown}
+count: 3
+labels:
+  - "${os-release.ID}"
+---
+other: ${hostname}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var documents []map[string]any
+	for _, document := range strings.Split(string(data), "---\n") {
+		var decoded map[string]any
+		if err := yaml.Unmarshal([]byte(document), &decoded); err != nil {
+			t.Fatalf("expected valid YAML, got %v in\n%s", err, data)
+		}
+		documents = append(documents, decoded)
+	}
+	want := []map[string]any{
+		{"name": "r12: 'n07' #1", "count": float64(3), "labels": []any{"ubuntu"}},
+		{"other": "r12: 'n07' #1"},
+	}
+	if !reflect.DeepEqual(documents, want) {
+		t.Errorf("expected the substituted documents\nexpected: %v\ngot:      %v", want, documents)
+	}
+}
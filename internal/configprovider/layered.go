@@ -0,0 +1,50 @@
+package configprovider
+
+import (
+	"fmt"
+
+	internalapi "github.com/aws/eks-hybrid/internal/api"
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+// layeredConfigProvider merges the configuration of several sources in order, so each
+// source overrides the ones before it.
+type layeredConfigProvider struct {
+	sources   []string
+	providers []ConfigProvider
+}
+
+func (l *layeredConfigProvider) Provide() (*internalapi.NodeConfig, error) {
+	var configs []*internalapi.NodeConfig
+	var certificateCNPaths []string
+	for i, provider := range l.providers {
+		config, err := provider.Provide()
+		if err != nil {
+			return nil, fmt.Errorf("reading config source %s: %w", util.RedactURI(l.sources[i]), err)
+		}
+		configs = append(configs, config)
+		if reader, ok := provider.(certificateCNReader); ok {
+			certificateCNPaths = append(certificateCNPaths, reader.certificateCNReads()...)
+		}
+	}
+	config, err := mergeNodeConfigs(configs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCertificateCN(config, certificateCNPaths); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// mergeNodeConfigs merges configs into the first one, in order. Scalar fields are overridden,
+// kubelet flags are appended and kubelet configs are merged key by key.
+func mergeNodeConfigs(configs []*internalapi.NodeConfig) (*internalapi.NodeConfig, error) {
+	config := configs[0]
+	for _, nodeConfig := range configs[1:] {
+		if err := config.Merge(nodeConfig); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
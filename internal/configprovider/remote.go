@@ -0,0 +1,236 @@
+package configprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	internalapi "github.com/aws/eks-hybrid/internal/api"
+	apibridge "github.com/aws/eks-hybrid/internal/api/bridge"
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+const (
+	// pinPrefix starts the URL fragment pinning the digest of a remote config, as in
+	// https://example.com/node.yaml#sha256=<hex>.
+	pinPrefix = "sha256="
+
+	remoteTimeout  = 2 * time.Minute
+	remoteAttempts = 3
+	// defaultS3Region is used to look up the region of the bucket when none is configured.
+	defaultS3Region = "us-east-1"
+)
+
+// remoteBackoff is the wait before the first retry of a failed fetch, doubled after every attempt.
+var remoteBackoff = 2 * time.Second
+
+// remoteConfigProvider fetches the NodeConfig from a provisioning service or a bucket. The
+// config is only decoded if it matches the pinned digest, when there is one.
+type remoteConfigProvider struct {
+	interpolator
+	uri string
+	// name is uri without credentials, used in errors.
+	name   string
+	sha256 []byte
+	fetch  func(ctx context.Context) ([]byte, error)
+}
+
+func (r *remoteConfigProvider) Provide() (*internalapi.NodeConfig, error) {
+	r.interpolator = interpolator{}
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+
+	var data []byte
+	err := util.RetryExponentialBackoff(remoteAttempts, remoteBackoff, func() error {
+		var err error
+		data, err = r.fetch(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching config from %s: %w", r.name, err)
+	}
+	if r.sha256 != nil {
+		if sum := sha256.Sum256(data); !bytes.Equal(sum[:], r.sha256) {
+			return nil, fmt.Errorf("config from %s has sha256 %x, expected the pinned %x", r.name, sum, r.sha256)
+		}
+	}
+	data, err = r.interpolate(data)
+	if err != nil {
+		return nil, fmt.Errorf("interpolating config from %s: %w", r.name, err)
+	}
+	config, err := apibridge.DecodeStrictNodeConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCertificateCN(config, r.certificateCNPaths); err != nil {
+		return nil, fmt.Errorf("interpolating config from %s: %w", r.name, err)
+	}
+	return config, nil
+}
+
+// newRemoteConfigProvider returns the provider for an https:// or s3:// config source.
+func newRemoteConfigProvider(parsedURL *url.URL, options options) (ConfigProvider, error) {
+	pin, err := parsePin(parsedURL.Fragment)
+	if err != nil {
+		return nil, err
+	}
+	source := *parsedURL
+	source.Fragment = ""
+	provider := &remoteConfigProvider{uri: source.String(), name: util.RedactURI(source.String()), sha256: pin}
+
+	caBundle, err := readCABundles(options.caBundles)
+	if err != nil {
+		return nil, err
+	}
+	switch source.Scheme {
+	case "https":
+		client, err := newHTTPClient(caBundle)
+		if err != nil {
+			return nil, err
+		}
+		provider.fetch = func(ctx context.Context) ([]byte, error) {
+			return fetchHTTPS(ctx, client, provider.uri)
+		}
+	case "s3":
+		bucket, key, err := util.ParseS3URI(provider.name)
+		if err != nil {
+			return nil, err
+		}
+		provider.fetch = func(ctx context.Context) ([]byte, error) {
+			return fetchS3(ctx, bucket, key, caBundle)
+		}
+	}
+	return provider, nil
+}
+
+// parsePin returns the digest pinned by a sha256=<hex> fragment, or nil without fragment.
+func parsePin(fragment string) ([]byte, error) {
+	if fragment == "" {
+		return nil, nil
+	}
+	digest, ok := strings.CutPrefix(fragment, pinPrefix)
+	if !ok {
+		return nil, fmt.Errorf("unsupported config source fragment %q, expected %s<hex digest>", fragment, pinPrefix)
+	}
+	pin, err := hex.DecodeString(digest)
+	if err != nil || len(pin) != sha256.Size {
+		return nil, fmt.Errorf("invalid pinned sha256 %q, expected %d hex characters", digest, 2*sha256.Size)
+	}
+	return pin, nil
+}
+
+// readCABundles concatenates the PEM encoded CA bundles in paths.
+func readCABundles(paths []string) ([]byte, error) {
+	var bundle []byte
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		bundle = append(bundle, data...)
+		bundle = append(bundle, '\n')
+	}
+	return bundle, nil
+}
+
+// newHTTPClient returns a client trusting the CA bundle in addition to the system roots.
+func newHTTPClient(caBundle []byte) (*http.Client, error) {
+	if len(caBundle) == 0 {
+		return &http.Client{}, nil
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(caBundle) {
+		return nil, fmt.Errorf("no PEM encoded certificate found in the CA bundles")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	return &http.Client{Transport: transport}, nil
+}
+
+func fetchHTTPS(ctx context.Context, client *http.Client, uri string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(request)
+	if err != nil {
+		// The errors of the client quote the URL, which can carry credentials.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = util.RedactURI(urlErr.URL)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// newS3Client builds the client reading s3:// config sources. The node has no credentials
+// of its own before init, so the default credential chain is used.
+var newS3Client = func(ctx context.Context, bucket string, caBundle []byte) (util.S3Client, error) {
+	awsConfig, err := loadAWSConfig(ctx, "", caBundle)
+	if err != nil {
+		return nil, err
+	}
+	if awsConfig.Region == "" {
+		region, err := manager.GetBucketRegion(ctx, s3.NewFromConfig(awsConfig, func(o *s3.Options) {
+			o.Region = defaultS3Region
+		}), bucket)
+		if err != nil {
+			return nil, fmt.Errorf("looking up the region of bucket %s: %w", bucket, err)
+		}
+		awsConfig.Region = region
+	}
+	return s3.NewFromConfig(awsConfig), nil
+}
+
+func fetchS3(ctx context.Context, bucket, key string, caBundle []byte) ([]byte, error) {
+	client, err := newS3Client(ctx, bucket, caBundle)
+	if err != nil {
+		return nil, err
+	}
+	object, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer object.Body.Close()
+	return io.ReadAll(object.Body)
+}
+
+// loadAWSConfig loads the default AWS config, in the given region if not empty, trusting
+// the CA bundle in addition to the system roots.
+func loadAWSConfig(ctx context.Context, region string, caBundle []byte) (aws.Config, error) {
+	var opts []func(*config.LoadOptions) error
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	if len(caBundle) > 0 {
+		opts = append(opts, config.WithCustomCABundle(bytes.NewReader(caBundle)))
+	}
+	return config.LoadDefaultConfig(ctx, opts...)
+}
@@ -0,0 +1,236 @@
+package configprovider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+
+	internalapi "github.com/aws/eks-hybrid/internal/api"
+	"github.com/aws/eks-hybrid/internal/ledger"
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+// Schemes of the secret references a sensitive NodeConfig field can be set to, instead of
+// the secret itself.
+const (
+	// ssmParameterScheme reads a SecureString or String parameter, as in
+	// ssm-parameter:///eks/hybrid/activation-code.
+	ssmParameterScheme = "ssm-parameter"
+	// secretsManagerScheme reads the string of a secret by name or ARN, as in
+	// secretsmanager://eks-hybrid-activation-code.
+	secretsManagerScheme = "secretsmanager"
+	// envScheme reads an environment variable of nodeadm, as in env://ACTIVATION_CODE.
+	envScheme = "env"
+	// fileScheme reads a file, without trailing newlines, as in file:///run/secrets/activation-code.
+	fileScheme = "file"
+)
+
+// secretFilesDir is where the certificate and private key resolved from secret references
+// are written. Their fields hold paths, so the config is set to the path of the file rather
+// than to the secret, which would otherwise end up in messages quoting the path.
+var secretFilesDir = "/etc/iam/pki"
+
+type ssmClient interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+type secretsManagerClient interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// newSSMClient and newSecretsManagerClient build the clients reading secret references
+// with the default credential chain.
+var (
+	newSSMClient = func(ctx context.Context, region string, caBundle []byte) (ssmClient, error) {
+		awsConfig, err := loadAWSConfig(ctx, region, caBundle)
+		if err != nil {
+			return nil, err
+		}
+		return ssm.NewFromConfig(awsConfig), nil
+	}
+	newSecretsManagerClient = func(ctx context.Context, region string, caBundle []byte) (secretsManagerClient, error) {
+		awsConfig, err := loadAWSConfig(ctx, region, caBundle)
+		if err != nil {
+			return nil, err
+		}
+		return secretsmanager.NewFromConfig(awsConfig), nil
+	}
+)
+
+// secretResolvingProvider resolves the secret references of the config it provides.
+type secretResolvingProvider struct {
+	provider ConfigProvider
+	options  options
+}
+
+func (s *secretResolvingProvider) Provide() (*internalapi.NodeConfig, error) {
+	config, err := s.provider.Provide()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+	if err := resolveSecrets(ctx, config, s.options); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// sensitiveField is a NodeConfig field that can be set to a secret reference.
+type sensitiveField struct {
+	name  string
+	value *string
+	// file is set for fields holding the path of a secret file. The secret is written to
+	// this file under secretFilesDir and the field is set to its path.
+	file string
+}
+
+func sensitiveFields(config *internalapi.NodeConfig) []sensitiveField {
+	if config.Spec.Hybrid == nil {
+		return nil
+	}
+	var fields []sensitiveField
+	if ssm := config.Spec.Hybrid.SSM; ssm != nil {
+		fields = append(fields,
+			sensitiveField{name: "spec.hybrid.ssm.activationCode", value: &ssm.ActivationCode},
+			sensitiveField{name: "spec.hybrid.ssm.activationId", value: &ssm.ActivationID},
+		)
+	}
+	if iamRolesAnywhere := config.Spec.Hybrid.IAMRolesAnywhere; iamRolesAnywhere != nil {
+		fields = append(fields,
+			sensitiveField{name: "spec.hybrid.iamRolesAnywhere.certificatePath", value: &iamRolesAnywhere.CertificatePath, file: "server.pem"},
+			sensitiveField{name: "spec.hybrid.iamRolesAnywhere.privateKeyPath", value: &iamRolesAnywhere.PrivateKeyPath, file: "server.key"},
+		)
+	}
+	return fields
+}
+
+// IsSecretReference returns true if value is a secret reference, which is resolved to the
+// secret by the commands reading the config with secret resolution, such as init.
+func IsSecretReference(value string) bool {
+	return strings.Contains(value, "://")
+}
+
+// RedactSecrets replaces every sensitive field of config set to a secret with redacted.
+// Secret references are kept as they don't disclose the secret, and so are the paths of
+// the certificate and private key, which hold the path of the secret file once resolved.
+func RedactSecrets(config *internalapi.NodeConfig, redacted string) {
+	for _, field := range sensitiveFields(config) {
+		if field.file == "" && *field.value != "" && !IsSecretReference(*field.value) {
+			*field.value = redacted
+		}
+	}
+}
+
+// resolveSecrets replaces every sensitive field set to a secret reference with the secret,
+// or with the path of the file the secret is written to for path fields. SSM parameters and
+// secrets are read in the region of their ARN, or else of the cluster. Errors name the field
+// and the reference, never the secret.
+func resolveSecrets(ctx context.Context, config *internalapi.NodeConfig, options options) error {
+	fields := sensitiveFields(config)
+	if len(fields) == 0 {
+		return nil
+	}
+	caBundle, err := readCABundles(options.caBundles)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		scheme, name, ok := strings.Cut(*field.value, "://")
+		if !ok {
+			continue
+		}
+		secret, err := readSecret(ctx, scheme, name, config.Spec.Cluster.Region, caBundle)
+		if err != nil {
+			return fmt.Errorf("resolving %s from %s: %w", field.name, *field.value, err)
+		}
+		if field.file != "" {
+			path := filepath.Join(secretFilesDir, field.file)
+			if err := writeSecretFile(path, secret); err != nil {
+				return fmt.Errorf("writing %s resolved from %s: %w", field.name, *field.value, err)
+			}
+			secret = path
+		}
+		*field.value = secret
+	}
+	return nil
+}
+
+// writeSecretFile writes secret to path, readable by root only.
+func writeSecretFile(path, secret string) error {
+	if err := ledger.MkdirAll(util.HostPath(filepath.Dir(path)), 0o755); err != nil {
+		return err
+	}
+	if err := util.WriteFileWithDir(path, []byte(secret+"\n"), 0o600); err != nil {
+		return err
+	}
+	// The mode of an existing file isn't changed by writing it.
+	return os.Chmod(util.HostPath(path), 0o600)
+}
+
+func readSecret(ctx context.Context, scheme, name, region string, caBundle []byte) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("empty secret reference")
+	}
+	if arn.IsARN(name) {
+		parsed, err := arn.Parse(name)
+		if err != nil {
+			return "", err
+		}
+		region = parsed.Region
+	}
+	switch scheme {
+	case ssmParameterScheme:
+		client, err := newSSMClient(ctx, region, caBundle)
+		if err != nil {
+			return "", err
+		}
+		output, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+			Name:           aws.String(name),
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			return "", err
+		}
+		if output.Parameter == nil || output.Parameter.Value == nil {
+			return "", fmt.Errorf("parameter has no value")
+		}
+		return *output.Parameter.Value, nil
+	case secretsManagerScheme:
+		client, err := newSecretsManagerClient(ctx, region, caBundle)
+		if err != nil {
+			return "", err
+		}
+		output, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+			SecretId: aws.String(name),
+		})
+		if err != nil {
+			return "", err
+		}
+		if output.SecretString == nil {
+			return "", fmt.Errorf("secret has no string value")
+		}
+		return *output.SecretString, nil
+	case envScheme:
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+	case fileScheme:
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	default:
+		return "", fmt.Errorf("unsupported secret reference scheme: %s", scheme)
+	}
+}
@@ -22,32 +22,42 @@ const (
 	nodeConfigMediaType        = "application/" + api.GroupName
 )
 
-type userDataConfigProvider struct{}
+type userDataConfigProvider struct {
+	interpolator
+}
 
 func NewUserDataConfigProvider() ConfigProvider {
 	return &userDataConfigProvider{}
 }
 
 func (ics *userDataConfigProvider) Provide() (*internalapi.NodeConfig, error) {
+	ics.interpolator = interpolator{}
 	userData, err := imds.GetUserData()
 	if err != nil {
 		return nil, err
 	}
+	var config *internalapi.NodeConfig
 	// if the MIME data fails to parse as a multipart document, then fall back
 	// to parsing the entire userdata as the node config.
 	if multipartReader, err := getMIMEMultipartReader(userData); err == nil {
-		config, err := parseMultipart(multipartReader)
+		config, err = ics.parseMultipart(multipartReader)
 		if err != nil {
 			return nil, err
 		}
-		return config, nil
 	} else {
-		config, err := apibridge.DecodeNodeConfig(userData)
+		userData, err := ics.interpolate(userData)
+		if err != nil {
+			return nil, fmt.Errorf("interpolating user data: %w", err)
+		}
+		config, err = apibridge.DecodeNodeConfig(userData)
 		if err != nil {
 			return nil, err
 		}
-		return config, nil
 	}
+	if err := checkCertificateCN(config, ics.certificateCNPaths); err != nil {
+		return nil, fmt.Errorf("interpolating user data: %w", err)
+	}
+	return config, nil
 }
 
 func getMIMEMultipartReader(data []byte) (*multipart.Reader, error) {
@@ -65,7 +75,7 @@ func getMIMEMultipartReader(data []byte) (*multipart.Reader, error) {
 	return multipart.NewReader(msg.Body, params[mimeBoundaryParam]), nil
 }
 
-func parseMultipart(userDataReader *multipart.Reader) (*internalapi.NodeConfig, error) {
+func (ics *userDataConfigProvider) parseMultipart(userDataReader *multipart.Reader) (*internalapi.NodeConfig, error) {
 	var nodeConfigs []*internalapi.NodeConfig
 	for {
 		part, err := userDataReader.NextPart()
@@ -85,6 +95,10 @@ func parseMultipart(userDataReader *multipart.Reader) (*internalapi.NodeConfig,
 				if err != nil {
 					return nil, err
 				}
+				nodeConfigPart, err = ics.interpolate(nodeConfigPart)
+				if err != nil {
+					return nil, fmt.Errorf("interpolating user data: %w", err)
+				}
 				decodedConfig, err := apibridge.DecodeNodeConfig(nodeConfigPart)
 				if err != nil {
 					return nil, err
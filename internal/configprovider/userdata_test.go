@@ -104,7 +104,7 @@ func TestParseMIMENodeConfig(t *testing.T) {
 		t.Fatal(err)
 	}
 	userDataReader := multipart.NewReader(mimeMessage.Body, boundary)
-	if _, err := parseMultipart(userDataReader); err != nil {
+	if _, err := (&userDataConfigProvider{}).parseMultipart(userDataReader); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -125,7 +125,7 @@ func TestMergeNodeConfig(t *testing.T) {
 		t.Fatal(err)
 	}
 	userDataReader := multipart.NewReader(mimeMessage.Body, boundary)
-	config, err := parseMultipart(userDataReader)
+	config, err := (&userDataConfigProvider{}).parseMultipart(userDataReader)
 	if err != nil {
 		t.Fatal(err)
 	}
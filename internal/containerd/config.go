@@ -35,6 +35,15 @@ type containerdTemplateVars struct {
 	SandboxImage string
 }
 
+// ConfigPaths returns the files and directories nodeadm writes to configure containerd.
+func ConfigPaths() []string {
+	return []string{
+		containerdConfigFile,
+		containerdConfigImportDir,
+		containerdKernelModulesConfigFile,
+	}
+}
+
 func writeContainerdConfig(cfg *api.NodeConfig) error {
 	// write nodeadm's generated containerd config to the default path
 	containerdConfig, err := generateContainerdConfig(cfg)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"time"
 
 	"github.com/pkg/errors"
@@ -22,6 +23,9 @@ const (
 	runcPackageName       = "runc"
 )
 
+// binaryNames are the executables installed by the containerd and runc packages.
+var binaryNames = []string{"containerd", "containerd-shim-runc-v2", "ctr", runcPackageName}
+
 // Source represents a source that serves a containerd binary.
 type Source interface {
 	GetContainerd(version string) artifact.Package
@@ -128,6 +132,23 @@ func ValidateSystemdUnitFile() error {
 	return nil
 }
 
+// BinaryPaths returns the installed executables of the containerd and runc packages, with
+// symlinks resolved, so they can be restored if a package upgrade has to be undone.
+func BinaryPaths() []string {
+	var paths []string
+	for _, name := range binaryNames {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
 func isContainerdInstalled() bool {
 	_, containerdNotFoundErr := exec.LookPath(containerdPackageName)
 	return containerdNotFoundErr == nil
@@ -6,9 +6,13 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 
 	"github.com/aws/eks-hybrid/internal/api"
 	"github.com/aws/eks-hybrid/internal/iamrolesanywhere"
+	"github.com/aws/eks-hybrid/internal/ssm"
+	"github.com/aws/eks-hybrid/internal/util"
+	"github.com/aws/eks-hybrid/internal/util/file"
 )
 
 func ReadConfigAsKubelet(ctx context.Context, node *api.NodeConfig, opts ...func(*config.LoadOptions) error) (aws.Config, error) {
@@ -44,3 +48,54 @@ func ReadConfigAsKubelet(ctx context.Context, node *api.NodeConfig, opts ...func
 
 	return aws.Config{}, errors.New("don't know how to build aws config for node config: only EC2, SSM or IAM Roles Anywhere are supported")
 }
+
+// ReadHybridConfig loads the AWS config of the node's own credentials for provider: the
+// credentials file written by the SSM agent or the IAM Roles Anywhere profile in
+// awsConfigPath. Before the node is registered those don't exist yet and the default
+// credential chain is used instead.
+func ReadHybridConfig(ctx context.Context, provider CredentialProvider, awsConfigPath, region string) (aws.Config, error) {
+	opts := []func(*config.LoadOptions) error{config.WithRegion(region)}
+	switch provider {
+	case SsmCredentialProvider:
+		if credentialsPath := ssm.CredentialsFilePath(); file.Exists(credentialsPath) {
+			opts = append(opts, config.WithSharedCredentialsFiles([]string{credentialsPath}))
+		}
+	case IamRolesAnywhereCredentialProvider:
+		if awsConfigPath == "" {
+			awsConfigPath = iamrolesanywhere.DefaultAWSConfigPath
+		}
+		if file.Exists(awsConfigPath) {
+			opts = append(opts,
+				config.WithSharedConfigFiles([]string{awsConfigPath}),
+				config.WithSharedCredentialsFiles([]string{iamrolesanywhere.EksHybridAwsCredentialsPath}),
+				config.WithSharedConfigProfile(iamrolesanywhere.ProfileName),
+			)
+		}
+	}
+	return config.LoadDefaultConfig(ctx, opts...)
+}
+
+// S3ClientProvider returns a provider for util.SetS3ClientProvider that reads s3:// URIs
+// with the node's own credentials, see ReadHybridConfig.
+func S3ClientProvider(provider CredentialProvider, awsConfigPath, region string) func(context.Context) (util.S3Client, error) {
+	return func(ctx context.Context) (util.S3Client, error) {
+		awsConfig, err := ReadHybridConfig(ctx, provider, awsConfigPath, region)
+		if err != nil {
+			return nil, err
+		}
+		return s3.NewFromConfig(awsConfig), nil
+	}
+}
+
+// NodeS3ClientProvider is S3ClientProvider for the credential provider and region of node.
+func NodeS3ClientProvider(node *api.NodeConfig) (func(context.Context) (util.S3Client, error), error) {
+	provider, err := GetCredentialProviderFromNodeConfig(node)
+	if err != nil {
+		return nil, err
+	}
+	awsConfigPath := ""
+	if node.IsIAMRolesAnywhere() {
+		awsConfigPath = node.Spec.Hybrid.IAMRolesAnywhere.AwsConfigPath
+	}
+	return S3ClientProvider(provider, awsConfigPath, node.Spec.Cluster.Region), nil
+}
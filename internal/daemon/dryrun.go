@@ -0,0 +1,67 @@
+package daemon
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+var _ DaemonManager = &dryRunDaemonManager{}
+
+// dryRunDaemonManager logs every operation that would change the state of a daemon
+// without performing it. Status queries are served by the delegate.
+type dryRunDaemonManager struct {
+	delegate DaemonManager
+	logger   *zap.Logger
+}
+
+// NewDryRunDaemonManager returns a DaemonManager that never starts, stops, enables,
+// disables or reloads daemons. Reads are delegated to manager.
+func NewDryRunDaemonManager(manager DaemonManager, logger *zap.Logger) DaemonManager {
+	return &dryRunDaemonManager{
+		delegate: manager,
+		logger:   logger,
+	}
+}
+
+func (m *dryRunDaemonManager) StartDaemon(name string) error {
+	m.skip("start", name)
+	return nil
+}
+
+func (m *dryRunDaemonManager) StopDaemon(name string) error {
+	m.skip("stop", name)
+	return nil
+}
+
+func (m *dryRunDaemonManager) RestartDaemon(ctx context.Context, name string, opts ...OperationOption) error {
+	m.skip("restart", name)
+	return nil
+}
+
+func (m *dryRunDaemonManager) GetDaemonStatus(name string) (DaemonStatus, error) {
+	return m.delegate.GetDaemonStatus(name)
+}
+
+func (m *dryRunDaemonManager) EnableDaemon(name string) error {
+	m.skip("enable", name)
+	return nil
+}
+
+func (m *dryRunDaemonManager) DisableDaemon(name string) error {
+	m.skip("disable", name)
+	return nil
+}
+
+func (m *dryRunDaemonManager) DaemonReload() error {
+	m.skip("daemon-reload", "")
+	return nil
+}
+
+func (m *dryRunDaemonManager) Close() {
+	m.delegate.Close()
+}
+
+func (m *dryRunDaemonManager) skip(operation, name string) {
+	m.logger.Info("Dry run: skipping daemon operation", zap.String("operation", operation), zap.String("daemon", name))
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 
 	"github.com/coreos/go-systemd/v22/dbus"
+
+	"github.com/aws/eks-hybrid/internal/ledger"
 )
 
 var _ DaemonManager = &systemdDaemonManager{}
@@ -95,10 +97,14 @@ func (m *systemdDaemonManager) EnableDaemon(name string) error {
 	if err != nil {
 		return err
 	}
-	if len(changes) != 0 && changes[0].Type != TypeSymlink {
+	if len(changes) == 0 {
+		// Already enabled, it isn't recorded in the ledger so uninstall doesn't disable it.
+		return nil
+	}
+	if changes[0].Type != TypeSymlink {
 		return fmt.Errorf("unexpected unit file change type: %s", changes[0].Type)
 	}
-	return nil
+	return ledger.RecordUnit(name)
 }
 
 func (m *systemdDaemonManager) DisableDaemon(name string) error {
@@ -0,0 +1,84 @@
+package destination
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/aws/eks-hybrid/internal/oci"
+)
+
+// Destination is where sync-artifacts uploads artifacts to.
+type Destination interface {
+	// Validate checks the destination can be written to before anything is uploaded.
+	Validate(ctx context.Context) error
+
+	// Upload stores content at path, relative to the root of the destination, and returns
+	// the URI nodes download it from.
+	Upload(ctx context.Context, path string, content io.ReadSeeker, size int64) (string, error)
+
+	// Finish is called once every file has been uploaded.
+	Finish(ctx context.Context) error
+}
+
+// Options configures the destinations built by New.
+type Options struct {
+	// BaseURL is the URL a local directory destination is served from. If empty, nodes
+	// download from file:// URIs.
+	BaseURL string
+	// Username and Password authenticate to HTTP and OCI destinations.
+	Username string
+	Password string
+	// PlainHTTP talks to OCI registries over http instead of https.
+	PlainHTTP bool
+	// Tag is the tag of the OCI manifest listing the uploaded artifacts, when the
+	// destination reference doesn't have one.
+	Tag        string
+	HTTPClient *http.Client
+}
+
+// New returns the destination for uri based on its scheme: file:// for a local directory,
+// http:// or https:// for a server accepting PUT requests and oci:// for an OCI registry.
+func New(uri string, opts Options) (Destination, error) {
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	switch {
+	case strings.HasPrefix(uri, "file://"):
+		return &Local{Dir: strings.TrimPrefix(uri, "file://"), BaseURL: opts.BaseURL}, nil
+	case strings.HasPrefix(uri, "http://"), strings.HasPrefix(uri, "https://"):
+		return &HTTP{
+			BaseURL:  uri,
+			Username: opts.Username,
+			Password: opts.Password,
+			Client:   httpClient,
+		}, nil
+	case strings.HasPrefix(uri, oci.Scheme):
+		ref, err := oci.ParseReference(uri)
+		if err != nil {
+			return nil, err
+		}
+		if ref.Digest != "" {
+			return nil, fmt.Errorf("OCI destination %s can't have a digest", uri)
+		}
+		if ref.Tag == "" {
+			ref.Tag = opts.Tag
+		}
+		client := oci.NewClient(ref)
+		client.Username = opts.Username
+		client.Password = opts.Password
+		client.PlainHTTP = opts.PlainHTTP
+		client.HTTPClient = httpClient
+		return &OCI{Client: client}, nil
+	default:
+		return nil, fmt.Errorf("unsupported destination %s. Supported schemes: [file, http, https, oci]", uri)
+	}
+}
+
+func joinURL(base, path string) string {
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
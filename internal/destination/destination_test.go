@@ -0,0 +1,167 @@
+package destination_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/eks-hybrid/internal/destination"
+	"github.com/aws/eks-hybrid/internal/oci"
+	"github.com/aws/eks-hybrid/internal/oci/ocitest"
+	"github.com/aws/eks-hybrid/internal/test"
+)
+
+func upload(g *WithT, dest destination.Destination, path string, content []byte) string {
+	uri, err := dest.Upload(context.Background(), path, bytes.NewReader(content), int64(len(content)))
+	g.Expect(err).NotTo(HaveOccurred())
+	return uri
+}
+
+func TestLocal(t *testing.T) {
+	g := NewWithT(t)
+	dir := filepath.Join(t.TempDir(), "mirror")
+	content := []byte("kubelet binary")
+
+	dest, err := destination.New("file://"+dir, destination.Options{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(dest.Validate(context.Background())).To(Succeed())
+
+	uri := upload(g, dest, "1700000000/eks/kubelet", content)
+	g.Expect(uri).To(Equal("file://" + filepath.Join(dir, "1700000000/eks/kubelet")))
+	g.Expect(os.ReadFile(filepath.Join(dir, "1700000000/eks/kubelet"))).To(Equal(content))
+	g.Expect(dest.Finish(context.Background())).To(Succeed())
+
+	_, err = dest.Upload(context.Background(), "../escape", bytes.NewReader(content), int64(len(content)))
+	g.Expect(err).To(MatchError(ContainSubstring("invalid destination path")))
+}
+
+func TestLocalBaseURL(t *testing.T) {
+	g := NewWithT(t)
+	dest, err := destination.New("file://"+t.TempDir(), destination.Options{BaseURL: "https://mirror.example.com/eks/"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	uri := upload(g, dest, "1700000000/eks/kubelet", []byte("kubelet binary"))
+	g.Expect(uri).To(Equal("https://mirror.example.com/eks/1700000000/eks/kubelet"))
+}
+
+// webDAV is a minimal WebDAV stand-in that refuses PUT requests into missing collections.
+type webDAV struct {
+	mu          sync.Mutex
+	collections map[string]bool
+	files       map[string][]byte
+}
+
+func (d *webDAV) serve(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if username, password, _ := r.BasicAuth(); username != "ci" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch r.Method {
+	case http.MethodOptions:
+	case "MKCOL":
+		collection := strings.TrimSuffix(r.URL.Path, "/")
+		if d.collections[collection] {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		d.collections[collection] = true
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodPut:
+		if !d.collections[filepath.Dir(r.URL.Path)] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		d.files[r.URL.Path] = data
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	g := NewWithT(t)
+	dav := &webDAV{collections: map[string]bool{"/dav": true, "/dav/1700000000": true}, files: map[string][]byte{}}
+	server := test.NewHTTPServer(t, dav.serve)
+	content := []byte("kubelet binary")
+
+	dest, err := destination.New(server.URL+"/dav", destination.Options{Username: "ci", Password: "secret"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(dest.Validate(context.Background())).To(Succeed())
+
+	uri := upload(g, dest, "1700000000/eks/kubelet", content)
+	g.Expect(uri).To(Equal(server.URL + "/dav/1700000000/eks/kubelet"))
+	g.Expect(dav.files).To(HaveKeyWithValue("/dav/1700000000/eks/kubelet", content))
+	g.Expect(dav.collections).To(HaveKey("/dav/1700000000/eks"))
+
+	// The collection exists now, so this is a single PUT.
+	upload(g, dest, "1700000000/eks/kubectl", content)
+	g.Expect(dav.files).To(HaveKey("/dav/1700000000/eks/kubectl"))
+}
+
+func TestHTTPAccessDenied(t *testing.T) {
+	g := NewWithT(t)
+	dav := &webDAV{collections: map[string]bool{}, files: map[string][]byte{}}
+	server := test.NewHTTPServer(t, dav.serve)
+
+	dest, err := destination.New(server.URL+"/dav", destination.Options{Username: "ci", Password: "wrong"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(dest.Validate(context.Background())).To(MatchError(ContainSubstring("access denied")))
+}
+
+func TestOCI(t *testing.T) {
+	g := NewWithT(t)
+	registry := ocitest.NewRegistry(t)
+	registry.Username = "robot"
+	registry.Password = "secret"
+	content := []byte("kubelet binary")
+
+	dest, err := destination.New("oci://"+registry.Host()+"/eks/artifacts", destination.Options{
+		Username:  "robot",
+		Password:  "secret",
+		PlainHTTP: true,
+		Tag:       "1.31.2-linux-amd64-1700000000",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(dest.Validate(context.Background())).To(Succeed())
+
+	uri := upload(g, dest, "1700000000/eks/kubelet", content)
+	g.Expect(uri).To(Equal("oci://" + registry.Host() + "/eks/artifacts@" + oci.Digest(content)))
+	blob, ok := registry.Blob("eks/artifacts", oci.Digest(content))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(blob).To(Equal(content))
+
+	// Identical content is only pushed once.
+	uploads := registry.Uploads()
+	upload(g, dest, "1700000000/eks/kubelet.copy", content)
+	g.Expect(registry.Uploads()).To(Equal(uploads))
+
+	g.Expect(dest.Finish(context.Background())).To(Succeed())
+	data, ok := registry.Manifest("eks/artifacts", "1.31.2-linux-amd64-1700000000")
+	g.Expect(ok).To(BeTrue())
+	var manifest oci.Manifest
+	g.Expect(json.Unmarshal(data, &manifest)).To(Succeed())
+	g.Expect(manifest.ArtifactType).To(Equal(destination.ArtifactType))
+	g.Expect(manifest.Layers).To(HaveLen(2))
+	g.Expect(manifest.Layers[0].Annotations).To(HaveKeyWithValue(oci.AnnotationTitle, "1700000000/eks/kubelet"))
+}
+
+func TestNewErrors(t *testing.T) {
+	g := NewWithT(t)
+	_, err := destination.New("ftp://mirror.example.com", destination.Options{})
+	g.Expect(err).To(MatchError(ContainSubstring("unsupported destination")))
+
+	_, err = destination.New("oci://registry.example.com/artifacts@sha256:"+strings.Repeat("a", 64), destination.Options{})
+	g.Expect(err).To(MatchError(ContainSubstring("can't have a digest")))
+}
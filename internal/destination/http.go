@@ -0,0 +1,111 @@
+package destination
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// HTTP uploads artifacts with PUT requests, as accepted by WebDAV servers and artifact
+// repositories such as Artifactory and Nexus raw repositories.
+type HTTP struct {
+	BaseURL  string
+	Username string
+	Password string
+	Client   *http.Client
+}
+
+func (h *HTTP) Validate(ctx context.Context) error {
+	req, err := h.newRequest(ctx, http.MethodOptions, h.BaseURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("reaching destination %s: %w", h.BaseURL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("access denied to destination %s - check the destination credentials", h.BaseURL)
+	}
+	return nil
+}
+
+func (h *HTTP) Upload(ctx context.Context, path string, content io.ReadSeeker, size int64) (string, error) {
+	target := joinURL(h.BaseURL, path)
+	status, err := h.put(ctx, target, content, size)
+	if err != nil {
+		return "", err
+	}
+	// WebDAV servers refuse a PUT into a missing collection, create the parents and retry.
+	if status == http.StatusConflict {
+		if err := h.makeCollections(ctx, path); err != nil {
+			return "", err
+		}
+		if status, err = h.put(ctx, target, content, size); err != nil {
+			return "", err
+		}
+	}
+	if status != http.StatusOK && status != http.StatusCreated && status != http.StatusNoContent {
+		return "", fmt.Errorf("uploading %s: unexpected status code %d", target, status)
+	}
+	return target, nil
+}
+
+func (h *HTTP) Finish(ctx context.Context) error {
+	return nil
+}
+
+func (h *HTTP) put(ctx context.Context, target string, content io.ReadSeeker, size int64) (int, error) {
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	req, err := h.newRequest(ctx, http.MethodPut, target, io.NopCloser(content))
+	if err != nil {
+		return 0, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("uploading %s: %w", target, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// makeCollections creates every parent collection of path with MKCOL. Collections that
+// already exist are answered with 405 Method Not Allowed, which is ignored.
+func (h *HTTP) makeCollections(ctx context.Context, path string) error {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	collection := strings.TrimSuffix(h.BaseURL, "/")
+	for _, part := range parts[:len(parts)-1] {
+		collection += "/" + part
+		req, err := h.newRequest(ctx, "MKCOL", collection+"/", nil)
+		if err != nil {
+			return err
+		}
+		resp, err := h.Client.Do(req)
+		if err != nil {
+			return fmt.Errorf("creating collection %s: %w", collection, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+			return fmt.Errorf("creating collection %s: unexpected status code %d", collection, resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+func (h *HTTP) newRequest(ctx context.Context, method, target string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if h.Username != "" {
+		req.SetBasicAuth(h.Username, h.Password)
+	}
+	return req, nil
+}
@@ -0,0 +1,76 @@
+package destination
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Local writes artifacts to a directory tree, for example one served by a web server.
+type Local struct {
+	Dir string
+	// BaseURL is the URL Dir is served from. If empty, file:// URIs are returned.
+	BaseURL string
+}
+
+func (l *Local) Validate(ctx context.Context) error {
+	if err := os.MkdirAll(l.Dir, 0o755); err != nil {
+		return fmt.Errorf("creating destination directory: %w", err)
+	}
+	probe, err := os.CreateTemp(l.Dir, ".nodeadm-")
+	if err != nil {
+		return fmt.Errorf("destination directory %s isn't writable: %w", l.Dir, err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+func (l *Local) Upload(ctx context.Context, path string, content io.ReadSeeker, size int64) (string, error) {
+	rel := filepath.FromSlash(path)
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("invalid destination path %s", path)
+	}
+	dst := filepath.Join(l.Dir, rel)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", err
+	}
+
+	// Write to a temporary file first so a failed sync never leaves a partial artifact.
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+"-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(tmp, content); err != nil {
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return "", err
+	}
+
+	if l.BaseURL != "" {
+		return joinURL(l.BaseURL, path), nil
+	}
+	absolute, err := filepath.Abs(dst)
+	if err != nil {
+		return "", err
+	}
+	return "file://" + absolute, nil
+}
+
+func (l *Local) Finish(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,84 @@
+package destination
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/aws/eks-hybrid/internal/oci"
+)
+
+// ArtifactType is the artifact type of the OCI manifest listing the synced artifacts.
+const ArtifactType = "application/vnd.amazon.eks.hybrid.artifacts.v1"
+
+// emptyConfig is the config blob of artifact manifests.
+var emptyConfig = []byte("{}")
+
+// OCI pushes artifacts as blobs to a repository of an OCI registry. Once every artifact is
+// uploaded, a manifest listing them is pushed under the reference tag so the registry
+// doesn't garbage collect them.
+type OCI struct {
+	Client *oci.Client
+
+	layers []oci.Descriptor
+}
+
+func (o *OCI) Validate(ctx context.Context) error {
+	if o.Client.Reference.Tag == "" {
+		return fmt.Errorf("OCI destination %s needs a tag", o.Client.Reference)
+	}
+	// Pushing the empty config checks the repository is reachable and writable.
+	return o.push(ctx, oci.Digest(emptyConfig), int64(len(emptyConfig)), bytes.NewReader(emptyConfig))
+}
+
+func (o *OCI) Upload(ctx context.Context, path string, content io.ReadSeeker, size int64) (string, error) {
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	digest := "sha256:" + hex.EncodeToString(hash.Sum(nil))
+
+	if err := o.push(ctx, digest, size, content); err != nil {
+		return "", fmt.Errorf("pushing %s: %w", path, err)
+	}
+	o.layers = append(o.layers, oci.Descriptor{
+		MediaType:   "application/octet-stream",
+		Digest:      digest,
+		Size:        size,
+		Annotations: map[string]string{oci.AnnotationTitle: path},
+	})
+	return o.Client.Reference.WithDigest(digest).String(), nil
+}
+
+func (o *OCI) Finish(ctx context.Context) error {
+	_, err := o.Client.PushManifest(ctx, o.Client.Reference.Tag, oci.Manifest{
+		SchemaVersion: 2,
+		MediaType:     oci.MediaTypeImageManifest,
+		ArtifactType:  ArtifactType,
+		Config: oci.Descriptor{
+			MediaType: oci.MediaTypeEmptyJSON,
+			Digest:    oci.Digest(emptyConfig),
+			Size:      int64(len(emptyConfig)),
+		},
+		Layers: o.layers,
+	})
+	return err
+}
+
+// push uploads a blob unless the repository already has it.
+func (o *OCI) push(ctx context.Context, digest string, size int64, content io.ReadSeeker) error {
+	exists, err := o.Client.BlobExists(ctx, digest)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	return o.Client.PushBlob(ctx, digest, size, content)
+}
@@ -0,0 +1,69 @@
+package destination
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	awsSDKv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/pkg/errors"
+)
+
+// S3 uploads artifacts to a bucket, under a key prefix.
+type S3 struct {
+	Client    *s3.Client
+	Bucket    string
+	Prefix    string
+	Region    string
+	DnsSuffix string
+	// Private uploads without the public-read ACL and returns s3:// URIs, which nodes read
+	// with their own credentials. Otherwise artifacts are public and https:// URLs are returned.
+	Private bool
+}
+
+// Validate verifies that the bucket exists and is accessible.
+func (d *S3) Validate(ctx context.Context) error {
+	_, err := d.Client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: awsSDKv2.String(d.Bucket),
+	})
+	if err != nil {
+		// Check for specific error types to provide better error messages
+		if strings.Contains(err.Error(), "NotFound") || strings.Contains(err.Error(), "NoSuchBucket") || strings.Contains(err.Error(), "MovedPermanently") {
+			return fmt.Errorf("bucket '%s' does not exist", d.Bucket)
+		}
+		if strings.Contains(err.Error(), "Forbidden") || strings.Contains(err.Error(), "AccessDenied") {
+			return fmt.Errorf("access denied to bucket '%s' - check your AWS credentials and bucket permissions", d.Bucket)
+		}
+	}
+	return nil
+}
+
+func (d *S3) Upload(ctx context.Context, path string, content io.ReadSeeker, size int64) (string, error) {
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	key := strings.TrimSuffix(d.Prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+	input := &s3.PutObjectInput{
+		Bucket: awsSDKv2.String(d.Bucket),
+		Key:    awsSDKv2.String(key),
+		Body:   content,
+	}
+	if !d.Private {
+		input.ACL = types.ObjectCannedACLPublicRead
+	}
+	if _, err := manager.NewUploader(d.Client).Upload(ctx, input); err != nil {
+		return "", errors.Wrap(err, "uploading to S3 using manager")
+	}
+	if d.Private {
+		return fmt.Sprintf("s3://%s/%s", d.Bucket, key), nil
+	}
+	return fmt.Sprintf("https://%s.s3.%s.%s/%s", d.Bucket, d.Region, d.DnsSuffix, key), nil
+}
+
+func (d *S3) Finish(ctx context.Context) error {
+	return nil
+}
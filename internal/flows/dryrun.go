@@ -0,0 +1,130 @@
+package flows
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/configenricher"
+	"github.com/aws/eks-hybrid/internal/nodeprovider"
+)
+
+// DryRunner renders every file init would write to the host into a scratch directory and
+// prints a unified diff against the live files. It never starts, stops or reloads daemons.
+// util.SetDryRunRoot must be called with RootDir before building the NodeProvider.
+type DryRunner struct {
+	NodeProvider     nodeprovider.NodeProvider
+	ManifestOverride string
+	// ManifestOptions configures how the release manifest signature is verified.
+	ManifestOptions []aws.ManifestOption
+	RootDir         string
+	Output          io.Writer
+	Logger          *zap.Logger
+}
+
+func (d *DryRunner) Run(ctx context.Context) error {
+	d.NodeProvider.PopulateNodeConfigDefaults()
+
+	if err := d.NodeProvider.ValidateConfig(); err != nil {
+		return err
+	}
+
+	d.Logger.Info("Configuring Aws...")
+	if err := d.NodeProvider.ConfigureAws(ctx); err != nil {
+		return err
+	}
+
+	regionConfig, err := getRegionConfig(ctx, d.NodeProvider.GetNodeConfig().Spec.Cluster.Region, d.ManifestOverride, d.ManifestOptions, d.Logger)
+	if err != nil {
+		return err
+	}
+	if err := d.NodeProvider.Enrich(ctx, configenricher.WithRegionConfig(regionConfig)); err != nil {
+		return err
+	}
+
+	for _, aspect := range d.NodeProvider.GetAspects() {
+		d.Logger.Info("Rendering system aspect...", zap.String("name", aspect.Name()))
+		if err := aspect.Setup(); err != nil {
+			return fmt.Errorf("rendering system aspect %s: %w", aspect.Name(), err)
+		}
+	}
+
+	daemons, err := d.NodeProvider.GetDaemons()
+	if err != nil {
+		return err
+	}
+	for _, daemon := range daemons {
+		d.Logger.Info("Rendering daemon configuration...", zap.String("name", daemon.Name()))
+		if err := daemon.Configure(ctx); err != nil {
+			return fmt.Errorf("rendering daemon %s configuration: %w", daemon.Name(), err)
+		}
+	}
+
+	if err := diffRenderedFiles(d.Output, d.RootDir, "/"); err != nil {
+		return err
+	}
+
+	return d.NodeProvider.Cleanup()
+}
+
+// diffRenderedFiles writes a unified diff between every file under renderedRoot and the
+// file at the same relative path under liveRoot.
+func diffRenderedFiles(w io.Writer, renderedRoot, liveRoot string) error {
+	changed := 0
+	err := filepath.WalkDir(renderedRoot, func(renderedPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(renderedRoot, renderedPath)
+		if err != nil {
+			return err
+		}
+		livePath := filepath.Join(liveRoot, relPath)
+
+		rendered, err := os.ReadFile(renderedPath)
+		if err != nil {
+			return err
+		}
+		live, err := os.ReadFile(livePath)
+		fromFile := livePath
+		if os.IsNotExist(err) {
+			fromFile = "/dev/null"
+		} else if err != nil {
+			return err
+		}
+
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(live)),
+			B:        difflib.SplitLines(string(rendered)),
+			FromFile: fromFile,
+			ToFile:   livePath,
+			Context:  3,
+		})
+		if err != nil {
+			return fmt.Errorf("diffing %s: %w", livePath, err)
+		}
+		if diff == "" {
+			return nil
+		}
+		changed++
+		_, err = io.WriteString(w, diff)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if changed == 0 {
+		_, err = fmt.Fprintln(w, "No changes to host files")
+	}
+	return err
+}
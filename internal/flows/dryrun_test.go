@@ -0,0 +1,137 @@
+package flows
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/api"
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/configenricher"
+	"github.com/aws/eks-hybrid/internal/daemon"
+	"github.com/aws/eks-hybrid/internal/nodeprovider"
+	"github.com/aws/eks-hybrid/internal/system"
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+func TestDryRunnerWritesOnlyUnderRoot(t *testing.T) {
+	g := NewWithT(t)
+	rootDir := t.TempDir()
+	hostDir := t.TempDir()
+	t.Cleanup(util.SetDryRunRoot(rootDir))
+
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	writeFile(g, manifestPath, "region_config:\n  us-west-2:\n    partition: aws\n")
+
+	aspectFile := filepath.Join(hostDir, "aspect.conf")
+	daemonFile := filepath.Join(hostDir, "daemon", "config.yaml")
+	cfg := &api.NodeConfig{}
+	cfg.Spec.Cluster.Region = "us-west-2"
+	provider := &fakeNodeProvider{
+		nodeConfig: cfg,
+		aspects:    []system.SystemAspect{&fileAspect{path: aspectFile}},
+		daemons:    []daemon.Daemon{&fileDaemon{path: daemonFile}},
+	}
+
+	var out bytes.Buffer
+	runner := &DryRunner{
+		NodeProvider:     provider,
+		ManifestOverride: "file://" + manifestPath,
+		ManifestOptions:  []aws.ManifestOption{aws.WithInsecureSkipVerify()},
+		RootDir:          rootDir,
+		Output:           &out,
+		Logger:           zap.NewNop(),
+	}
+	g.Expect(runner.Run(context.Background())).To(Succeed())
+
+	g.Expect(aspectFile).NotTo(BeAnExistingFile())
+	g.Expect(daemonFile).NotTo(BeAnExistingFile())
+	g.Expect(filepath.Join(rootDir, aspectFile)).To(BeAnExistingFile())
+	g.Expect(filepath.Join(rootDir, daemonFile)).To(BeAnExistingFile())
+	g.Expect(out.String()).To(ContainSubstring("+aspect\n"))
+	g.Expect(out.String()).To(ContainSubstring("+daemon\n"))
+}
+
+func TestDiffRenderedFiles(t *testing.T) {
+	g := NewWithT(t)
+	renderedRoot := t.TempDir()
+	liveRoot := t.TempDir()
+
+	writeFile(g, filepath.Join(liveRoot, "etc/sysctl.d/99-nodeadm.conf"), "a = 1\nb = 2\n")
+	writeFile(g, filepath.Join(renderedRoot, "etc/sysctl.d/99-nodeadm.conf"), "a = 1\nb = 3\n")
+	writeFile(g, filepath.Join(liveRoot, "etc/containerd/config.toml"), "version = 2\n")
+	writeFile(g, filepath.Join(renderedRoot, "etc/containerd/config.toml"), "version = 2\n")
+	writeFile(g, filepath.Join(renderedRoot, "etc/kubernetes/pki/ca.crt"), "ca\n")
+
+	var out bytes.Buffer
+	g.Expect(diffRenderedFiles(&out, renderedRoot, liveRoot)).To(Succeed())
+
+	diff := out.String()
+	g.Expect(diff).To(ContainSubstring("-b = 2\n+b = 3\n"))
+	g.Expect(diff).To(ContainSubstring("--- /dev/null"))
+	g.Expect(diff).To(ContainSubstring("+ca\n"))
+	g.Expect(diff).NotTo(ContainSubstring("config.toml"))
+}
+
+func TestDiffRenderedFilesNoChanges(t *testing.T) {
+	g := NewWithT(t)
+	renderedRoot := t.TempDir()
+	liveRoot := t.TempDir()
+
+	writeFile(g, filepath.Join(liveRoot, "etc/containerd/config.toml"), "version = 2\n")
+	writeFile(g, filepath.Join(renderedRoot, "etc/containerd/config.toml"), "version = 2\n")
+
+	var out bytes.Buffer
+	g.Expect(diffRenderedFiles(&out, renderedRoot, liveRoot)).To(Succeed())
+	g.Expect(out.String()).To(Equal("No changes to host files\n"))
+}
+
+func writeFile(g *WithT, path, content string) {
+	g.Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+	g.Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+}
+
+// fakeNodeProvider renders the given aspects and daemons without touching AWS.
+type fakeNodeProvider struct {
+	nodeprovider.NodeProvider
+	nodeConfig *api.NodeConfig
+	aspects    []system.SystemAspect
+	daemons    []daemon.Daemon
+}
+
+func (f *fakeNodeProvider) GetNodeConfig() *api.NodeConfig       { return f.nodeConfig }
+func (f *fakeNodeProvider) PopulateNodeConfigDefaults()          {}
+func (f *fakeNodeProvider) ValidateConfig() error                { return nil }
+func (f *fakeNodeProvider) ConfigureAws(context.Context) error   { return nil }
+func (f *fakeNodeProvider) GetAspects() []system.SystemAspect    { return f.aspects }
+func (f *fakeNodeProvider) GetDaemons() ([]daemon.Daemon, error) { return f.daemons, nil }
+func (f *fakeNodeProvider) Cleanup() error                       { return nil }
+func (f *fakeNodeProvider) Enrich(context.Context, ...configenricher.ConfigEnricherOption) error {
+	return nil
+}
+
+type fileAspect struct {
+	path string
+}
+
+func (a *fileAspect) Name() string { return "file" }
+
+func (a *fileAspect) Setup() error {
+	return util.WriteFileWithDir(a.path, []byte("aspect\n"), 0o644)
+}
+
+type fileDaemon struct {
+	daemon.Daemon
+	path string
+}
+
+func (d *fileDaemon) Name() string { return "file" }
+
+func (d *fileDaemon) Configure(context.Context) error {
+	return util.WriteFileWithDir(d.path, []byte("daemon\n"), 0o644)
+}
@@ -2,12 +2,14 @@ package flows
 
 import (
 	"context"
+	"errors"
 
 	"go.uber.org/zap"
 	"k8s.io/utils/strings/slices"
 
 	"github.com/aws/eks-hybrid/internal/aws"
 	"github.com/aws/eks-hybrid/internal/configenricher"
+	"github.com/aws/eks-hybrid/internal/journal"
 	"github.com/aws/eks-hybrid/internal/nodeprovider"
 )
 
@@ -22,44 +24,49 @@ type Initer struct {
 	SkipPhases       []string
 	Logger           *zap.Logger
 	ManifestOverride string
-	PrivateMode      bool
+	// ManifestOptions configures how the release manifest signature is verified.
+	ManifestOptions []aws.ManifestOption
+	PrivateMode     bool
+	// Journal records the progress of init. Steps that mutate the host and are already
+	// completed in the journal are skipped, which allows resuming an interrupted init.
+	// If nil, nothing is recorded.
+	Journal *journal.Journal
 }
 
 func (i *Initer) Run(ctx context.Context) error {
-	i.NodeProvider.PopulateNodeConfigDefaults()
+	// Steps that only build in-memory state are always run, even when resuming,
+	// since later steps depend on the state they produce.
+	if err := i.Journal.Record("defaults", func() error {
+		i.NodeProvider.PopulateNodeConfigDefaults()
+		return nil
+	}); err != nil {
+		return err
+	}
 
-	if err := i.NodeProvider.ValidateConfig(); err != nil {
+	if err := i.Journal.Record("validate-config", i.NodeProvider.ValidateConfig); err != nil {
 		return err
 	}
 
 	i.Logger.Info("Configuring Aws...")
-	if err := i.NodeProvider.ConfigureAws(ctx); err != nil {
+	if err := i.Journal.Record("configure-aws", func() error {
+		return i.NodeProvider.ConfigureAws(ctx)
+	}); err != nil {
 		return err
 	}
 
-	var regionConfig *aws.RegionData
-	var err error
-
-	// Get region config for ECR registry lookup
-	region := i.NodeProvider.GetNodeConfig().Spec.Cluster.Region
-	// Use manifest override if provided, otherwise use default AWS source
-	if i.ManifestOverride != "" {
-		regionConfig, err = aws.GetRegionConfigFromManifest(ctx, region, i.ManifestOverride)
+	if err := i.Journal.Record("enrich", func() error {
+		regionConfig, err := getRegionConfig(ctx, i.NodeProvider.GetNodeConfig().Spec.Cluster.Region, i.ManifestOverride, i.ManifestOptions, i.Logger)
 		if err != nil {
-			i.Logger.Warn("Failed to get region config from manifest", zap.Error(err))
-		}
-	} else {
-		regionConfig, err = aws.GetRegionConfig(ctx, region)
-		if err != nil {
-			i.Logger.Warn("Failed to get region config", zap.Error(err))
+			return err
 		}
-	}
-
-	if err := i.NodeProvider.Enrich(ctx, configenricher.WithRegionConfig(regionConfig)); err != nil {
+		return i.NodeProvider.Enrich(ctx, configenricher.WithRegionConfig(regionConfig))
+	}); err != nil {
 		return err
 	}
 
-	if err := i.NodeProvider.Validate(ctx); err != nil {
+	if err := i.Journal.Record("validate", func() error {
+		return i.NodeProvider.Validate(ctx)
+	}); err != nil {
 		return err
 	}
 
@@ -67,24 +74,62 @@ func (i *Initer) Run(ctx context.Context) error {
 	i.Logger.Info("Setting up system aspects...")
 	for _, aspect := range aspects {
 		nameField := zap.String("name", aspect.Name())
-		i.Logger.Info("Setting up system aspect...", nameField)
-		if err := aspect.Setup(); err != nil {
+		if err := runStep(i.Journal, "aspect/"+aspect.Name(), i.Logger, func() error {
+			i.Logger.Info("Setting up system aspect...", nameField)
+			if err := aspect.Setup(); err != nil {
+				return err
+			}
+			i.Logger.Info("Finished setting up system aspect", nameField)
+			return nil
+		}); err != nil {
 			return err
 		}
-		i.Logger.Info("Finished setting up system aspect", nameField)
 	}
 
-	if err := initDaemons(ctx, i.NodeProvider, i.SkipPhases, i.Logger); err != nil {
+	if err := initDaemons(ctx, i.NodeProvider, i.SkipPhases, i.Journal, i.Logger); err != nil {
 		return err
 	}
 
-	return i.NodeProvider.Cleanup()
+	return i.Journal.Record("cleanup", i.NodeProvider.Cleanup)
+}
+
+// getRegionConfig gets the region config used for ECR registry lookup. Failures are only
+// logged since enrichment can fall back to defaults.
+func getRegionConfig(ctx context.Context, region, manifestOverride string, manifestOpts []aws.ManifestOption, logger *zap.Logger) (*aws.RegionData, error) {
+	// Use manifest override if provided, otherwise use default AWS source
+	var regionConfig *aws.RegionData
+	var err error
+	if manifestOverride != "" {
+		regionConfig, err = aws.GetRegionConfigFromManifest(ctx, region, manifestOverride, manifestOpts...)
+	} else {
+		regionConfig, err = aws.GetRegionConfig(ctx, region, manifestOpts...)
+	}
+	// A manifest that can't be read is not fatal, the region config has defaults. One
+	// that fails signature verification can't be trusted for anything.
+	var signatureErr *aws.SignatureError
+	if errors.As(err, &signatureErr) {
+		return nil, err
+	} else if err != nil {
+		logger.Warn("Failed to get region config from manifest", zap.Error(err))
+	}
+	return regionConfig, nil
+}
+
+// runStep runs fn as the journal step name, unless the journal shows it already completed.
+func runStep(j *journal.Journal, name string, logger *zap.Logger, fn func() error) error {
+	if j.Completed(name) {
+		logger.Info("Skipping step completed in a previous run", zap.String("step", name))
+		return nil
+	}
+	return j.Record(name, fn)
 }
 
-func initDaemons(ctx context.Context, nodeProvider nodeprovider.NodeProvider, skipPhases []string, logger *zap.Logger) error {
+func initDaemons(ctx context.Context, nodeProvider nodeprovider.NodeProvider, skipPhases []string, j *journal.Journal, logger *zap.Logger) error {
 	if !slices.Contains(skipPhases, preprocessPhase) {
-		logger.Info("Configuring Pre-process daemons...")
-		if err := nodeProvider.PreProcessDaemon(ctx); err != nil {
+		if err := runStep(j, preprocessPhase, logger, func() error {
+			logger.Info("Configuring Pre-process daemons...")
+			return nodeProvider.PreProcessDaemon(ctx)
+		}); err != nil {
 			return err
 		}
 	}
@@ -98,11 +143,16 @@ func initDaemons(ctx context.Context, nodeProvider nodeprovider.NodeProvider, sk
 		for _, daemon := range daemons {
 			nameField := zap.String("name", daemon.Name())
 
-			logger.Info("Configuring daemon...", nameField)
-			if err := daemon.Configure(ctx); err != nil {
+			if err := runStep(j, "daemon/"+daemon.Name()+"/configure", logger, func() error {
+				logger.Info("Configuring daemon...", nameField)
+				if err := daemon.Configure(ctx); err != nil {
+					return err
+				}
+				logger.Info("Configured daemon", nameField)
+				return nil
+			}); err != nil {
 				return err
 			}
-			logger.Info("Configured daemon", nameField)
 		}
 	}
 
@@ -110,17 +160,27 @@ func initDaemons(ctx context.Context, nodeProvider nodeprovider.NodeProvider, sk
 		for _, daemon := range daemons {
 			nameField := zap.String("name", daemon.Name())
 
-			logger.Info("Ensuring daemon is running...", nameField)
-			if err := daemon.EnsureRunning(ctx); err != nil {
+			if err := runStep(j, "daemon/"+daemon.Name()+"/ensure-running", logger, func() error {
+				logger.Info("Ensuring daemon is running...", nameField)
+				if err := daemon.EnsureRunning(ctx); err != nil {
+					return err
+				}
+				logger.Info("Daemon is running", nameField)
+				return nil
+			}); err != nil {
 				return err
 			}
-			logger.Info("Daemon is running", nameField)
 
-			logger.Info("Running post-launch tasks...", nameField)
-			if err := daemon.PostLaunch(); err != nil {
+			if err := runStep(j, "daemon/"+daemon.Name()+"/post-launch", logger, func() error {
+				logger.Info("Running post-launch tasks...", nameField)
+				if err := daemon.PostLaunch(); err != nil {
+					return err
+				}
+				logger.Info("Finished post-launch tasks", nameField)
+				return nil
+			}); err != nil {
 				return err
 			}
-			logger.Info("Finished post-launch tasks", nameField)
 		}
 	}
 	return nil
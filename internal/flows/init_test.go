@@ -0,0 +1,35 @@
+package flows
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/aws"
+)
+
+func TestGetRegionConfig(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	writeFile(g, manifestPath, "region_config:\n  us-west-2:\n    partition: aws\n")
+	noKeys := []aws.ManifestOption{aws.WithTrustedKeysDir(t.TempDir())}
+
+	// An unsigned override can't be trusted, init must not go on without it.
+	_, err := getRegionConfig(ctx, "us-west-2", "file://"+manifestPath, noKeys, zap.NewNop())
+	var signatureErr *aws.SignatureError
+	g.Expect(errors.As(err, &signatureErr)).To(BeTrue())
+
+	// A manifest that can't be read only loses the region config.
+	regionConfig, err := getRegionConfig(ctx, "us-west-2", "file://"+manifestPath+".missing", noKeys, zap.NewNop())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(regionConfig).To(BeNil())
+
+	regionConfig, err = getRegionConfig(ctx, "us-west-2", "file://"+manifestPath, []aws.ManifestOption{aws.WithInsecureSkipVerify()}, zap.NewNop())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(regionConfig.Partition).To(Equal("aws"))
+}
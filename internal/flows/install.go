@@ -30,6 +30,11 @@ type Installer struct {
 	Tracker            *tracker.Tracker
 	Logger             *zap.Logger
 	PrivateMode        bool
+	// DownloadParallelism is the maximum number of artifacts downloaded at the same time.
+	// Defaults to aws.DefaultDownloadParallelism.
+	DownloadParallelism int
+
+	staged *aws.StagedSource
 }
 
 func (i *Installer) Run(ctx context.Context) error {
@@ -39,6 +44,13 @@ func (i *Installer) Run(ctx context.Context) error {
 		return err
 	}
 
+	// All artifacts are downloaded and verified before anything is installed.
+	i.staged, err = i.AwsSource.Stage(ctx, aws.StagingDir, releaseArtifactNames(i.CredentialProvider), i.DownloadParallelism, i.Logger)
+	if err != nil {
+		return fmt.Errorf("downloading artifacts: %w", err)
+	}
+	defer i.staged.Remove()
+
 	if i.PrivateMode {
 		i.Logger.Info("Private mode: Skipping OS package installation")
 		i.Logger.Info("Installing credential processes and EKS artifacts from manifest...")
@@ -53,7 +65,7 @@ func (i *Installer) Run(ctx context.Context) error {
 		}
 
 		i.Logger.Info("Private mode install completed")
-		return i.Tracker.Save()
+		return i.saveTracker()
 	}
 
 	// Normal installation flow
@@ -77,6 +89,13 @@ func (i *Installer) Run(ctx context.Context) error {
 	}
 
 	i.Logger.Info("Finishing up install...")
+	return i.saveTracker()
+}
+
+func (i *Installer) saveTracker() error {
+	if err := recordArtifacts(i.Tracker, i.AwsSource); err != nil {
+		return err
+	}
 	return i.Tracker.Save()
 }
 
@@ -102,7 +121,7 @@ func (i *Installer) installCredentialProcess(ctx context.Context) error {
 		i.Logger.Info("Installing AWS signing helper...")
 		if err := iamrolesanywhere.Install(ctx, iamrolesanywhere.InstallOptions{
 			Tracker: i.Tracker,
-			Source:  i.AwsSource,
+			Source:  i.staged,
 			Logger:  i.Logger,
 		}); err != nil {
 			return err
@@ -133,7 +152,7 @@ func (i *Installer) installEksArtifacts(ctx context.Context) error {
 	i.Logger.Info("Installing kubelet...")
 	if err := kubelet.Install(ctx, kubelet.InstallOptions{
 		Tracker: i.Tracker,
-		Source:  i.AwsSource,
+		Source:  i.staged,
 		Logger:  i.Logger,
 	}); err != nil {
 		return err
@@ -142,7 +161,7 @@ func (i *Installer) installEksArtifacts(ctx context.Context) error {
 	i.Logger.Info("Installing kubectl...")
 	if err := kubectl.Install(ctx, kubectl.InstallOptions{
 		Tracker: i.Tracker,
-		Source:  i.AwsSource,
+		Source:  i.staged,
 		Logger:  i.Logger,
 	}); err != nil {
 		return err
@@ -151,7 +170,7 @@ func (i *Installer) installEksArtifacts(ctx context.Context) error {
 	i.Logger.Info("Installing cni-plugins...")
 	if err := cni.Install(ctx, cni.InstallOptions{
 		Tracker: i.Tracker,
-		Source:  i.AwsSource,
+		Source:  i.staged,
 		Logger:  i.Logger,
 	}); err != nil {
 		return err
@@ -160,7 +179,7 @@ func (i *Installer) installEksArtifacts(ctx context.Context) error {
 	i.Logger.Info("Installing image credential provider...")
 	if err := imagecredentialprovider.Install(ctx, imagecredentialprovider.InstallOptions{
 		Tracker: i.Tracker,
-		Source:  i.AwsSource,
+		Source:  i.staged,
 		Logger:  i.Logger,
 	}); err != nil {
 		return err
@@ -169,7 +188,7 @@ func (i *Installer) installEksArtifacts(ctx context.Context) error {
 	i.Logger.Info("Installing AWS IAM authenticator...")
 	return iamauthenticator.Install(ctx, iamauthenticator.InstallOptions{
 		Tracker: i.Tracker,
-		Source:  i.AwsSource,
+		Source:  i.staged,
 		Logger:  i.Logger,
 	})
 }
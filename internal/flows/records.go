@@ -0,0 +1,94 @@
+package flows
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/aws/eks-hybrid/internal/artifact"
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/cni"
+	"github.com/aws/eks-hybrid/internal/creds"
+	"github.com/aws/eks-hybrid/internal/iamauthenticator"
+	"github.com/aws/eks-hybrid/internal/iamrolesanywhere"
+	"github.com/aws/eks-hybrid/internal/imagecredentialprovider"
+	"github.com/aws/eks-hybrid/internal/kubectl"
+	"github.com/aws/eks-hybrid/internal/kubelet"
+	"github.com/aws/eks-hybrid/internal/tracker"
+)
+
+// manifestArtifact is an artifact nodeadm downloads from the release manifest.
+type manifestArtifact struct {
+	// name is the name of the artifact in the tracker.
+	name string
+	// manifestName is the name of the artifact in the release manifest.
+	manifestName string
+	// path is where the downloaded artifact is stored.
+	path string
+	// archive is true for artifacts that are extracted and removed after download.
+	// Their checksums are recorded by the installer.
+	archive bool
+}
+
+var manifestArtifacts = []manifestArtifact{
+	{name: artifact.Kubelet, manifestName: "kubelet", path: kubelet.BinPath},
+	{name: artifact.Kubectl, manifestName: "kubectl", path: kubectl.BinPath},
+	{name: artifact.CniPlugins, manifestName: "cni-plugins", path: cni.BinPath, archive: true},
+	{name: artifact.ImageCredentialProvider, manifestName: "ecr-credential-provider", path: imagecredentialprovider.BinPath},
+	{name: artifact.IamAuthenticator, manifestName: "aws-iam-authenticator", path: iamauthenticator.IAMAuthenticatorBinPath},
+	{name: artifact.IamRolesAnywhere, manifestName: "aws_signing_helper", path: iamrolesanywhere.SigningHelperBinPath},
+}
+
+// releaseArtifactNames returns the manifest names of the artifacts installed from the
+// release for the given credential provider.
+func releaseArtifactNames(credentialProvider creds.CredentialProvider) []string {
+	var names []string
+	for _, a := range manifestArtifacts {
+		if a.name == artifact.IamRolesAnywhere && credentialProvider != creds.IamRolesAnywhereCredentialProvider {
+			continue
+		}
+		names = append(names, a.manifestName)
+	}
+	return names
+}
+
+// recordArtifacts stores in the tracker the manifest the artifacts were installed from,
+// the component pins and the version, URI and checksum of each installed artifact.
+func recordArtifacts(tr *tracker.Tracker, source aws.Source) error {
+	tr.Manifest = &tracker.Manifest{
+		URI:               source.Manifest.URI,
+		Sha256:            source.Manifest.Sha256,
+		KubernetesVersion: source.Eks.Version,
+	}
+	tr.Pins = source.Pins
+
+	now := time.Now().UTC()
+	for _, a := range manifestArtifacts {
+		if !tr.Artifacts.IsInstalled(a.name) {
+			continue
+		}
+		releaseArtifact, version, ok := source.LookupArtifact(a.manifestName)
+		if !ok {
+			continue
+		}
+		if a.archive {
+			if record, ok := tr.Records[a.name]; ok {
+				record.Version = version
+				record.URI = releaseArtifact.DownloadURI()
+			}
+			continue
+		}
+		checksum, err := artifact.FileSha256(a.path)
+		if err != nil {
+			return fmt.Errorf("recording %s in tracker: %w", a.name, err)
+		}
+		tr.Record(a.name, tracker.ArtifactRecord{
+			Version:     version,
+			URI:         releaseArtifact.DownloadURI(),
+			Sha256:      hex.EncodeToString(checksum),
+			Path:        a.path,
+			InstalledAt: now,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package flows
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/cni"
+	"github.com/aws/eks-hybrid/internal/containerd"
+	"github.com/aws/eks-hybrid/internal/daemon"
+	"github.com/aws/eks-hybrid/internal/iamauthenticator"
+	"github.com/aws/eks-hybrid/internal/iamrolesanywhere"
+	"github.com/aws/eks-hybrid/internal/imagecredentialprovider"
+	"github.com/aws/eks-hybrid/internal/kubectl"
+	"github.com/aws/eks-hybrid/internal/kubelet"
+	"github.com/aws/eks-hybrid/internal/ledger"
+	"github.com/aws/eks-hybrid/internal/snapshot"
+	"github.com/aws/eks-hybrid/internal/ssm"
+	"github.com/aws/eks-hybrid/internal/tracker"
+	"github.com/aws/eks-hybrid/internal/util/file"
+)
+
+// upgradeSnapshotPaths returns the binaries, rendered configs and tracker state that
+// upgrade can modify and that need to be restored on rollback. The containerd and runc
+// binaries are included when upgrade upgrades their packages.
+func upgradeSnapshotPaths(nodeConfigAwsConfigPath string, containerdUpgraded bool) []string {
+	paths := []string{
+		kubelet.BinPath,
+		kubectl.BinPath,
+		cni.BinPath,
+		imagecredentialprovider.BinPath,
+		iamauthenticator.IAMAuthenticatorBinPath,
+		iamrolesanywhere.SigningHelperBinPath,
+		iamrolesanywhere.SigningHelperServiceFilePath,
+		tracker.FilePath,
+		ledger.FilePath,
+	}
+	paths = append(paths, kubelet.ConfigPaths()...)
+	paths = append(paths, containerd.ConfigPaths()...)
+	if containerdUpgraded {
+		paths = append(paths, containerd.BinaryPaths()...)
+	}
+	if nodeConfigAwsConfigPath != "" {
+		paths = append(paths, nodeConfigAwsConfigPath)
+	}
+	return paths
+}
+
+// rollbackDaemons returns the daemons restarted after a rollback: containerd, kubelet and
+// the daemons of the credential provider in the restored tracker.
+func rollbackDaemons(artifacts *tracker.InstalledArtifacts) []string {
+	names := []string{containerd.ContainerdDaemonName, kubelet.KubeletDaemonName}
+	if artifacts.Ssm {
+		names = append(names, ssm.DaemonName())
+	}
+	// The signing helper daemon only exists when the credentials file is enabled.
+	if artifacts.IamRolesAnywhere && file.Exists(iamrolesanywhere.SigningHelperServiceFilePath) {
+		names = append(names, iamrolesanywhere.DaemonName)
+	}
+	return names
+}
+
+// Rollbacker restores the snapshot taken before an upgrade and restarts the node daemons
+// so they pick up the previous binaries and configuration.
+type Rollbacker struct {
+	DaemonManager daemon.DaemonManager
+	Logger        *zap.Logger
+}
+
+func (r *Rollbacker) Run(ctx context.Context, snap *snapshot.Snapshot) error {
+	r.Logger.Info("Restoring snapshot...", zap.Time("createdAt", snap.CreatedAt), zap.String("description", snap.Description))
+	if err := snap.Restore(); err != nil {
+		return fmt.Errorf("restoring snapshot: %w", err)
+	}
+	// The changes recorded during the upgrade were just undone.
+	if err := ledger.Reload(); err != nil {
+		return fmt.Errorf("reloading install ledger: %w", err)
+	}
+
+	if err := r.DaemonManager.DaemonReload(); err != nil {
+		return fmt.Errorf("reloading systemd daemons: %w", err)
+	}
+
+	restored, err := tracker.GetCurrentState()
+	if err != nil {
+		return fmt.Errorf("reading restored tracker: %w", err)
+	}
+	for _, name := range rollbackDaemons(restored.Artifacts) {
+		r.Logger.Info("Restarting daemon...", zap.String("name", name))
+		if err := r.DaemonManager.RestartDaemon(ctx, name); err != nil {
+			return fmt.Errorf("restarting %s: %w", name, err)
+		}
+	}
+
+	r.Logger.Info("Rollback completed")
+	return nil
+}
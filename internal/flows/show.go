@@ -0,0 +1,69 @@
+package flows
+
+import (
+	"context"
+	"regexp"
+
+	"go.uber.org/zap"
+
+	"github.com/aws/eks-hybrid/internal/api"
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/configenricher"
+	"github.com/aws/eks-hybrid/internal/configprovider"
+	"github.com/aws/eks-hybrid/internal/nodeprovider"
+)
+
+// RedactedValue replaces the secrets of a node config shown to users.
+const RedactedValue = "<redacted>"
+
+// containerdSecretPattern matches the registry credentials of a containerd config.
+var containerdSecretPattern = regexp.MustCompile(`(?m)^(\s*(?:password|auth|identitytoken|registrytoken)\s*=\s*)(?:"[^"\n]*"|'[^'\n]*')`)
+
+// ConfigResolver runs the defaulting and enrichment steps of init to resolve the node
+// config init would use. It renders nothing, so util.SetDryRunRoot must be called with a
+// scratch directory before building the NodeProvider, as for DryRunner.
+type ConfigResolver struct {
+	NodeProvider     nodeprovider.NodeProvider
+	ManifestOverride string
+	// ManifestOptions configures how the release manifest signature is verified.
+	ManifestOptions []aws.ManifestOption
+	// Offline skips the steps reading AWS credentials or calling AWS, so the config is only
+	// resolved from its sources and the defaults.
+	Offline bool
+	Logger  *zap.Logger
+}
+
+func (r *ConfigResolver) Run(ctx context.Context) (*api.NodeConfig, error) {
+	r.NodeProvider.PopulateNodeConfigDefaults()
+
+	if err := r.NodeProvider.ValidateConfig(); err != nil {
+		return nil, err
+	}
+
+	if r.Offline {
+		r.Logger.Info("Offline: skipping AWS configuration and enrichment")
+		return r.NodeProvider.GetNodeConfig(), nil
+	}
+
+	r.Logger.Info("Configuring Aws...")
+	if err := r.NodeProvider.ConfigureAws(ctx); err != nil {
+		return nil, err
+	}
+
+	regionConfig, err := getRegionConfig(ctx, r.NodeProvider.GetNodeConfig().Spec.Cluster.Region, r.ManifestOverride, r.ManifestOptions, r.Logger)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.NodeProvider.Enrich(ctx, configenricher.WithRegionConfig(regionConfig)); err != nil {
+		return nil, err
+	}
+	return r.NodeProvider.GetNodeConfig(), nil
+}
+
+// RedactSecrets replaces the secrets of nodeConfig with RedactedValue: the fields that can
+// be set to secret references, see configprovider.RedactSecrets, and the registry
+// credentials of the containerd config.
+func RedactSecrets(nodeConfig *api.NodeConfig) {
+	configprovider.RedactSecrets(nodeConfig, RedactedValue)
+	nodeConfig.Spec.Containerd.Config = containerdSecretPattern.ReplaceAllString(nodeConfig.Spec.Containerd.Config, `${1}"`+RedactedValue+`"`)
+}
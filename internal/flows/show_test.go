@@ -0,0 +1,59 @@
+package flows
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/eks-hybrid/internal/api"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	g := NewWithT(t)
+	nodeConfig := &api.NodeConfig{
+		Spec: api.NodeConfigSpec{
+			Containerd: api.ContainerdOptions{
+				Config: `[plugins."io.containerd.grpc.v1.cri".registry.configs."registry.example.com".auth]
+  username = "puller"
+  password = "hunter2"
+  auth = 'cHVsbGVyOmh1bnRlcjI='
+  identitytoken = ""
+`,
+			},
+			Hybrid: &api.HybridOptions{
+				SSM: &api.SSM{
+					ActivationCode: "ssm-activation-code-secret",
+					ActivationID:   "e488f2f6-e686-4afb-8a04-ef6dfabcdeff",
+				},
+				IAMRolesAnywhere: &api.IAMRolesAnywhere{
+					NodeName:        "my-node",
+					CertificatePath: "/etc/iam/pki/server.pem",
+					PrivateKeyPath:  "secretsmanager://node-key",
+				},
+			},
+		},
+	}
+
+	RedactSecrets(nodeConfig)
+
+	g.Expect(nodeConfig.Spec.Hybrid.SSM.ActivationCode).To(Equal(RedactedValue))
+	g.Expect(nodeConfig.Spec.Hybrid.SSM.ActivationID).To(Equal(RedactedValue))
+	g.Expect(nodeConfig.Spec.Hybrid.IAMRolesAnywhere.CertificatePath).To(Equal("/etc/iam/pki/server.pem"), "paths are shown")
+	g.Expect(nodeConfig.Spec.Hybrid.IAMRolesAnywhere.PrivateKeyPath).To(Equal("secretsmanager://node-key"), "secret references are shown")
+	g.Expect(nodeConfig.Spec.Hybrid.IAMRolesAnywhere.NodeName).To(Equal("my-node"))
+	g.Expect(nodeConfig.Spec.Containerd.Config).To(Equal(`[plugins."io.containerd.grpc.v1.cri".registry.configs."registry.example.com".auth]
+  username = "puller"
+  password = "<redacted>"
+  auth = "<redacted>"
+  identitytoken = "<redacted>"
+`))
+}
+
+func TestRedactSecretsWithoutSecrets(t *testing.T) {
+	g := NewWithT(t)
+	nodeConfig := &api.NodeConfig{Spec: api.NodeConfigSpec{Cluster: api.ClusterDetails{Name: "my-cluster"}}}
+
+	RedactSecrets(nodeConfig)
+
+	g.Expect(nodeConfig).To(Equal(&api.NodeConfig{Spec: api.NodeConfigSpec{Cluster: api.ClusterDetails{Name: "my-cluster"}}}))
+}
@@ -2,8 +2,10 @@ package flows
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws/retry"
@@ -19,6 +21,7 @@ import (
 	"github.com/aws/eks-hybrid/internal/iptables"
 	"github.com/aws/eks-hybrid/internal/kubectl"
 	"github.com/aws/eks-hybrid/internal/kubelet"
+	"github.com/aws/eks-hybrid/internal/ledger"
 	"github.com/aws/eks-hybrid/internal/packagemanager"
 	"github.com/aws/eks-hybrid/internal/ssm"
 	"github.com/aws/eks-hybrid/internal/tracker"
@@ -31,7 +34,10 @@ type (
 )
 
 type Uninstaller struct {
-	Artifacts      *tracker.InstalledArtifacts
+	Artifacts *tracker.InstalledArtifacts
+	// Ledger has the host changes recorded by install, init and upgrade. They are undone
+	// in reverse during cleanup. The nodeadm config directory is removed either way.
+	Ledger         *ledger.Ledger
 	DaemonManager  daemon.DaemonManager
 	PackageManager *packagemanager.DistroPackageManager
 	Logger         *zap.Logger
@@ -39,6 +45,12 @@ type Uninstaller struct {
 }
 
 func (u *Uninstaller) Run(ctx context.Context) error {
+	// Changes are reported before any component is uninstalled since component
+	// uninstalls remove some of the recorded files.
+	if err := u.reportDrift(); err != nil {
+		return err
+	}
+
 	if err := u.uninstallDaemons(ctx); err != nil {
 		return err
 	}
@@ -167,9 +179,33 @@ func (u *Uninstaller) cleanup() error {
 		return err
 	}
 
-	if err := os.RemoveAll(eksConfigDir); err != nil {
-		return err
+	var replayErr error
+	if u.Ledger != nil {
+		u.Logger.Info("Undoing host changes recorded in the install ledger...")
+		var kept []ledger.Drift
+		kept, replayErr = u.Ledger.Replay(u.DaemonManager)
+		for _, drift := range kept {
+			if strings.HasPrefix(drift.Path, eksConfigDir+"/") {
+				continue
+			}
+			u.Logger.Warn("Leaving path in place", zap.String("kind", string(drift.Kind)), zap.String("path", drift.Path), zap.String("reason", drift.Reason))
+		}
 	}
+	// The nodeadm config directory is owned by nodeadm as a whole, it can have files written
+	// before the ledger existed.
+	return errors.Join(replayErr, os.RemoveAll(eksConfigDir))
+}
 
+func (u *Uninstaller) reportDrift() error {
+	if u.Ledger == nil {
+		return nil
+	}
+	drift, err := u.Ledger.Check()
+	if err != nil {
+		return fmt.Errorf("checking install ledger: %w", err)
+	}
+	for _, d := range drift {
+		u.Logger.Warn("Path changed by hand since nodeadm wrote it", zap.String("kind", string(d.Kind)), zap.String("path", d.Path), zap.String("change", d.Reason))
+	}
 	return nil
 }
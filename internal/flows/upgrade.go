@@ -1,13 +1,16 @@
 package flows
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"slices"
+	"time"
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
+	"github.com/aws/eks-hybrid/internal/artifact"
 	"github.com/aws/eks-hybrid/internal/aws"
 	"github.com/aws/eks-hybrid/internal/cni"
 	"github.com/aws/eks-hybrid/internal/configenricher"
@@ -20,27 +23,187 @@ import (
 	"github.com/aws/eks-hybrid/internal/iptables"
 	"github.com/aws/eks-hybrid/internal/kubectl"
 	"github.com/aws/eks-hybrid/internal/kubelet"
+	"github.com/aws/eks-hybrid/internal/node"
 	"github.com/aws/eks-hybrid/internal/nodeprovider"
+	"github.com/aws/eks-hybrid/internal/nodevalidator"
 	"github.com/aws/eks-hybrid/internal/packagemanager"
+	"github.com/aws/eks-hybrid/internal/snapshot"
 	"github.com/aws/eks-hybrid/internal/ssm"
 	"github.com/aws/eks-hybrid/internal/tracker"
+	"github.com/aws/eks-hybrid/internal/validation"
 )
 
-const containerdMajorVersionUpgrade = "containerd-major-version-upgrade"
+const (
+	containerdMajorVersionUpgrade = "containerd-major-version-upgrade"
+	// ReadinessValidationPhase waits for the node to be Ready after the upgrade
+	// and rolls back when it isn't.
+	ReadinessValidationPhase = "readiness-validation"
+	// RollbackPhase restores the snapshot taken before the upgrade when it fails.
+	RollbackPhase = "rollback"
+)
+
+// rollbackTimeout bounds the rollback run after a failed upgrade.
+var rollbackTimeout = 5 * time.Minute
 
 type Upgrader struct {
 	NodeProvider       nodeprovider.NodeProvider
 	AwsSource          aws.Source
 	PackageManager     *packagemanager.DistroPackageManager
 	CredentialProvider creds.CredentialProvider
-	Artifacts          *tracker.InstalledArtifacts
+	Tracker            *tracker.Tracker
 	DaemonManager      daemon.DaemonManager
 	SkipPhases         []string
 	Logger             *zap.Logger
 	PrivateMode        bool
+	// SnapshotDir is where the state previous to the upgrade is stored.
+	// Defaults to snapshot.UpgradeDir.
+	SnapshotDir string
+	// ReadinessTimeout is how long to wait for the node to be Ready after the upgrade.
+	ReadinessTimeout time.Duration
+	// Drainer is set to drain the node once the artifacts are downloaded and the snapshot
+	// is taken, right before the upgrade. The node is uncordoned once it passes the readiness
+	// checks or, if the upgrade fails, once it is rolled back. It is left cordoned if the
+	// upgrade fails and isn't rolled back.
+	Drainer *node.Drainer
+	// DownloadParallelism is the maximum number of artifacts downloaded at the same time.
+	// Defaults to aws.DefaultDownloadParallelism.
+	DownloadParallelism int
+
+	staged *aws.StagedSource
+	// artifacts defaults to manifestArtifacts.
+	artifacts []manifestArtifact
 }
 
+// Run upgrades the node components. The binaries, rendered configs and tracker state are
+// snapshotted first and restored if the upgrade fails or the node doesn't become Ready.
+// Containerd is upgraded through the package manager, only its binaries are rolled back.
 func (u *Upgrader) Run(ctx context.Context) error {
+	snapshotDir := u.SnapshotDir
+	if snapshotDir == "" {
+		snapshotDir = snapshot.UpgradeDir
+	}
+
+	// All artifacts due for an upgrade are downloaded and verified before anything is
+	// upgraded, and the others are kept as installed, so nothing is downloaded afterwards.
+	outdated, current, err := u.outdatedArtifactNames(ctx)
+	if err != nil {
+		return err
+	}
+	u.staged, err = u.AwsSource.Stage(ctx, aws.StagingDir, outdated, u.DownloadParallelism, u.Logger)
+	if err != nil {
+		return fmt.Errorf("downloading artifacts: %w", err)
+	}
+	defer u.staged.Remove()
+	for name, checksum := range current {
+		u.staged.Keep(name, checksum)
+	}
+
+	awsConfigPath := ""
+	if nodeConfig := u.NodeProvider.GetNodeConfig(); nodeConfig.IsIAMRolesAnywhere() {
+		awsConfigPath = nodeConfig.Spec.Hybrid.IAMRolesAnywhere.AwsConfigPath
+	}
+	u.Logger.Info("Taking snapshot of installed components...", zap.String("dir", snapshotDir))
+	snap, err := snapshot.Take(snapshotDir, fmt.Sprintf("before upgrade to Kubernetes %s", u.AwsSource.Eks.Version), upgradeSnapshotPaths(awsConfigPath, u.upgradesContainerd()))
+	if err != nil {
+		return fmt.Errorf("taking snapshot before upgrade: %w", err)
+	}
+
+	if u.Drainer != nil {
+		if err := u.Drainer.Drain(ctx); err != nil {
+			// Nothing was upgraded yet, the node can run workloads again.
+			if uncordonErr := u.Drainer.Uncordon(context.WithoutCancel(ctx)); uncordonErr != nil {
+				return fmt.Errorf("draining node: %w; %w", err, uncordonErr)
+			}
+			return fmt.Errorf("draining node: %w", err)
+		}
+	}
+
+	err = u.upgrade(ctx)
+	if err == nil {
+		if u.Drainer != nil {
+			return u.Drainer.Uncordon(ctx)
+		}
+		return nil
+	}
+	if slices.Contains(u.SkipPhases, RollbackPhase) {
+		u.leaveCordoned()
+		return err
+	}
+	return u.rollback(ctx, snap, err)
+}
+
+// outdatedArtifactNames returns the manifest names of the release artifacts whose installed
+// checksum doesn't match the manifest. Archives are always reinstalled, so always returned.
+// The checksums of the artifacts that are up to date are returned keyed by manifest name.
+func (u *Upgrader) outdatedArtifactNames(ctx context.Context) ([]string, map[string][]byte, error) {
+	artifacts := u.artifacts
+	if artifacts == nil {
+		artifacts = manifestArtifacts
+	}
+
+	var names []string
+	current := map[string][]byte{}
+	for _, a := range artifacts {
+		if a.name == artifact.IamRolesAnywhere && u.CredentialProvider != creds.IamRolesAnywhereCredentialProvider {
+			continue
+		}
+		if a.archive {
+			names = append(names, a.manifestName)
+			continue
+		}
+		algorithm, expected, err := u.AwsSource.GetArtifactChecksum(ctx, a.manifestName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("getting %s checksum from manifest: %w", a.name, err)
+		}
+		installed, err := artifact.FileDigest(a.path, algorithm)
+		if err == nil && bytes.Equal(installed, expected) {
+			u.Logger.Info("Artifact is up to date, skipping download", zap.String("artifact", a.manifestName))
+			current[a.manifestName] = expected
+			continue
+		}
+		names = append(names, a.manifestName)
+	}
+	return names, current, nil
+}
+
+// upgradesContainerd returns true if the containerd package is upgraded.
+func (u *Upgrader) upgradesContainerd() bool {
+	return !u.PrivateMode && u.Tracker.Artifacts.Containerd != tracker.ContainerdSourceNone
+}
+
+func (u *Upgrader) leaveCordoned() {
+	if u.Drainer != nil {
+		u.Logger.Warn("Leaving node cordoned after failed upgrade", zap.String("node", u.Drainer.NodeName))
+	}
+}
+
+// rollback restores snap after the upgrade failed with upgradeErr.
+func (u *Upgrader) rollback(ctx context.Context, snap *snapshot.Snapshot, upgradeErr error) error {
+	u.Logger.Error("Upgrade failed, rolling back...", zap.Error(upgradeErr))
+	if u.upgradesContainerd() {
+		u.Logger.Warn("Restoring the containerd binaries, the package manager keeps reporting the upgraded version")
+	}
+	rollbacker := &Rollbacker{
+		DaemonManager: u.DaemonManager,
+		Logger:        u.Logger,
+	}
+	// The upgrade may have failed because ctx expired, rollback needs its own deadline.
+	rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+	if err := rollbacker.Run(rollbackCtx, snap); err != nil {
+		u.leaveCordoned()
+		return fmt.Errorf("%w; rolling back upgrade: %w", upgradeErr, err)
+	}
+	// The node is back to the previous version, it can run workloads again.
+	if u.Drainer != nil {
+		if err := u.Drainer.Uncordon(rollbackCtx); err != nil {
+			return fmt.Errorf("upgrade rolled back: %w; %w", upgradeErr, err)
+		}
+	}
+	return fmt.Errorf("upgrade rolled back: %w", upgradeErr)
+}
+
+func (u *Upgrader) upgrade(ctx context.Context) error {
 	if !u.PrivateMode {
 		if err := u.upgradeDistroPackages(ctx); err != nil {
 			return err
@@ -55,6 +218,13 @@ func (u *Upgrader) Run(ctx context.Context) error {
 		return err
 	}
 
+	if err := recordArtifacts(u.Tracker, u.AwsSource); err != nil {
+		return err
+	}
+	if err := u.Tracker.Save(); err != nil {
+		return err
+	}
+
 	if err := u.NodeProvider.ConfigureAws(ctx); err != nil {
 		return err
 	}
@@ -62,11 +232,27 @@ func (u *Upgrader) Run(ctx context.Context) error {
 	if err := u.NodeProvider.Enrich(ctx, configenricher.WithRegionConfig(&u.AwsSource.RegionInfo)); err != nil {
 		return err
 	}
-	if err := initDaemons(ctx, u.NodeProvider, u.SkipPhases, u.Logger); err != nil {
+	if err := initDaemons(ctx, u.NodeProvider, u.SkipPhases, nil, u.Logger); err != nil {
 		return err
 	}
 
-	return u.NodeProvider.Cleanup()
+	if err := u.NodeProvider.Cleanup(); err != nil {
+		return err
+	}
+
+	if slices.Contains(u.SkipPhases, runPhase) || slices.Contains(u.SkipPhases, ReadinessValidationPhase) {
+		return nil
+	}
+	u.Logger.Info("Validating node readiness after upgrade...")
+	validator := nodevalidator.NewActiveNodeValidator(nodevalidator.WithTimeout(u.readinessTimeout()))
+	return validator.Run(ctx, validation.NewLoggerPrinterWithLogger(u.Logger), u.NodeProvider.GetNodeConfig())
+}
+
+func (u *Upgrader) readinessTimeout() time.Duration {
+	if u.ReadinessTimeout == 0 {
+		return 5 * time.Minute
+	}
+	return u.ReadinessTimeout
 }
 
 func (u *Upgrader) upgradeDistroPackages(ctx context.Context) error {
@@ -74,7 +260,7 @@ func (u *Upgrader) upgradeDistroPackages(ctx context.Context) error {
 	if err := u.PackageManager.RefreshMetadataCache(ctx); err != nil {
 		return err
 	}
-	if u.Artifacts.Containerd != tracker.ContainerdSourceNone {
+	if u.upgradesContainerd() {
 		skipContainerdMajorVersionUpgrade := slices.Contains(u.SkipPhases, containerdMajorVersionUpgrade)
 		if skipContainerdMajorVersionUpgrade {
 			u.Logger.Info("Upgrading containerd with major version constraint...")
@@ -87,7 +273,7 @@ func (u *Upgrader) upgradeDistroPackages(ctx context.Context) error {
 		}
 	}
 
-	if u.Artifacts.Iptables {
+	if u.Tracker.Artifacts.Iptables {
 		u.Logger.Info("Upgrading iptables...")
 		if err := iptables.Upgrade(ctx, u.PackageManager); err != nil {
 			return err
@@ -100,7 +286,7 @@ func (u *Upgrader) upgradeCredentialProvider(ctx context.Context) error {
 	switch u.CredentialProvider {
 	case creds.IamRolesAnywhereCredentialProvider:
 		u.Logger.Info("Upgrading AWS signing helper...")
-		if err := iamrolesanywhere.Upgrade(ctx, u.AwsSource, u.Logger); err != nil {
+		if err := iamrolesanywhere.Upgrade(ctx, u.staged, u.Logger); err != nil {
 			return err
 		}
 	case creds.SsmCredentialProvider:
@@ -127,25 +313,25 @@ func (u *Upgrader) upgradeCredentialProvider(ctx context.Context) error {
 
 func (u *Upgrader) upgradeEksArtifacts(ctx context.Context) error {
 	u.Logger.Info("Upgrading kubelet...")
-	if err := kubelet.Upgrade(ctx, u.AwsSource, u.Logger); err != nil {
+	if err := kubelet.Upgrade(ctx, u.staged, u.Logger); err != nil {
 		return errors.Wrap(err, "failed to upgrade kubelet")
 	}
 
 	u.Logger.Info("Upgrading kubectl...")
-	if err := kubectl.Upgrade(ctx, u.AwsSource, u.Logger); err != nil {
+	if err := kubectl.Upgrade(ctx, u.staged, u.Logger); err != nil {
 		return err
 	}
 
 	u.Logger.Info("Upgrading image credential provider...")
-	if err := imagecredentialprovider.Upgrade(ctx, u.AwsSource, u.Logger); err != nil {
+	if err := imagecredentialprovider.Upgrade(ctx, u.staged, u.Logger); err != nil {
 		return err
 	}
 
 	u.Logger.Info("Upgrading IAM authenticator...")
-	if err := iamauthenticator.Upgrade(ctx, u.AwsSource, u.Logger); err != nil {
+	if err := iamauthenticator.Upgrade(ctx, u.staged, u.Logger); err != nil {
 		return err
 	}
 
 	u.Logger.Info("Upgrading cni-plugins...")
-	return cni.Upgrade(ctx, u.AwsSource, u.Logger)
+	return cni.Upgrade(ctx, u.staged, u.Tracker, u.Logger)
 }
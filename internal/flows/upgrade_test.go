@@ -0,0 +1,177 @@
+package flows
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/aws/eks-hybrid/internal/artifact"
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/creds"
+	"github.com/aws/eks-hybrid/internal/daemon"
+	"github.com/aws/eks-hybrid/internal/node"
+	"github.com/aws/eks-hybrid/internal/snapshot"
+	"github.com/aws/eks-hybrid/internal/ssm"
+	"github.com/aws/eks-hybrid/internal/tracker"
+)
+
+func TestUpgraderRollbackTimesOut(t *testing.T) {
+	g := NewWithT(t)
+	previous := rollbackTimeout
+	rollbackTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { rollbackTimeout = previous })
+
+	snap, err := snapshot.Take(filepath.Join(t.TempDir(), "snapshot"), "before upgrade", nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// The upgrade failed because its context was canceled, rollback must still run and
+	// give up once its own deadline expires.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	upgradeErr := errors.New("upgrade failed")
+	u := &Upgrader{
+		DaemonManager: &blockingDaemonManager{},
+		Tracker:       &tracker.Tracker{Artifacts: &tracker.InstalledArtifacts{}},
+		Logger:        zap.NewNop(),
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- u.rollback(ctx, snap, upgradeErr) }()
+	g.Eventually(done, 5*time.Second).Should(Receive(SatisfyAll(
+		MatchError(upgradeErr),
+		MatchError(context.DeadlineExceeded),
+	)))
+}
+
+func TestUpgraderRollbackUncordons(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	snap, err := snapshot.Take(filepath.Join(t.TempDir(), "snapshot"), "before upgrade", nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	client := fake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-node"},
+		Spec:       corev1.NodeSpec{Unschedulable: true},
+	})
+	upgradeErr := errors.New("upgrade failed")
+	u := &Upgrader{
+		DaemonManager: &restartingDaemonManager{},
+		Tracker:       &tracker.Tracker{Artifacts: &tracker.InstalledArtifacts{}},
+		Logger:        zap.NewNop(),
+		Drainer:       &node.Drainer{Client: client, NodeName: "test-node", Logger: zap.NewNop()},
+	}
+	g.Expect(u.rollback(ctx, snap, upgradeErr)).To(MatchError(upgradeErr))
+
+	k8sNode, err := client.CoreV1().Nodes().Get(ctx, "test-node", metav1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(k8sNode.Spec.Unschedulable).To(BeFalse())
+}
+
+func TestUpgraderDownloadFailureKeepsNodeSchedulable(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "test-node"}})
+	u := &Upgrader{
+		AwsSource:          aws.Source{},
+		CredentialProvider: creds.SsmCredentialProvider,
+		Logger:             zap.NewNop(),
+		Drainer:            &node.Drainer{Client: client, NodeName: "test-node", Logger: zap.NewNop()},
+		SnapshotDir:        filepath.Join(t.TempDir(), "snapshot"),
+		artifacts: []manifestArtifact{
+			{name: artifact.Kubelet, manifestName: "kubelet", path: filepath.Join(t.TempDir(), "kubelet")},
+		},
+	}
+	// The release has no kubelet, so the upgrade fails before anything is downloaded.
+	g.Expect(u.Run(ctx)).To(MatchError(ContainSubstring("kubelet")))
+	g.Expect(client.Actions()).To(BeEmpty(), "the node must not be drained before the artifacts are downloaded")
+}
+
+func TestUpgraderOutdatedArtifactNames(t *testing.T) {
+	g := NewWithT(t)
+	root := t.TempDir()
+	kubeletPath := filepath.Join(root, "usr/bin/kubelet")
+	kubectlPath := filepath.Join(root, "usr/local/bin/kubectl")
+	writeFile(g, kubeletPath, "kubelet")
+	writeFile(g, kubectlPath, "old kubectl")
+
+	releaseArtifact := func(name, content string) aws.Artifact {
+		return aws.Artifact{Name: name, Arch: runtime.GOARCH, OS: runtime.GOOS, Sha256: sha256Hex(content)}
+	}
+	u := &Upgrader{
+		AwsSource: aws.Source{Eks: aws.EksPatchRelease{Artifacts: []aws.Artifact{
+			releaseArtifact("kubelet", "kubelet"),
+			releaseArtifact("kubectl", "kubectl"),
+			releaseArtifact("aws-iam-authenticator", "aws-iam-authenticator"),
+			releaseArtifact("cni-plugins", "cni-plugins"),
+		}}},
+		CredentialProvider: creds.SsmCredentialProvider,
+		Logger:             zap.NewNop(),
+		artifacts: []manifestArtifact{
+			{name: artifact.Kubelet, manifestName: "kubelet", path: kubeletPath},
+			{name: artifact.Kubectl, manifestName: "kubectl", path: kubectlPath},
+			{name: artifact.CniPlugins, manifestName: "cni-plugins", path: filepath.Join(root, "opt/cni/bin"), archive: true},
+			{name: artifact.IamAuthenticator, manifestName: "aws-iam-authenticator", path: filepath.Join(root, "usr/local/bin/aws-iam-authenticator")},
+			{name: artifact.IamRolesAnywhere, manifestName: "aws_signing_helper", path: filepath.Join(root, "usr/local/bin/aws_signing_helper")},
+		},
+	}
+
+	names, current, err := u.outdatedArtifactNames(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(names).To(Equal([]string{"kubectl", "cni-plugins", "aws-iam-authenticator"}))
+	kubeletChecksum := sha256.Sum256([]byte("kubelet"))
+	g.Expect(current).To(Equal(map[string][]byte{"kubelet": kubeletChecksum[:]}))
+}
+
+func TestUpgradeSnapshotPathsContainerdBinaries(t *testing.T) {
+	g := NewWithT(t)
+	bin := t.TempDir()
+	versioned := filepath.Join(bin, "containerd-2.0.5")
+	writeFile(g, versioned, "containerd")
+	g.Expect(os.Chmod(versioned, 0o755)).To(Succeed())
+	g.Expect(os.Symlink(versioned, filepath.Join(bin, "containerd"))).To(Succeed())
+	t.Setenv("PATH", bin)
+
+	g.Expect(upgradeSnapshotPaths("", true)).To(ContainElement(versioned))
+	g.Expect(upgradeSnapshotPaths("", false)).NotTo(ContainElement(versioned))
+}
+
+func TestRollbackDaemons(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(rollbackDaemons(&tracker.InstalledArtifacts{Ssm: true})).To(Equal([]string{"containerd", "kubelet", ssm.DaemonName()}))
+	// Without the credentials file there is no signing helper daemon to restart.
+	g.Expect(rollbackDaemons(&tracker.InstalledArtifacts{IamRolesAnywhere: true})).To(Equal([]string{"containerd", "kubelet"}))
+}
+
+// restartingDaemonManager restarts daemons successfully without touching systemd.
+type restartingDaemonManager struct {
+	daemon.DaemonManager
+}
+
+func (m *restartingDaemonManager) DaemonReload() error { return nil }
+
+func (m *restartingDaemonManager) RestartDaemon(context.Context, string, ...daemon.OperationOption) error {
+	return nil
+}
+
+// blockingDaemonManager never finishes restarting a daemon until its context is done.
+type blockingDaemonManager struct {
+	daemon.DaemonManager
+}
+
+func (m *blockingDaemonManager) DaemonReload() error { return nil }
+
+func (m *blockingDaemonManager) RestartDaemon(ctx context.Context, _ string, _ ...daemon.OperationOption) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
@@ -0,0 +1,180 @@
+package flows
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+
+	"github.com/aws/eks-hybrid/internal/artifact"
+	"github.com/aws/eks-hybrid/internal/aws"
+	"github.com/aws/eks-hybrid/internal/tracker"
+)
+
+// VerifyStatus is the result of verifying a file managed by nodeadm.
+type VerifyStatus string
+
+const (
+	// VerifyStatusOK means the file matches the expected checksum.
+	VerifyStatusOK VerifyStatus = "ok"
+	// VerifyStatusModified means the file doesn't match the expected checksum.
+	VerifyStatusModified VerifyStatus = "modified"
+	// VerifyStatusMissing means the artifact is tracked as installed but the file doesn't exist.
+	VerifyStatusMissing VerifyStatus = "missing"
+	// VerifyStatusUnexpected means the file exists but the artifact isn't tracked as installed.
+	VerifyStatusUnexpected VerifyStatus = "unexpected"
+	// VerifyStatusUnverified means there is no checksum to compare the file with, usually
+	// because the artifact was installed before nodeadm recorded checksums in the tracker.
+	VerifyStatusUnverified VerifyStatus = "unverified"
+)
+
+// VerifyResult is the verification of a single file.
+type VerifyResult struct {
+	Artifact string       `json:"artifact"`
+	Path     string       `json:"path"`
+	Status   VerifyStatus `json:"status"`
+	Expected string       `json:"expected,omitempty"`
+	Actual   string       `json:"actual,omitempty"`
+}
+
+// Verifier rehashes the artifacts downloaded by nodeadm and compares them with the
+// checksums recorded in the tracker or, when Source is set, in the release manifest.
+// Files extracted from archives, like the cni-plugins, are always compared with the
+// tracker since the manifest only has the checksum of the archive. Other files in the
+// cni bin directory aren't reported since CNI plugins install their own binaries there.
+type Verifier struct {
+	Tracker *tracker.Tracker
+	Source  *aws.Source
+
+	// artifacts defaults to manifestArtifacts.
+	artifacts []manifestArtifact
+}
+
+func (v *Verifier) Run(ctx context.Context) ([]VerifyResult, error) {
+	artifacts := v.artifacts
+	if artifacts == nil {
+		artifacts = manifestArtifacts
+	}
+
+	var results []VerifyResult
+	for _, a := range artifacts {
+		record := v.Tracker.Records[a.name]
+
+		if !v.Tracker.Artifacts.IsInstalled(a.name) {
+			if a.archive {
+				continue
+			}
+			actual, err := fileChecksum(a.path, artifact.SHA256)
+			if err != nil {
+				return nil, err
+			}
+			if actual != "" {
+				results = append(results, VerifyResult{Artifact: a.name, Path: a.path, Status: VerifyStatusUnexpected, Actual: actual})
+			}
+			continue
+		}
+
+		if a.archive {
+			archiveResults, err := verifyArchive(a, record)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, archiveResults...)
+			continue
+		}
+
+		algorithm, expected, err := v.ExpectedChecksum(ctx, a, record)
+		if err != nil {
+			return nil, err
+		}
+		result, err := verifyFile(a.name, a.path, algorithm, expected)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// ExpectedChecksum returns the hex encoded checksum a file is expected to have and the
+// algorithm to compute it with. The tracker only records sha256 checksums.
+func (v *Verifier) ExpectedChecksum(ctx context.Context, a manifestArtifact, record *tracker.ArtifactRecord) (artifact.Algorithm, string, error) {
+	if v.Source != nil {
+		algorithm, checksum, err := v.Source.GetArtifactChecksum(ctx, a.manifestName)
+		if err != nil {
+			return "", "", fmt.Errorf("getting %s checksum from manifest: %w", a.name, err)
+		}
+		return algorithm, hex.EncodeToString(checksum), nil
+	}
+	if record == nil {
+		return artifact.SHA256, "", nil
+	}
+	return artifact.SHA256, record.Sha256, nil
+}
+
+func verifyArchive(a manifestArtifact, record *tracker.ArtifactRecord) ([]VerifyResult, error) {
+	if record == nil || len(record.Files) == 0 {
+		return []VerifyResult{{Artifact: a.name, Path: a.path, Status: VerifyStatusUnverified}}, nil
+	}
+
+	paths := make([]string, 0, len(record.Files))
+	for path := range record.Files {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	results := make([]VerifyResult, 0, len(paths))
+	for _, path := range paths {
+		result, err := verifyFile(a.name, path, artifact.SHA256, record.Files[path])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func verifyFile(name, path string, algorithm artifact.Algorithm, expected string) (VerifyResult, error) {
+	result := VerifyResult{Artifact: name, Path: path, Expected: expected}
+	actual, err := fileChecksum(path, algorithm)
+	if err != nil {
+		return result, err
+	}
+	result.Actual = actual
+
+	switch {
+	case actual == "":
+		result.Status = VerifyStatusMissing
+	case expected == "":
+		result.Status = VerifyStatusUnverified
+	case actual != expected:
+		result.Status = VerifyStatusModified
+	default:
+		result.Status = VerifyStatusOK
+	}
+	return result, nil
+}
+
+// fileChecksum returns the hex encoded digest of the file at path or an empty string if it doesn't exist.
+func fileChecksum(path string, algorithm artifact.Algorithm) (string, error) {
+	checksum, err := artifact.FileDigest(path, algorithm)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("verifying %s: %w", path, err)
+	}
+	return hex.EncodeToString(checksum), nil
+}
+
+// VerifyFailed returns true if any result reports a modified, missing or unexpected file.
+func VerifyFailed(results []VerifyResult) bool {
+	for _, result := range results {
+		switch result.Status {
+		case VerifyStatusModified, VerifyStatusMissing, VerifyStatusUnexpected:
+			return true
+		}
+	}
+	return false
+}
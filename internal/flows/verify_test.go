@@ -0,0 +1,100 @@
+package flows
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/eks-hybrid/internal/artifact"
+	"github.com/aws/eks-hybrid/internal/tracker"
+)
+
+func TestVerifierRun(t *testing.T) {
+	g := NewWithT(t)
+	root := t.TempDir()
+	kubeletPath := filepath.Join(root, "usr/bin/kubelet")
+	kubectlPath := filepath.Join(root, "usr/local/bin/kubectl")
+	authenticatorPath := filepath.Join(root, "usr/local/bin/aws-iam-authenticator")
+	signingHelperPath := filepath.Join(root, "usr/local/bin/aws_signing_helper")
+	cniBinPath := filepath.Join(root, "opt/cni/bin")
+
+	writeFile(g, kubeletPath, "kubelet")
+	writeFile(g, kubectlPath, "tampered kubectl")
+	writeFile(g, signingHelperPath, "aws_signing_helper")
+	writeFile(g, filepath.Join(cniBinPath, "bridge"), "bridge")
+	writeFile(g, filepath.Join(cniBinPath, "cilium-cni"), "cilium")
+
+	verifier := &Verifier{
+		Tracker: &tracker.Tracker{
+			Artifacts: &tracker.InstalledArtifacts{
+				Kubelet:          true,
+				Kubectl:          true,
+				IamAuthenticator: true,
+				CniPlugins:       true,
+			},
+			Records: map[string]*tracker.ArtifactRecord{
+				artifact.Kubelet:          {Sha256: sha256Hex("kubelet")},
+				artifact.Kubectl:          {Sha256: sha256Hex("kubectl")},
+				artifact.IamAuthenticator: {Sha256: sha256Hex("aws-iam-authenticator")},
+				artifact.CniPlugins: {Files: map[string]string{
+					filepath.Join(cniBinPath, "bridge"):  sha256Hex("bridge"),
+					filepath.Join(cniBinPath, "portmap"): sha256Hex("portmap"),
+				}},
+			},
+		},
+		artifacts: []manifestArtifact{
+			{name: artifact.Kubelet, path: kubeletPath},
+			{name: artifact.Kubectl, path: kubectlPath},
+			{name: artifact.CniPlugins, path: cniBinPath, archive: true},
+			{name: artifact.IamAuthenticator, path: authenticatorPath},
+			{name: artifact.IamRolesAnywhere, path: signingHelperPath},
+		},
+	}
+
+	results, err := verifier.Run(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(results).To(Equal([]VerifyResult{
+		{Artifact: artifact.Kubelet, Path: kubeletPath, Status: VerifyStatusOK, Expected: sha256Hex("kubelet"), Actual: sha256Hex("kubelet")},
+		{Artifact: artifact.Kubectl, Path: kubectlPath, Status: VerifyStatusModified, Expected: sha256Hex("kubectl"), Actual: sha256Hex("tampered kubectl")},
+		{Artifact: artifact.CniPlugins, Path: filepath.Join(cniBinPath, "bridge"), Status: VerifyStatusOK, Expected: sha256Hex("bridge"), Actual: sha256Hex("bridge")},
+		{Artifact: artifact.CniPlugins, Path: filepath.Join(cniBinPath, "portmap"), Status: VerifyStatusMissing, Expected: sha256Hex("portmap")},
+		{Artifact: artifact.IamAuthenticator, Path: authenticatorPath, Status: VerifyStatusMissing, Expected: sha256Hex("aws-iam-authenticator")},
+		{Artifact: artifact.IamRolesAnywhere, Path: signingHelperPath, Status: VerifyStatusUnexpected, Actual: sha256Hex("aws_signing_helper")},
+	}))
+	g.Expect(VerifyFailed(results)).To(BeTrue())
+}
+
+func TestVerifierRunWithoutRecords(t *testing.T) {
+	g := NewWithT(t)
+	root := t.TempDir()
+	kubeletPath := filepath.Join(root, "usr/bin/kubelet")
+	cniBinPath := filepath.Join(root, "opt/cni/bin")
+	writeFile(g, kubeletPath, "kubelet")
+
+	verifier := &Verifier{
+		Tracker: &tracker.Tracker{
+			Artifacts: &tracker.InstalledArtifacts{Kubelet: true, CniPlugins: true},
+		},
+		artifacts: []manifestArtifact{
+			{name: artifact.Kubelet, path: kubeletPath},
+			{name: artifact.CniPlugins, path: cniBinPath, archive: true},
+		},
+	}
+
+	results, err := verifier.Run(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(results).To(Equal([]VerifyResult{
+		{Artifact: artifact.Kubelet, Path: kubeletPath, Status: VerifyStatusUnverified, Actual: sha256Hex("kubelet")},
+		{Artifact: artifact.CniPlugins, Path: cniBinPath, Status: VerifyStatusUnverified},
+	}))
+	g.Expect(VerifyFailed(results)).To(BeFalse())
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
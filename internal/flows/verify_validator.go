@@ -0,0 +1,57 @@
+package flows
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/eks-hybrid/internal/api"
+	"github.com/aws/eks-hybrid/internal/tracker"
+	"github.com/aws/eks-hybrid/internal/validation"
+)
+
+// ArtifactValidator validates in nodeadm debug that the artifacts installed by nodeadm
+// haven't been modified or removed since they were installed.
+type ArtifactValidator struct{}
+
+// NewArtifactValidator creates a new ArtifactValidator
+func NewArtifactValidator() *ArtifactValidator {
+	return &ArtifactValidator{}
+}
+
+// Run compares the installed artifacts with the checksums recorded in the tracker
+func (v *ArtifactValidator) Run(ctx context.Context, informer validation.Informer, _ *api.NodeConfig) error {
+	var err error
+	informer.Starting(ctx, "binary-drift", "Validating installed artifacts haven't been modified")
+	defer func() {
+		informer.Done(ctx, "binary-drift", err)
+	}()
+
+	installed, err := tracker.GetInstalledArtifacts()
+	if err != nil {
+		err = validation.WithRemediation(fmt.Errorf("reading tracker: %w", err),
+			"Ensure nodeadm install completed successfully on this node.")
+		return err
+	}
+
+	verifier := &Verifier{Tracker: installed}
+	results, err := verifier.Run(ctx)
+	if err != nil {
+		return err
+	}
+	if !VerifyFailed(results) {
+		return nil
+	}
+
+	var failed []string
+	for _, result := range results {
+		switch result.Status {
+		case VerifyStatusModified, VerifyStatusMissing, VerifyStatusUnexpected:
+			failed = append(failed, fmt.Sprintf("%s (%s)", result.Path, result.Status))
+		}
+	}
+	err = validation.WithRemediation(fmt.Errorf("installed artifacts don't match the tracker: %s", strings.Join(failed, ", ")),
+		"Run 'nodeadm verify' for details. Reinstall the affected components with 'nodeadm upgrade' "+
+			"or 'nodeadm uninstall' followed by 'nodeadm install'.")
+	return err
+}
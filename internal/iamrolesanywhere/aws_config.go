@@ -9,7 +9,9 @@ import (
 	"path"
 	"text/template"
 
+	"github.com/aws/eks-hybrid/internal/ledger"
 	"github.com/aws/eks-hybrid/internal/network"
+	"github.com/aws/eks-hybrid/internal/util"
 )
 
 const (
@@ -119,13 +121,15 @@ func writeConfigFile(cfg AWSConfig) error {
 		return err
 	}
 
-	if err := os.MkdirAll(path.Dir(cfg.ConfigPath), os.ModeDir); err != nil {
+	configPath := util.HostPath(cfg.ConfigPath)
+	if err := ledger.MkdirAll(path.Dir(configPath), os.ModeDir); err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(cfg.ConfigPath, buf.Bytes(), 0o644); err != nil {
+	preexisting := ledger.Exists(configPath)
+	if err := os.WriteFile(configPath, buf.Bytes(), 0o644); err != nil {
 		return fmt.Errorf("writing AWS config file: %w", err)
 	}
 
-	return nil
+	return ledger.RecordFile(configPath, preexisting)
 }
@@ -0,0 +1,170 @@
+package journal
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+// InitJournalFile is where nodeadm init records its progress. It lives next to the tracker
+// so it is removed together with it on uninstall.
+const InitJournalFile = "/opt/nodeadm/init-journal"
+
+type StepStatus string
+
+const (
+	StepStatusRunning   StepStatus = "running"
+	StepStatusCompleted StepStatus = "completed"
+	StepStatusFailed    StepStatus = "failed"
+)
+
+// Journal records the outcome of each step of a multi-step flow so an interrupted run
+// can be resumed from the last completed step.
+type Journal struct {
+	// ConfigChecksum identifies the node configuration the journal was recorded with.
+	ConfigChecksum string    `json:"configChecksum,omitempty"`
+	StartedAt      time.Time `json:"startedAt"`
+	Steps          []Step    `json:"steps,omitempty"`
+
+	path string
+}
+
+// Step is a single entry in the journal.
+type Step struct {
+	Name       string     `json:"name"`
+	Status     StepStatus `json:"status"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// New creates an empty journal that will be persisted at path.
+func New(path, configChecksum string) *Journal {
+	return &Journal{
+		ConfigChecksum: configChecksum,
+		StartedAt:      time.Now().UTC(),
+		path:           path,
+	}
+}
+
+// Load reads the journal stored at path.
+func Load(path string) (*Journal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var j Journal
+	if err := yaml.Unmarshal(data, &j); err != nil {
+		return nil, errors.Wrap(err, "invalid yaml data in journal")
+	}
+	j.path = path
+	return &j, nil
+}
+
+// Completed returns true if the step has been completed successfully.
+// A nil journal has no completed steps.
+func (j *Journal) Completed(name string) bool {
+	if j == nil {
+		return false
+	}
+	step := j.step(name)
+	return step != nil && step.Status == StepStatusCompleted
+}
+
+// Failed returns the step that failed during the last recorded run, if any.
+func (j *Journal) Failed() *Step {
+	if j == nil {
+		return nil
+	}
+	for i := range j.Steps {
+		if j.Steps[i].Status == StepStatusFailed {
+			return &j.Steps[i]
+		}
+	}
+	return nil
+}
+
+// Record runs fn and records its outcome under name, persisting the journal before and
+// after fn runs. If the journal is nil, fn is run without recording anything.
+func (j *Journal) Record(name string, fn func() error) error {
+	if j == nil {
+		return fn()
+	}
+
+	step := j.step(name)
+	if step == nil {
+		j.Steps = append(j.Steps, Step{Name: name})
+		step = &j.Steps[len(j.Steps)-1]
+	}
+	step.Status = StepStatusRunning
+	step.StartedAt = time.Now().UTC()
+	step.FinishedAt = nil
+	step.Error = ""
+	if err := j.Save(); err != nil {
+		return err
+	}
+
+	runErr := fn()
+
+	finishedAt := time.Now().UTC()
+	step.FinishedAt = &finishedAt
+	if runErr != nil {
+		step.Status = StepStatusFailed
+		step.Error = runErr.Error()
+	} else {
+		step.Status = StepStatusCompleted
+	}
+	if err := j.Save(); err != nil {
+		if runErr != nil {
+			return fmt.Errorf("%w (recording step %s: %v)", runErr, name, err)
+		}
+		return err
+	}
+	return runErr
+}
+
+// Save persists the journal to disk.
+func (j *Journal) Save() error {
+	data, err := yaml.Marshal(j)
+	if err != nil {
+		return err
+	}
+	return util.WriteFileWithDir(j.path, data, 0o644)
+}
+
+// Remove deletes the persisted journal once the flow finished, so a later resume starts
+// from the beginning. A nil journal or a journal that was never saved is a no-op.
+func (j *Journal) Remove() error {
+	if j == nil {
+		return nil
+	}
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (j *Journal) step(name string) *Step {
+	for i := range j.Steps {
+		if j.Steps[i].Name == name {
+			return &j.Steps[i]
+		}
+	}
+	return nil
+}
+
+// Checksum returns a sha256 hex digest of the yaml representation of obj. It is used to
+// detect if the configuration changed between an interrupted run and its resumption.
+func Checksum(obj any) (string, error) {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
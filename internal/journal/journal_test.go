@@ -0,0 +1,75 @@
+package journal_test
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/eks-hybrid/internal/journal"
+)
+
+func TestJournalRecord(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "init-journal")
+
+	j := journal.New(path, "abc")
+	g.Expect(j.Record("first", func() error { return nil })).To(Succeed())
+	g.Expect(j.Record("second", func() error { return errors.New("boom") })).To(MatchError("boom"))
+
+	loaded, err := journal.Load(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(loaded.ConfigChecksum).To(Equal("abc"))
+	g.Expect(loaded.Completed("first")).To(BeTrue())
+	g.Expect(loaded.Completed("second")).To(BeFalse())
+	g.Expect(loaded.Failed()).NotTo(BeNil())
+	g.Expect(loaded.Failed().Name).To(Equal("second"))
+	g.Expect(loaded.Failed().Error).To(Equal("boom"))
+
+	// re-running a failed step replaces its previous outcome
+	g.Expect(loaded.Record("second", func() error { return nil })).To(Succeed())
+	loaded, err = journal.Load(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(loaded.Steps).To(HaveLen(2))
+	g.Expect(loaded.Completed("second")).To(BeTrue())
+	g.Expect(loaded.Failed()).To(BeNil())
+}
+
+func TestJournalNil(t *testing.T) {
+	g := NewWithT(t)
+	var j *journal.Journal
+
+	called := false
+	g.Expect(j.Record("step", func() error { called = true; return nil })).To(Succeed())
+	g.Expect(called).To(BeTrue())
+	g.Expect(j.Completed("step")).To(BeFalse())
+	g.Expect(j.Failed()).To(BeNil())
+}
+
+func TestChecksum(t *testing.T) {
+	g := NewWithT(t)
+
+	a, err := journal.Checksum(map[string]string{"a": "b"})
+	g.Expect(err).NotTo(HaveOccurred())
+	b, err := journal.Checksum(map[string]string{"a": "c"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(a).NotTo(Equal(b))
+}
+
+func TestJournalRemove(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "init-journal")
+
+	j := journal.New(path, "abc")
+	g.Expect(j.Remove()).To(Succeed(), "removing a journal that was never saved")
+	g.Expect(j.Record("first", func() error { return nil })).To(Succeed())
+	g.Expect(j.Remove()).To(Succeed())
+
+	_, err := journal.Load(path)
+	g.Expect(err).To(MatchError(fs.ErrNotExist))
+
+	var nilJournal *journal.Journal
+	g.Expect(nilJournal.Remove()).To(Succeed())
+}
@@ -47,6 +47,9 @@ const (
 
 var nodeNameProviderIdRegexPattern = regexp.MustCompile(`^eks-hybrid:///[^/]+/[^/]+/(.+)$`)
 
+// hostsPath is the hosts file the API server mappings of outposts are appended to.
+var hostsPath = "/etc/hosts"
+
 func (k *kubelet) writeKubeletConfig() error {
 	kubeletVersion, err := GetKubeletVersion()
 	if err != nil {
@@ -203,18 +206,30 @@ func (ksc *kubeletConfig) withOutpostSetup(cfg *api.NodeConfig) error {
 		output := strings.Join(ipHostMappings, "\n") + "\n"
 
 		// append to /etc/hosts file with shuffled mappings of "IP address to API server domain name"
-		f, err := os.OpenFile("/etc/hosts", os.O_APPEND|os.O_WRONLY, kubeletConfigPerm)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		if _, err := f.WriteString(output); err != nil {
-			return err
-		}
+		return appendHosts(output)
 	}
 	return nil
 }
 
+// appendHosts appends the mappings to the hosts file. The file is rewritten through
+// util.WriteFileWithDir so a dry run renders it with the mappings instead of changing it.
+func appendHosts(mappings string) error {
+	path := util.HostPath(hostsPath)
+	if _, err := os.Stat(path); err != nil {
+		// Nothing rendered yet, start from the live file.
+		path = hostsPath
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	hosts, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return util.WriteFileWithDir(hostsPath, append(hosts, mappings...), info.Mode().Perm())
+}
+
 func (ksc *kubeletConfig) withNodeIp(cfg *api.NodeConfig, flags map[string]string) error {
 	nodeIp, err := getNodeIp(context.TODO(), imds.New(imds.Options{}), cfg)
 	if err != nil {
@@ -375,8 +390,10 @@ func (k *kubelet) GenerateKubeletConfig() (*kubeletConfig, error) {
 			kubeletConfig.withResolvConf(system.UbuntuResolvConfPath)
 		}
 	} else {
-		if err := kubeletConfig.withNodeIp(k.nodeConfig, k.flags); err != nil {
-			return nil, err
+		if !k.skipNodeIP {
+			if err := kubeletConfig.withNodeIp(k.nodeConfig, k.flags); err != nil {
+				return nil, err
+			}
 		}
 		kubeletConfig.withCloudProvider(kubeletVersion, k.nodeConfig, k.flags)
 		kubeletConfig.withDefaultReservedResources(k.nodeConfig)
@@ -385,6 +402,23 @@ func (k *kubelet) GenerateKubeletConfig() (*kubeletConfig, error) {
 	return &kubeletConfig, nil
 }
 
+// EffectiveConfig returns the kubelet configuration init generates for cfg, with the
+// user's kubelet config merged over it the way kubelet reads them. The flags aren't part of
+// it, so the node IP isn't read from IMDS and the config can be generated offline.
+func EffectiveConfig(cfg *api.NodeConfig) (map[string]interface{}, error) {
+	k := &kubelet{
+		nodeConfig:  cfg,
+		environment: make(map[string]string),
+		flags:       make(map[string]string),
+		skipNodeIP:  true,
+	}
+	kubeletConfig, err := k.GenerateKubeletConfig()
+	if err != nil {
+		return nil, err
+	}
+	return util.DocumentMerge(kubeletConfig, cfg.Spec.Kubelet.Config, mergo.WithOverride)
+}
+
 // WriteConfig writes the kubelet config to a file.
 // This should only be used for kubelet versions < 1.28.
 func (k *kubelet) writeKubeletConfigToFile() error {
@@ -1,11 +1,15 @@
 package kubelet
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/aws/eks-hybrid/internal/api"
+	"github.com/aws/eks-hybrid/internal/util"
 )
 
 func TestHybridCloudProvider(t *testing.T) {
@@ -69,3 +73,35 @@ func TestResolvConf(t *testing.T) {
 	kubeletConfig.withResolvConf(resolvConfPath)
 	assert.Equal(t, kubeletConfig.ResolvConf, resolvConfPath)
 }
+
+func TestOutpostSetupDryRun(t *testing.T) {
+	hosts := filepath.Join(t.TempDir(), "hosts")
+	assert.NoError(t, os.WriteFile(hosts, []byte("127.0.0.1\tlocalhost\n"), 0o644))
+	previous := hostsPath
+	hostsPath = hosts
+	t.Cleanup(func() { hostsPath = previous })
+	root := t.TempDir()
+	t.Cleanup(util.SetDryRunRoot(root))
+
+	enabled := true
+	nodeConfig := &api.NodeConfig{
+		Spec: api.NodeConfigSpec{
+			Cluster: api.ClusterDetails{
+				ID:                "my-cluster-id",
+				APIServerEndpoint: "https://localhost",
+				EnableOutpost:     &enabled,
+			},
+		},
+	}
+	var kubeletConfig kubeletConfig
+	assert.NoError(t, kubeletConfig.withOutpostSetup(nodeConfig))
+
+	live, err := os.ReadFile(hosts)
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1\tlocalhost\n", string(live), "the live hosts file must not change in a dry run")
+	rendered, err := os.ReadFile(filepath.Join(root, hosts))
+	assert.NoError(t, err)
+	mappings, ok := strings.CutPrefix(string(rendered), "127.0.0.1\tlocalhost\n")
+	assert.True(t, ok, "the rendered hosts file must start with the live one")
+	assert.Contains(t, mappings, "\tlocalhost\n")
+}
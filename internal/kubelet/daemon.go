@@ -11,6 +11,7 @@ import (
 	"github.com/aws/eks-hybrid/internal/api"
 	"github.com/aws/eks-hybrid/internal/daemon"
 	"github.com/aws/eks-hybrid/internal/kubernetes"
+	"github.com/aws/eks-hybrid/internal/util"
 	"github.com/aws/eks-hybrid/internal/validation"
 )
 
@@ -37,6 +38,8 @@ type kubelet struct {
 	credentialProviderAwsConfig CredentialProviderAwsConfig
 	validationRunner            *validation.Runner[*api.NodeConfig]
 	logger                      *zap.Logger
+	// skipNodeIP leaves out the node-ip flag of non-hybrid nodes, which is read from IMDS.
+	skipNodeIP bool
 }
 
 func NewKubeletDaemon(daemonManager daemon.DaemonManager, cfg *api.NodeConfig, awsConfig *aws.Config, credentialProviderAwsConfig CredentialProviderAwsConfig, logger *zap.Logger, skipPhases []string) daemon.Daemon {
@@ -74,7 +77,8 @@ func (k *kubelet) Configure(ctx context.Context) error {
 		return err
 	}
 
-	if k.validationRunner != nil {
+	// A dry run renders files only, it must not reach out to the cluster.
+	if k.validationRunner != nil && !util.DryRun() {
 		k.validationRunner.Register(
 			validation.New(kubernetesAuthenticationValidation, kubernetes.NewAPIServerValidator(New()).MakeAuthenticatedRequest),
 		)
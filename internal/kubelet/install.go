@@ -101,6 +101,19 @@ func installSystemdUnit(unitPath string) error {
 	return nil
 }
 
+// ConfigPaths returns the files and directories nodeadm writes to configure kubelet.
+func ConfigPaths() []string {
+	return []string{
+		UnitPath,
+		kubeletConfigRoot,
+		caCertificatePath,
+		kubeconfigPath,
+		kubeconfigBootstrapPath,
+		kubeletEnvironmentFilePath,
+		imageCredentialProviderConfigPath,
+	}
+}
+
 type UninstallOptions struct {
 	// InstallRoot is optionally the root directory of the installation
 	// If not provided, the default will be /
@@ -0,0 +1,431 @@
+package ledger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// FilePath is where the install ledger is stored. It lives next to the tracker so it is
+// removed together with it on uninstall.
+const FilePath = "/opt/nodeadm/ledger"
+
+// stateDir holds nodeadm's own state. It is removed as a whole on uninstall, so changes
+// under it aren't recorded.
+const stateDir = "/opt/nodeadm"
+
+type EntryKind string
+
+const (
+	EntryKindFile      EntryKind = "file"
+	EntryKindDirectory EntryKind = "directory"
+	EntryKindSymlink   EntryKind = "symlink"
+	EntryKindUnit      EntryKind = "unit"
+	EntryKindLine      EntryKind = "line"
+)
+
+// Ledger records every change nodeadm makes to the host, in the order they were made,
+// so uninstall can undo them in reverse.
+type Ledger struct {
+	Entries []Entry `json:"entries,omitempty"`
+
+	path string
+}
+
+// Entry is a single host change.
+type Entry struct {
+	Kind EntryKind `json:"kind"`
+	// Path is the file, directory or symlink changed or, for units, the daemon name.
+	Path string `json:"path"`
+	// Line is the line appended to the preexisting file at Path.
+	Line string `json:"line,omitempty"`
+	// Sha256 is the hex encoded checksum of a file when nodeadm last wrote it.
+	Sha256 string `json:"sha256,omitempty"`
+	// Target is where a symlink points to.
+	Target string `json:"target,omitempty"`
+	// Preexisting is true when the file existed before nodeadm first wrote it.
+	// Uninstall leaves it in place since it isn't owned by nodeadm.
+	Preexisting bool      `json:"preexisting,omitempty"`
+	RecordedAt  time.Time `json:"recordedAt"`
+}
+
+// Drift is a recorded path that doesn't match what nodeadm wrote.
+type Drift struct {
+	Kind   EntryKind
+	Path   string
+	Reason string
+}
+
+// Open reads the ledger stored at path, returning an empty ledger if it doesn't exist.
+func Open(path string) (*Ledger, error) {
+	l, err := Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Ledger{path: path}, nil
+	}
+	return l, err
+}
+
+// Load reads the ledger stored at path.
+func Load(path string) (*Ledger, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var l Ledger
+	if err := yaml.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("invalid yaml data in ledger: %w", err)
+	}
+	l.path = path
+	return &l, nil
+}
+
+// Save writes the ledger to the path it was opened from.
+// It doesn't go through the util helpers so the write isn't redirected in dry-run mode
+// nor recorded in the ledger itself.
+func (l *Ledger) Save() error {
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(l.path, data, 0o644)
+}
+
+func (l *Ledger) record(entry Entry) {
+	if strings.HasPrefix(entry.Path, stateDir+"/") {
+		return
+	}
+	entry.RecordedAt = time.Now().UTC()
+	for i := range l.Entries {
+		existing := &l.Entries[i]
+		if existing.sameChange(entry) {
+			// Keep the original position so replaying in reverse still removes the
+			// content of a directory before the directory itself.
+			entry.Preexisting = existing.Preexisting
+			*existing = entry
+			return
+		}
+	}
+	l.Entries = append(l.Entries, entry)
+}
+
+// sameChange returns true if both entries record a change to the same thing. Files,
+// directories and symlinks share a path namespace, units and appended lines don't.
+func (e Entry) sameChange(other Entry) bool {
+	if e.Path != other.Path {
+		return false
+	}
+	switch {
+	case e.Kind == EntryKindUnit || other.Kind == EntryKindUnit:
+		return e.Kind == other.Kind
+	case e.Kind == EntryKindLine || other.Kind == EntryKindLine:
+		return e.Kind == other.Kind && e.Line == other.Line
+	}
+	return true
+}
+
+// Check returns the recorded files and symlinks that have been modified or removed
+// since nodeadm wrote them.
+func (l *Ledger) Check() ([]Drift, error) {
+	var drift []Drift
+	for _, entry := range l.Entries {
+		reason, err := entry.drift()
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			drift = append(drift, Drift{Kind: entry.Kind, Path: entry.Path, Reason: reason})
+		}
+	}
+	return drift, nil
+}
+
+func (e Entry) drift() (string, error) {
+	switch e.Kind {
+	case EntryKindFile:
+		checksum, err := fileSha256(e.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return "removed", nil
+		} else if err != nil {
+			return "", err
+		}
+		if checksum != e.Sha256 {
+			return "modified", nil
+		}
+	case EntryKindSymlink:
+		target, err := os.Readlink(e.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return "removed", nil
+		} else if err != nil {
+			return "", err
+		}
+		if target != e.Target {
+			return fmt.Sprintf("points to %s instead of %s", target, e.Target), nil
+		}
+	case EntryKindLine:
+		data, err := os.ReadFile(e.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return "removed", nil
+		} else if err != nil {
+			return "", err
+		}
+		if !slices.Contains(strings.Split(string(data), "\n"), e.Line) {
+			return "removed", nil
+		}
+	}
+	return "", nil
+}
+
+// UnitDisabler disables systemd units.
+type UnitDisabler interface {
+	DisableDaemon(name string) error
+}
+
+// Replay undoes the recorded changes in reverse order. Files and symlinks are only removed
+// if they still match what nodeadm wrote and directories only if they are empty, so content
+// changed or added by hand is kept. Lines appended to preexisting files are removed from
+// them, leaving the rest of the file untouched. The kept paths are returned.
+// Units that can't be disabled are also returned, since they may have been removed already.
+func (l *Ledger) Replay(units UnitDisabler) ([]Drift, error) {
+	var kept []Drift
+	var errs []error
+	for i := len(l.Entries) - 1; i >= 0; i-- {
+		entry := l.Entries[i]
+		switch entry.Kind {
+		case EntryKindUnit:
+			if err := units.DisableDaemon(entry.Path); err != nil {
+				kept = append(kept, Drift{Kind: entry.Kind, Path: entry.Path, Reason: fmt.Sprintf("disabling unit: %s", err)})
+			}
+		case EntryKindFile, EntryKindSymlink:
+			if entry.Preexisting {
+				continue
+			}
+			reason, err := entry.drift()
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			switch reason {
+			case "":
+				if err := os.Remove(entry.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					errs = append(errs, err)
+				}
+			case "removed":
+			default:
+				kept = append(kept, Drift{Kind: entry.Kind, Path: entry.Path, Reason: reason})
+			}
+		case EntryKindLine:
+			if err := removeLine(entry.Path, entry.Line); err != nil {
+				errs = append(errs, err)
+			}
+		case EntryKindDirectory:
+			dirEntries, err := os.ReadDir(entry.Path)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			} else if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if len(dirEntries) > 0 {
+				kept = append(kept, Drift{Kind: entry.Kind, Path: entry.Path, Reason: "not empty"})
+				continue
+			}
+			if err := os.Remove(entry.Path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return kept, errors.Join(errs...)
+}
+
+// removeLine removes the last occurrence of line from the file at path, keeping its mode.
+// It is a no-op if the file or the line are gone.
+func removeLine(path, line string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimSuffix(lines[i], "\n") == line {
+			lines = slices.Delete(lines, i, i+1)
+			return os.WriteFile(path, []byte(strings.Join(lines, "")), info.Mode().Perm())
+		}
+	}
+	return nil
+}
+
+func fileSha256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+var (
+	activeMu sync.Mutex
+	active   *Ledger
+)
+
+// Track opens the ledger at path and records every host change made through the Record*
+// functions while fn runs. The ledger is saved even if fn fails so partial installs can
+// also be undone.
+func Track(path string, fn func() error) error {
+	l, err := Open(path)
+	if err != nil {
+		return err
+	}
+
+	activeMu.Lock()
+	active = l
+	activeMu.Unlock()
+
+	err = fn()
+
+	activeMu.Lock()
+	l = active
+	active = nil
+	activeMu.Unlock()
+
+	if saveErr := l.Save(); saveErr != nil {
+		return errors.Join(err, fmt.Errorf("saving ledger: %w", saveErr))
+	}
+	return err
+}
+
+// Reload discards the changes recorded since the active ledger was opened and reads it
+// again from disk. It is used after restoring a snapshot that includes the ledger.
+// It is a no-op if no ledger is active.
+func Reload() error {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	if active == nil {
+		return nil
+	}
+	l, err := Open(active.path)
+	if err != nil {
+		return err
+	}
+	active = l
+	return nil
+}
+
+func withActive(fn func(l *Ledger) error) error {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	if active == nil {
+		return nil
+	}
+	return fn(active)
+}
+
+// RecordFile records a file written by nodeadm with its current content.
+// preexisting is true if the file existed before nodeadm wrote it.
+func RecordFile(path string, preexisting bool) error {
+	return withActive(func(l *Ledger) error {
+		checksum, err := fileSha256(path)
+		if err != nil {
+			return fmt.Errorf("recording %s in ledger: %w", path, err)
+		}
+		l.record(Entry{Kind: EntryKindFile, Path: path, Sha256: checksum, Preexisting: preexisting})
+		return nil
+	})
+}
+
+// RecordLine records a line nodeadm appended to a preexisting file. Uninstall removes
+// only that line. If the file was created by nodeadm, it is recorded as a whole instead.
+func RecordLine(path, line string) error {
+	return withActive(func(l *Ledger) error {
+		for _, entry := range l.Entries {
+			if entry.Kind == EntryKindFile && entry.Path == path && !entry.Preexisting {
+				checksum, err := fileSha256(path)
+				if err != nil {
+					return fmt.Errorf("recording %s in ledger: %w", path, err)
+				}
+				l.record(Entry{Kind: EntryKindFile, Path: path, Sha256: checksum})
+				return nil
+			}
+		}
+		l.record(Entry{Kind: EntryKindLine, Path: path, Line: line})
+		return nil
+	})
+}
+
+// RecordDir records a directory created by nodeadm.
+func RecordDir(path string) error {
+	return withActive(func(l *Ledger) error {
+		l.record(Entry{Kind: EntryKindDirectory, Path: path})
+		return nil
+	})
+}
+
+// RecordSymlink records a symlink created by nodeadm.
+func RecordSymlink(path, target string) error {
+	return withActive(func(l *Ledger) error {
+		l.record(Entry{Kind: EntryKindSymlink, Path: path, Target: target})
+		return nil
+	})
+}
+
+// RecordUnit records a systemd unit enabled by nodeadm.
+func RecordUnit(name string) error {
+	return withActive(func(l *Ledger) error {
+		l.record(Entry{Kind: EntryKindUnit, Path: name})
+		return nil
+	})
+}
+
+// MkdirAll wraps os.MkdirAll and records the directories it creates.
+func MkdirAll(dir string, perm fs.FileMode) error {
+	var missing []string
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if _, err := os.Lstat(d); !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		missing = append(missing, d)
+		if d == filepath.Dir(d) {
+			break
+		}
+	}
+	if err := os.MkdirAll(dir, perm); err != nil {
+		return err
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := RecordDir(missing[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Exists returns true if path exists. It is used to know if a file is preexisting
+// before writing it.
+func Exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
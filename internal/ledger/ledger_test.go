@@ -0,0 +1,165 @@
+package ledger_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/eks-hybrid/internal/ledger"
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+type fakeUnits struct {
+	disabled []string
+}
+
+func (f *fakeUnits) DisableDaemon(name string) error {
+	f.disabled = append(f.disabled, name)
+	if name == "missing" {
+		return errors.New("unit not found")
+	}
+	return nil
+}
+
+func TestTrackAndReplay(t *testing.T) {
+	g := NewWithT(t)
+	root := t.TempDir()
+	ledgerPath := filepath.Join(root, "ledger")
+	preexisting := filepath.Join(root, "etc/fstab")
+	g.Expect(os.MkdirAll(filepath.Dir(preexisting), 0o755)).To(Succeed())
+	g.Expect(os.WriteFile(preexisting, []byte("original"), 0o644)).To(Succeed())
+
+	config := filepath.Join(root, "etc/eks/kubelet/environment")
+	modified := filepath.Join(root, "etc/eks/kubelet/config.json")
+	symlink := filepath.Join(root, "eks-hybrid/.aws/config")
+
+	err := ledger.Track(ledgerPath, func() error {
+		g.Expect(util.WriteFileWithDir(config, []byte("env"), 0o644)).To(Succeed())
+		g.Expect(util.WriteFileWithDir(modified, []byte("{}"), 0o644)).To(Succeed())
+		g.Expect(util.WriteFileWithDir(preexisting, []byte("with swap disabled"), 0o644)).To(Succeed())
+		g.Expect(ledger.MkdirAll(filepath.Dir(symlink), 0o755)).To(Succeed())
+		g.Expect(os.Symlink("/root/.aws/config", symlink)).To(Succeed())
+		g.Expect(ledger.RecordSymlink(symlink, "/root/.aws/config")).To(Succeed())
+		g.Expect(ledger.RecordUnit("kubelet")).To(Succeed())
+		g.Expect(ledger.RecordUnit("missing")).To(Succeed())
+		return errors.New("install failed")
+	})
+	g.Expect(err).To(MatchError(ContainSubstring("install failed")))
+
+	// Nothing is recorded once Track returns.
+	g.Expect(util.WriteFileWithDir(filepath.Join(root, "untracked"), []byte("data"), 0o644)).To(Succeed())
+
+	l, err := ledger.Load(ledgerPath)
+	g.Expect(err).NotTo(HaveOccurred())
+	var paths []string
+	for _, entry := range l.Entries {
+		paths = append(paths, entry.Path)
+	}
+	g.Expect(paths).To(Equal([]string{
+		filepath.Join(root, "etc/eks"),
+		filepath.Join(root, "etc/eks/kubelet"),
+		config,
+		modified,
+		preexisting,
+		filepath.Join(root, "eks-hybrid"),
+		filepath.Join(root, "eks-hybrid/.aws"),
+		symlink,
+		"kubelet",
+		"missing",
+	}))
+	g.Expect(l.Entries[4].Preexisting).To(BeTrue())
+
+	g.Expect(os.WriteFile(modified, []byte(`{"changed":true}`), 0o644)).To(Succeed())
+
+	drift, err := l.Check()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(drift).To(ConsistOf(ledger.Drift{Kind: ledger.EntryKindFile, Path: modified, Reason: "modified"}))
+
+	units := &fakeUnits{}
+	kept, err := l.Replay(units)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(units.disabled).To(Equal([]string{"missing", "kubelet"}))
+	g.Expect(kept).To(Equal([]ledger.Drift{
+		{Kind: ledger.EntryKindUnit, Path: "missing", Reason: "disabling unit: unit not found"},
+		{Kind: ledger.EntryKindFile, Path: modified, Reason: "modified"},
+		{Kind: ledger.EntryKindDirectory, Path: filepath.Join(root, "etc/eks/kubelet"), Reason: "not empty"},
+		{Kind: ledger.EntryKindDirectory, Path: filepath.Join(root, "etc/eks"), Reason: "not empty"},
+	}))
+
+	g.Expect(config).NotTo(BeAnExistingFile())
+	g.Expect(filepath.Join(root, "eks-hybrid")).NotTo(BeADirectory())
+	g.Expect(modified).To(BeAnExistingFile())
+	g.Expect(os.ReadFile(preexisting)).To(Equal([]byte("with swap disabled")))
+}
+
+func TestCheckSymlink(t *testing.T) {
+	g := NewWithT(t)
+	root := t.TempDir()
+	ledgerPath := filepath.Join(root, "ledger")
+	symlink := filepath.Join(root, "config")
+	removed := filepath.Join(root, "removed")
+
+	g.Expect(ledger.Track(ledgerPath, func() error {
+		g.Expect(util.WriteFileWithDir(removed, []byte("data"), 0o644)).To(Succeed())
+		g.Expect(os.Symlink("/etc/aws/config", symlink)).To(Succeed())
+		return ledger.RecordSymlink(symlink, "/etc/aws/config")
+	})).To(Succeed())
+
+	g.Expect(os.Remove(symlink)).To(Succeed())
+	g.Expect(os.Symlink("/tmp/other", symlink)).To(Succeed())
+	g.Expect(os.Remove(removed)).To(Succeed())
+
+	l, err := ledger.Load(ledgerPath)
+	g.Expect(err).NotTo(HaveOccurred())
+	drift, err := l.Check()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(drift).To(Equal([]ledger.Drift{
+		{Kind: ledger.EntryKindFile, Path: removed, Reason: "removed"},
+		{Kind: ledger.EntryKindSymlink, Path: symlink, Reason: "points to /tmp/other instead of /etc/aws/config"},
+	}))
+
+	kept, err := l.Replay(&fakeUnits{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(kept).To(HaveLen(1))
+	g.Expect(os.Readlink(symlink)).To(Equal("/tmp/other"))
+}
+
+func TestReplayAppendedLine(t *testing.T) {
+	g := NewWithT(t)
+	root := t.TempDir()
+	ledgerPath := filepath.Join(root, "ledger")
+	gpgConf := filepath.Join(root, "root/.gnupg/gpg.conf")
+	created := filepath.Join(root, "etc/created.conf")
+	g.Expect(os.MkdirAll(filepath.Dir(gpgConf), 0o700)).To(Succeed())
+	g.Expect(os.WriteFile(gpgConf, []byte("keyserver hkps://keys.example.com\n"), 0o600)).To(Succeed())
+
+	g.Expect(ledger.Track(ledgerPath, func() error {
+		g.Expect(util.WriteFileUniqueLine(gpgConf, []byte("no-tty"), 0o600)).To(Succeed())
+		g.Expect(util.WriteFileUniqueLine(created, []byte("first"), 0o644)).To(Succeed())
+		return util.WriteFileUniqueLine(created, []byte("second"), 0o644)
+	})).To(Succeed())
+
+	// The user adds a line after nodeadm.
+	f, err := os.OpenFile(gpgConf, os.O_APPEND|os.O_WRONLY, 0)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = f.WriteString("use-agent\n")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(f.Close()).To(Succeed())
+
+	l, err := ledger.Load(ledgerPath)
+	g.Expect(err).NotTo(HaveOccurred())
+	drift, err := l.Check()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(drift).To(BeEmpty())
+
+	_, err = l.Replay(&fakeUnits{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(os.ReadFile(gpgConf)).To(Equal([]byte("keyserver hkps://keys.example.com\nuse-agent\n")))
+	info, err := os.Stat(gpgConf)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+	g.Expect(created).NotTo(BeAnExistingFile())
+}
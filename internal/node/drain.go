@@ -0,0 +1,170 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/aws/eks-hybrid/internal/kubelet"
+	"github.com/aws/eks-hybrid/internal/node/hybrid"
+)
+
+const (
+	// DefaultDrainTimeout is how long to wait for pods to be evicted by default.
+	DefaultDrainTimeout = 10 * time.Minute
+
+	defaultEvictionInterval = 5 * time.Second
+)
+
+// Drainer cordons the node and evicts its pods through the Eviction API, so
+// PodDisruptionBudgets are respected. DaemonSet and static pods are not evicted.
+type Drainer struct {
+	Client   kubernetes.Interface
+	NodeName string
+	Logger   *zap.Logger
+	// Timeout is how long to wait for all pods to be evicted. Defaults to DefaultDrainTimeout.
+	Timeout time.Duration
+	// EvictionInterval is how long to wait between eviction attempts, for example
+	// while a PodDisruptionBudget doesn't allow the eviction.
+	EvictionInterval time.Duration
+}
+
+// NewDrainer returns a Drainer for the node the kubelet is registered as.
+func NewDrainer(logger *zap.Logger, timeout time.Duration) (*Drainer, error) {
+	nodeName, err := kubelet.GetNodeName()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting node name from kubelet")
+	}
+	clientset, err := hybrid.BuildKubeClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create kubernetes client")
+	}
+	return &Drainer{
+		Client:   clientset,
+		NodeName: nodeName,
+		Logger:   logger,
+		Timeout:  timeout,
+	}, nil
+}
+
+// Drain cordons the node and waits until all the pods that are not controlled by a
+// DaemonSet or are static pods have been evicted.
+func (d *Drainer) Drain(ctx context.Context) error {
+	d.Logger.Info("Cordoning node...", zap.String("node", d.NodeName))
+	if err := d.setUnschedulable(ctx, true); err != nil {
+		return errors.Wrap(err, "cordoning node")
+	}
+
+	timeout := d.Timeout
+	if timeout == 0 {
+		timeout = DefaultDrainTimeout
+	}
+	interval := d.EvictionInterval
+	if interval == 0 {
+		interval = defaultEvictionInterval
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	d.Logger.Info("Evicting pods...", zap.String("node", d.NodeName), zap.Duration("timeout", timeout))
+	var pods []corev1.Pod
+	for {
+		remaining, err := d.podsToEvict(ctx)
+		if err != nil && ctx.Err() != nil {
+			return fmt.Errorf("timed out waiting for pods to be evicted: %s", podNames(pods))
+		} else if err != nil {
+			return err
+		}
+		pods = remaining
+		if len(pods) == 0 {
+			d.Logger.Info("Node drained", zap.String("node", d.NodeName))
+			return nil
+		}
+
+		for _, pod := range pods {
+			if err := d.evict(ctx, pod); errors.Is(err, context.DeadlineExceeded) {
+				return fmt.Errorf("timed out waiting for pods to be evicted: %s", podNames(pods))
+			} else if err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for pods to be evicted: %s", podNames(pods))
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Uncordon marks the node as schedulable.
+func (d *Drainer) Uncordon(ctx context.Context) error {
+	d.Logger.Info("Uncordoning node...", zap.String("node", d.NodeName))
+	if err := d.setUnschedulable(ctx, false); err != nil {
+		return errors.Wrap(err, "uncordoning node")
+	}
+	return nil
+}
+
+func (d *Drainer) setUnschedulable(ctx context.Context, unschedulable bool) error {
+	patch := fmt.Sprintf(`{"spec":{"unschedulable":%t}}`, unschedulable)
+	_, err := d.Client.CoreV1().Nodes().Patch(ctx, d.NodeName, types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+	return err
+}
+
+func (d *Drainer) podsToEvict(ctx context.Context) ([]corev1.Pod, error) {
+	pods, err := GetPodsOnNode(ctx, d.NodeName, d.Client)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting pods for node %s", d.NodeName)
+	}
+	for _, filter := range getDrainedPodFilters() {
+		pods, err = filter(pods)
+		if err != nil {
+			return nil, errors.Wrap(err, "running filter on pods")
+		}
+	}
+	return pods, nil
+}
+
+func (d *Drainer) evict(ctx context.Context, pod corev1.Pod) error {
+	// Pods being deleted have already been evicted, we just wait for them to terminate.
+	if pod.DeletionTimestamp != nil {
+		return nil
+	}
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+	}
+	err := d.Client.PolicyV1().Evictions(pod.Namespace).Evict(ctx, eviction)
+	switch {
+	case err == nil:
+		d.Logger.Info("Evicted pod", zap.String("namespace", pod.Namespace), zap.String("pod", pod.Name))
+	case apierrors.IsNotFound(err):
+	case apierrors.IsTooManyRequests(err):
+		// The eviction would violate a PodDisruptionBudget, it is retried on the next attempt.
+		d.Logger.Info("Pod eviction blocked by disruption budget, retrying", zap.String("namespace", pod.Namespace), zap.String("pod", pod.Name))
+	default:
+		return errors.Wrapf(err, "evicting pod %s/%s", pod.Namespace, pod.Name)
+	}
+	return nil
+}
+
+func podNames(pods []corev1.Pod) string {
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.Namespace+"/"+pod.Name)
+	}
+	return strings.Join(names, ", ")
+}
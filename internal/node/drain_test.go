@@ -0,0 +1,123 @@
+package node_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go/ptr"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	testingk8s "k8s.io/client-go/testing"
+
+	"github.com/aws/eks-hybrid/internal/node"
+)
+
+func drainTestObjects() []runtime.Object {
+	return []runtime.Object{
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "test-node"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: "test-node"},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cilium",
+				Namespace: "kube-system",
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "DaemonSet", Controller: ptr.Bool(true)},
+				},
+			},
+			Spec: corev1.PodSpec{NodeName: "test-node"},
+		},
+	}
+}
+
+// evictionReactor deletes the evicted pod after rejecting the first blocked evictions
+// like the API server does when a PodDisruptionBudget doesn't allow them.
+func evictionReactor(client *fake.Clientset, blocked int, evicted *[]string) testingk8s.ReactionFunc {
+	return func(action testingk8s.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := action.(testingk8s.CreateAction).GetObject().(*policyv1.Eviction)
+		if blocked > 0 {
+			blocked--
+			return true, nil, apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+		}
+		*evicted = append(*evicted, eviction.Name)
+		err := client.Tracker().Delete(corev1.SchemeGroupVersion.WithResource("pods"), eviction.Namespace, eviction.Name)
+		return true, nil, err
+	}
+}
+
+func TestDrainerDrain(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(drainTestObjects()...)
+	var evicted []string
+	client.PrependReactor("create", "pods", evictionReactor(client, 2, &evicted))
+
+	drainer := &node.Drainer{
+		Client:           client,
+		NodeName:         "test-node",
+		Logger:           zap.NewNop(),
+		Timeout:          5 * time.Second,
+		EvictionInterval: time.Millisecond,
+	}
+	g.Expect(drainer.Drain(ctx)).To(Succeed())
+	g.Expect(evicted).To(Equal([]string{"app"}))
+
+	k8sNode, err := client.CoreV1().Nodes().Get(ctx, "test-node", metav1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(k8sNode.Spec.Unschedulable).To(BeTrue())
+
+	g.Expect(drainer.Uncordon(ctx)).To(Succeed())
+	k8sNode, err = client.CoreV1().Nodes().Get(ctx, "test-node", metav1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(k8sNode.Spec.Unschedulable).To(BeFalse())
+}
+
+func TestDrainerDrainTimeout(t *testing.T) {
+	g := NewWithT(t)
+	client := fake.NewSimpleClientset(drainTestObjects()...)
+	var evicted []string
+	client.PrependReactor("create", "pods", evictionReactor(client, 1000, &evicted))
+
+	drainer := &node.Drainer{
+		Client:           client,
+		NodeName:         "test-node",
+		Logger:           zap.NewNop(),
+		Timeout:          50 * time.Millisecond,
+		EvictionInterval: 10 * time.Millisecond,
+	}
+	g.Expect(drainer.Drain(context.Background())).To(MatchError(ContainSubstring("timed out waiting for pods to be evicted: default/app")))
+	g.Expect(evicted).To(BeEmpty())
+}
+
+func TestDrainerDrainTimeoutDuringEviction(t *testing.T) {
+	g := NewWithT(t)
+	client := fake.NewSimpleClientset(drainTestObjects()...)
+	client.PrependReactor("create", "pods", func(action testingk8s.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		// The deadline expires while the API server handles the eviction.
+		return true, nil, context.DeadlineExceeded
+	})
+
+	drainer := &node.Drainer{
+		Client:           client,
+		NodeName:         "test-node",
+		Logger:           zap.NewNop(),
+		Timeout:          time.Second,
+		EvictionInterval: 10 * time.Millisecond,
+	}
+	g.Expect(drainer.Drain(context.Background())).To(MatchError("timed out waiting for pods to be evicted: default/app"))
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/aws/eks-hybrid/internal/containerd"
 	"github.com/aws/eks-hybrid/internal/daemon"
 	"github.com/aws/eks-hybrid/internal/kubelet"
+	"github.com/aws/eks-hybrid/internal/util"
 )
 
 func (enp *ec2NodeProvider) withDaemonManager() error {
@@ -15,6 +16,9 @@ func (enp *ec2NodeProvider) withDaemonManager() error {
 	if err != nil {
 		return err
 	}
+	if util.DryRun() {
+		manager = daemon.NewDryRunDaemonManager(manager, enp.logger)
+	}
 	enp.daemonManager = manager
 	return nil
 }
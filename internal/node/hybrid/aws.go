@@ -16,17 +16,24 @@ import (
 	"github.com/aws/eks-hybrid/internal/iamrolesanywhere"
 	"github.com/aws/eks-hybrid/internal/kubelet"
 	"github.com/aws/eks-hybrid/internal/ssm"
+	"github.com/aws/eks-hybrid/internal/util"
 	"github.com/aws/eks-hybrid/internal/util/file"
 )
 
 func (hnp *HybridNodeProvider) ConfigureAws(ctx context.Context) error {
 	if hnp.nodeConfig.IsSSM() {
-		configurator := SSMAWSConfigurator{
-			Manager: hnp.daemonManager,
-			Logger:  hnp.logger,
-		}
-		if err := configurator.Configure(ctx, hnp.nodeConfig); err != nil {
-			return fmt.Errorf("configuring aws credentials with SSM: %w", err)
+		// Registering with SSM doesn't render any host file, so in dry-run mode
+		// the credentials from a previous registration are used instead.
+		if util.DryRun() {
+			hnp.logger.Info("Dry run: skipping SSM registration")
+		} else {
+			configurator := SSMAWSConfigurator{
+				Manager: hnp.daemonManager,
+				Logger:  hnp.logger,
+			}
+			if err := configurator.Configure(ctx, hnp.nodeConfig); err != nil {
+				return fmt.Errorf("configuring aws credentials with SSM: %w", err)
+			}
 		}
 
 		configCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
@@ -111,6 +118,9 @@ func (c RolesAnywhereAWSConfigurator) Configure(ctx context.Context, nodeConfig
 	if err := signingHelper.Configure(ctx); err != nil {
 		return err
 	}
+	if util.DryRun() {
+		return nil
+	}
 	if err := signingHelper.EnsureRunning(ctx); err != nil {
 		return err
 	}
@@ -124,7 +134,7 @@ func (c RolesAnywhereAWSConfigurator) Configure(ctx context.Context, nodeConfig
 func LoadAWSConfigForRolesAnywhere(ctx context.Context, nodeConfig *api.NodeConfig) (aws.Config, error) {
 	return config.LoadDefaultConfig(ctx,
 		config.WithRegion(nodeConfig.Spec.Cluster.Region),
-		config.WithSharedConfigFiles([]string{nodeConfig.Spec.Hybrid.IAMRolesAnywhere.AwsConfigPath}),
+		config.WithSharedConfigFiles([]string{util.HostPath(nodeConfig.Spec.Hybrid.IAMRolesAnywhere.AwsConfigPath)}),
 		config.WithSharedCredentialsFiles([]string{iamrolesanywhere.EksHybridAwsCredentialsPath}),
 		config.WithSharedConfigProfile(iamrolesanywhere.ProfileName),
 		// This is helpful if the machine happens to be running on an EC2 instance
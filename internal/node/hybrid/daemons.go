@@ -9,6 +9,7 @@ import (
 	"github.com/aws/eks-hybrid/internal/daemon"
 	"github.com/aws/eks-hybrid/internal/iamrolesanywhere"
 	"github.com/aws/eks-hybrid/internal/kubelet"
+	"github.com/aws/eks-hybrid/internal/util"
 )
 
 func (hnp *HybridNodeProvider) withDaemonManager() error {
@@ -16,6 +17,9 @@ func (hnp *HybridNodeProvider) withDaemonManager() error {
 	if err != nil {
 		return err
 	}
+	if util.DryRun() {
+		manager = daemon.NewDryRunDaemonManager(manager, hnp.logger)
+	}
 	hnp.daemonManager = manager
 	return nil
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/aws/eks-hybrid/internal/api"
 	"github.com/aws/eks-hybrid/internal/certificate"
+	"github.com/aws/eks-hybrid/internal/configprovider"
 	"github.com/aws/eks-hybrid/internal/util/file"
 	"github.com/aws/eks-hybrid/internal/validation"
 )
@@ -70,7 +71,7 @@ func (hnp *HybridNodeProvider) withHybridValidators() {
 			}
 			// Check if ActivationCode matches the pattern
 			if !reCode.MatchString(cfg.Spec.Hybrid.SSM.ActivationCode) {
-				return fmt.Errorf("invalid ActivationCode format: %s. Must be 20-250 characters", cfg.Spec.Hybrid.SSM.ActivationCode)
+				return fmt.Errorf("invalid ActivationCode format. Must be 20-250 characters")
 			}
 
 			// Compile the regex patterns
@@ -116,18 +117,21 @@ func validateRolesAnywhereNode(node *api.NodeConfig) error {
 	if node.Spec.Hybrid.IAMRolesAnywhere.CertificatePath == "" {
 		return fmt.Errorf("CertificatePath is missing in hybrid iam roles anywhere configuration")
 	}
-	if !file.Exists(node.Spec.Hybrid.IAMRolesAnywhere.CertificatePath) {
-		return fmt.Errorf("IAM Roles Anywhere certificate %s not found", node.Spec.Hybrid.IAMRolesAnywhere.CertificatePath)
-	}
-	if err := certificate.Validate(node.Spec.Hybrid.IAMRolesAnywhere.CertificatePath, nil); err != nil {
-		return addIAMRARemediation(node.Spec.Hybrid.IAMRolesAnywhere.CertificatePath, err)
+	// Secret references are only resolved to files by init, there is no file to check yet.
+	if !configprovider.IsSecretReference(node.Spec.Hybrid.IAMRolesAnywhere.CertificatePath) {
+		if !file.Exists(node.Spec.Hybrid.IAMRolesAnywhere.CertificatePath) {
+			return fmt.Errorf("IAM Roles Anywhere certificate %s not found", node.Spec.Hybrid.IAMRolesAnywhere.CertificatePath)
+		}
+		if err := certificate.Validate(node.Spec.Hybrid.IAMRolesAnywhere.CertificatePath, nil); err != nil {
+			return addIAMRARemediation(node.Spec.Hybrid.IAMRolesAnywhere.CertificatePath, err)
+		}
 	}
 
 	// IAM roles anywhere key validation
 	if node.Spec.Hybrid.IAMRolesAnywhere.PrivateKeyPath == "" {
 		return fmt.Errorf("PrivateKeyPath is missing in hybrid iam roles anywhere configuration")
 	}
-	if !file.Exists(node.Spec.Hybrid.IAMRolesAnywhere.PrivateKeyPath) {
+	if !configprovider.IsSecretReference(node.Spec.Hybrid.IAMRolesAnywhere.PrivateKeyPath) && !file.Exists(node.Spec.Hybrid.IAMRolesAnywhere.PrivateKeyPath) {
 		return fmt.Errorf("IAM Roles Anywhere private key %s not found", node.Spec.Hybrid.IAMRolesAnywhere.PrivateKeyPath)
 	}
 
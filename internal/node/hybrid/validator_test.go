@@ -401,7 +401,7 @@ func Test_HybridNodeProviderValidateConfig(t *testing.T) {
 					},
 				},
 			},
-			wantError: "invalid ActivationCode format: activation-code. Must be 20-250 characters",
+			wantError: "invalid ActivationCode format. Must be 20-250 characters",
 		},
 		{
 			name: "invalid ssm activation code (too long - 251 chars)",
@@ -419,7 +419,7 @@ func Test_HybridNodeProviderValidateConfig(t *testing.T) {
 					},
 				},
 			},
-			wantError: "invalid ActivationCode format: " + strings.Repeat("a", 251) + ". Must be 20-250 characters",
+			wantError: "invalid ActivationCode format. Must be 20-250 characters",
 		},
 		{
 			name: "invalid ssm activation id by length",
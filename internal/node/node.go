@@ -7,11 +7,14 @@ import (
 	"github.com/aws/eks-hybrid/internal/node/ec2"
 	"github.com/aws/eks-hybrid/internal/node/hybrid"
 	"github.com/aws/eks-hybrid/internal/nodeprovider"
+	"github.com/aws/eks-hybrid/internal/util"
 )
 
-func NewNodeProvider(configSource string, skipPhases []string, logger *zap.Logger) (nodeprovider.NodeProvider, error) {
-	logger.Info("Loading configuration...", zap.String("configSource", configSource))
-	provider, err := configprovider.BuildConfigProvider(configSource)
+// NewNodeProvider returns the node provider for the configuration merged from configSources,
+// in order of increasing precedence. opts configure how the sources are read.
+func NewNodeProvider(configSources, skipPhases []string, logger *zap.Logger, opts ...configprovider.Option) (nodeprovider.NodeProvider, error) {
+	logger.Info("Loading configuration...", zap.Strings("configSource", util.RedactURIs(configSources)))
+	provider, err := configprovider.BuildLayeredConfigProvider(configSources, opts...)
 	if err != nil {
 		return nil, err
 	}
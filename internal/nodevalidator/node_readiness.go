@@ -62,6 +62,11 @@ func (nrc *nodeReadinessChecker) isNodeReady(node *corev1.Node) bool {
 	return true
 }
 
+// IsNodeReady checks if the node has Ready condition set to True
+func IsNodeReady(node *corev1.Node) bool {
+	return (&nodeReadinessChecker{}).hasReadyCondition(node)
+}
+
 // hasReadyCondition checks if the node has Ready condition set to True
 func (nrc *nodeReadinessChecker) hasReadyCondition(node *corev1.Node) bool {
 	for _, condition := range node.Status.Conditions {
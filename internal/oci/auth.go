@@ -0,0 +1,256 @@
+package oci
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	// HostsDir is where containerd reads the configuration of each registry host from,
+	// as <HostsDir>/<registry>/hosts.toml.
+	HostsDir = "/etc/containerd/certs.d"
+	// DockerConfigEnv overrides the directory of the docker config file.
+	DockerConfigEnv = "DOCKER_CONFIG"
+)
+
+// Auth finds the connection settings and credentials of registries in the same places
+// as the container runtime: the containerd registry hosts directory and a docker config file.
+type Auth struct {
+	// HostsDir is the containerd registry hosts directory. Ignored if empty.
+	HostsDir string
+	// DockerConfig is the path of a docker config.json file. Ignored if empty.
+	DockerConfig string
+}
+
+// DefaultAuth returns an Auth reading the containerd hosts directory and the docker config
+// of the current user.
+func DefaultAuth() Auth {
+	dockerConfigDir := os.Getenv(DockerConfigEnv)
+	if dockerConfigDir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			dockerConfigDir = filepath.Join(home, ".docker")
+		}
+	}
+	auth := Auth{HostsDir: HostsDir}
+	if dockerConfigDir != "" {
+		auth.DockerConfig = filepath.Join(dockerConfigDir, "config.json")
+	}
+	return auth
+}
+
+// NewClient returns a Client for the repository of ref, configured with the settings
+// found for its registry.
+func (a Auth) NewClient(ref Reference) (*Client, error) {
+	client := NewClient(ref)
+
+	host, err := a.hostConfig(ref.Registry)
+	if err != nil {
+		return nil, err
+	}
+	if host.server != "" {
+		server, err := url.Parse(host.server)
+		if err != nil || server.Host == "" {
+			return nil, fmt.Errorf("invalid server %s in hosts configuration of %s", host.server, ref.Registry)
+		}
+		// The server can be another host than the registry, like registry-1.docker.io
+		// serving docker.io.
+		client.Server = strings.TrimSuffix(server.String(), "/")
+		client.PlainHTTP = server.Scheme == "http"
+	}
+	if len(host.header) > 0 {
+		client.Header = host.header
+	}
+	if len(host.ca) > 0 || host.skipVerify {
+		httpClient, err := newHTTPClient(host.ca, host.skipVerify)
+		if err != nil {
+			return nil, fmt.Errorf("configuring TLS for %s: %w", ref.Registry, err)
+		}
+		client.HTTPClient = httpClient
+	}
+
+	if client.Username, client.Password, err = a.dockerCredentials(ref.Registry); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// hostConfig is the subset of a containerd hosts.toml used to reach the registry itself.
+// Mirrors declared in [host."..."] tables are ignored, except for a table whose URL is the
+// registry or its server, which is merged into the top level configuration.
+type hostConfig struct {
+	server     string
+	header     http.Header
+	ca         []string
+	skipVerify bool
+}
+
+// hostsFile is the layout of a containerd hosts.toml.
+type hostsFile struct {
+	Server     string                `toml:"server"`
+	CA         any                   `toml:"ca"`
+	SkipVerify bool                  `toml:"skip_verify"`
+	Header     map[string]any        `toml:"header"`
+	Host       map[string]hostsEntry `toml:"host"`
+}
+
+type hostsEntry struct {
+	CA         any            `toml:"ca"`
+	SkipVerify bool           `toml:"skip_verify"`
+	Header     map[string]any `toml:"header"`
+}
+
+// hostConfig parses <HostsDir>/<registry>/hosts.toml, falling back to the _default
+// directory like containerd.
+func (a Auth) hostConfig(registry string) (hostConfig, error) {
+	config := hostConfig{header: http.Header{}}
+	if a.HostsDir == "" {
+		return config, nil
+	}
+	var path string
+	for _, dir := range []string{strings.ReplaceAll(registry, ":", "_"), registry, "_default"} {
+		candidate := filepath.Join(a.HostsDir, dir, "hosts.toml")
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+			break
+		}
+	}
+	if path == "" {
+		return config, nil
+	}
+
+	var file hostsFile
+	if _, err := toml.DecodeFile(path, &file); err != nil {
+		return config, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	config.server = file.Server
+	entries := []hostsEntry{{CA: file.CA, SkipVerify: file.SkipVerify, Header: file.Header}}
+	for hostURL, entry := range file.Host {
+		parsed, err := url.Parse(hostURL)
+		if err != nil {
+			continue
+		}
+		if parsed.Host == registry || (file.Server != "" && strings.TrimSuffix(hostURL, "/") == strings.TrimSuffix(file.Server, "/")) {
+			entries = append(entries, entry)
+		}
+	}
+	for _, entry := range entries {
+		ca, err := tomlStrings(entry.CA)
+		if err != nil {
+			return config, fmt.Errorf("%s: ca: %w", path, err)
+		}
+		config.ca = append(config.ca, ca...)
+		config.skipVerify = config.skipVerify || entry.SkipVerify
+		for key, value := range entry.Header {
+			values, err := tomlStrings(value)
+			if err != nil {
+				return config, fmt.Errorf("%s: header %s: %w", path, key, err)
+			}
+			for _, v := range values {
+				config.header.Add(key, v)
+			}
+		}
+	}
+	return config, nil
+}
+
+// tomlStrings returns a TOML value that is either a string or an array of strings.
+func tomlStrings(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a string, got %v", item)
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("expected a string or an array of strings, got %v", value)
+	}
+}
+
+func newHTTPClient(caFiles []string, skipVerify bool) (*http.Client, error) {
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	for _, caFile := range caFiles {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs:            roots,
+		InsecureSkipVerify: skipVerify,
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// dockerCredentials returns the username and password stored for registry in the docker
+// config file. Credential helpers aren't supported.
+func (a Auth) dockerCredentials(registry string) (string, string, error) {
+	if a.DockerConfig == "" {
+		return "", "", nil
+	}
+	data, err := os.ReadFile(a.DockerConfig)
+	if os.IsNotExist(err) {
+		return "", "", nil
+	} else if err != nil {
+		return "", "", err
+	}
+
+	var config struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return "", "", fmt.Errorf("parsing docker config %s: %w", a.DockerConfig, err)
+	}
+	for key, entry := range config.Auths {
+		// Entries are keyed by host, or by URL for credentials stored by older docker versions.
+		host := key
+		if parsed, err := url.Parse(key); err == nil && parsed.Host != "" {
+			host = parsed.Host
+		}
+		if host != registry {
+			continue
+		}
+		if entry.Auth == "" {
+			return entry.Username, entry.Password, nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return "", "", fmt.Errorf("decoding credentials of %s in docker config: %w", registry, err)
+		}
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return "", "", fmt.Errorf("invalid credentials of %s in docker config", registry)
+		}
+		return username, password, nil
+	}
+	return "", "", nil
+}
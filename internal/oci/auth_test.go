@@ -0,0 +1,131 @@
+package oci_test
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/eks-hybrid/internal/oci"
+	"github.com/aws/eks-hybrid/internal/oci/ocitest"
+)
+
+func writeHostsTOML(g *WithT, dir, host, content string) {
+	g.Expect(os.MkdirAll(filepath.Join(dir, host), 0o755)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, host, "hosts.toml"), []byte(content), 0o644)).To(Succeed())
+}
+
+func TestAuthHostsConfig(t *testing.T) {
+	g := NewWithT(t)
+	hostsDir := t.TempDir()
+	writeHostsTOML(g, hostsDir, "registry.example.com_5000", `
+# Pull from the registry over plain HTTP
+server = "http://registry.example.com:5000"
+
+[host."http://registry.example.com:5000"]
+  capabilities = ["pull", "resolve"]
+
+[host."http://registry.example.com:5000".header]
+  Authorization = "Basic cm9ib3Q6c2VjcmV0"
+
+[host."https://mirror.example.com"]
+  skip_verify = true
+
+[host."https://mirror.example.com".header]
+  Authorization = "Bearer mirror-token"
+`)
+
+	client, err := oci.Auth{HostsDir: hostsDir}.NewClient(oci.Reference{Registry: "registry.example.com:5000", Repository: "eks/artifacts"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(client.PlainHTTP).To(BeTrue())
+	g.Expect(client.Header.Values("Authorization")).To(Equal([]string{"Basic cm9ib3Q6c2VjcmV0"}))
+}
+
+func TestAuthDefaultHostsConfig(t *testing.T) {
+	g := NewWithT(t)
+	hostsDir := t.TempDir()
+	writeHostsTOML(g, hostsDir, "_default", `
+[header]
+  X-Site = ["dc1"]
+`)
+
+	client, err := oci.Auth{HostsDir: hostsDir}.NewClient(oci.Reference{Registry: "registry.example.com", Repository: "eks/artifacts"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(client.PlainHTTP).To(BeFalse())
+	g.Expect(client.Header.Get("X-Site")).To(Equal("dc1"))
+}
+
+func TestAuthHostsConfigSyntax(t *testing.T) {
+	g := NewWithT(t)
+	hostsDir := t.TempDir()
+	writeHostsTOML(g, hostsDir, "registry.example.com", `
+server = "https://registry.example.com" # the registry itself
+skip_verify = false # keep TLS verification
+
+[header]
+  X-Site = [
+    "dc1", # primary
+    "dc2",
+  ]
+`)
+
+	client, err := oci.Auth{HostsDir: hostsDir}.NewClient(oci.Reference{Registry: "registry.example.com", Repository: "eks/artifacts"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(client.Server).To(Equal("https://registry.example.com"))
+	g.Expect(client.Header.Values("X-Site")).To(Equal([]string{"dc1", "dc2"}))
+}
+
+func TestAuthHostsConfigServer(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	registry := ocitest.NewRegistry(t)
+	content := []byte("kubelet binary")
+	digest := oci.Digest(content)
+	g.Expect(registry.AddBlob("library/artifacts", content)).To(Equal(digest))
+
+	// Like docker.io served by registry-1.docker.io, the registry is only reachable
+	// through the server of its hosts.toml.
+	hostsDir := t.TempDir()
+	writeHostsTOML(g, hostsDir, "docker.io", `
+server = "http://`+registry.Host()+`"
+
+[host."http://`+registry.Host()+`".header]
+  X-Site = "dc1"
+`)
+
+	client, err := oci.Auth{HostsDir: hostsDir}.NewClient(oci.Reference{Registry: "docker.io", Repository: "library/artifacts"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(client.Header.Get("X-Site")).To(Equal("dc1"))
+	g.Expect(client.BlobExists(ctx, digest)).To(BeTrue())
+}
+
+func TestAuthDockerConfig(t *testing.T) {
+	g := NewWithT(t)
+	dockerConfig := filepath.Join(t.TempDir(), "config.json")
+	auth := base64.StdEncoding.EncodeToString([]byte("robot:secret"))
+	g.Expect(os.WriteFile(dockerConfig, []byte(`{"auths":{
+		"https://registry.example.com/v1/": {"auth": "`+auth+`"},
+		"other.example.com": {"username": "other", "password": "password"}
+	}}`), 0o600)).To(Succeed())
+
+	client, err := oci.Auth{DockerConfig: dockerConfig}.NewClient(oci.Reference{Registry: "registry.example.com", Repository: "eks/artifacts"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(client.Username).To(Equal("robot"))
+	g.Expect(client.Password).To(Equal("secret"))
+
+	client, err = oci.Auth{DockerConfig: dockerConfig}.NewClient(oci.Reference{Registry: "unknown.example.com", Repository: "eks/artifacts"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(client.Username).To(BeEmpty())
+}
+
+func TestAuthInvalidHostsConfig(t *testing.T) {
+	g := NewWithT(t)
+	hostsDir := t.TempDir()
+	writeHostsTOML(g, hostsDir, "registry.example.com", "server https://registry.example.com\n")
+
+	_, err := oci.Auth{HostsDir: hostsDir}.NewClient(oci.Reference{Registry: "registry.example.com", Repository: "eks/artifacts"})
+	g.Expect(err).To(MatchError(ContainSubstring("parsing ")))
+}
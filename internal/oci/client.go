@@ -0,0 +1,297 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// MediaTypeImageManifest is the media type of OCI image manifests.
+	MediaTypeImageManifest = "application/vnd.oci.image.manifest.v1+json"
+	// MediaTypeEmptyJSON is the media type of the empty config of artifact manifests.
+	MediaTypeEmptyJSON = "application/vnd.oci.empty.v1+json"
+	// AnnotationTitle holds the file name of a layer.
+	AnnotationTitle = "org.opencontainers.image.title"
+)
+
+// Descriptor describes content stored in a registry.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest is an OCI image manifest.
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Client talks to a repository of a registry implementing the OCI distribution spec.
+type Client struct {
+	Reference Reference
+	Username  string
+	Password  string
+	// PlainHTTP talks to the registry over http instead of https.
+	PlainHTTP bool
+	// Server is the base URL of the registry API, such as https://registry-1.docker.io.
+	// If set, it is used instead of the registry of Reference and PlainHTTP.
+	Server string
+	// Header is added to every request to the registry, for example an Authorization
+	// header from the containerd hosts configuration.
+	Header     http.Header
+	HTTPClient *http.Client
+
+	token string
+}
+
+// NewClient returns a Client for the repository of ref.
+func NewClient(ref Reference) *Client {
+	return &Client{
+		Reference:  ref,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+func (c *Client) repositoryURL() string {
+	if c.Server != "" {
+		return fmt.Sprintf("%s/v2/%s", c.Server, c.Reference.Repository)
+	}
+	scheme := "https"
+	if c.PlainHTTP {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s", scheme, c.Reference.Registry, c.Reference.Repository)
+}
+
+// BlobExists returns true if the repository has the blob with the given digest.
+func (c *Client) BlobExists(ctx context.Context, digest string) (bool, error) {
+	resp, err := c.do(ctx, http.MethodHead, c.repositoryURL()+"/blobs/"+digest, nil, "")
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("checking blob %s: unexpected status code %d", digest, resp.StatusCode)
+	}
+}
+
+// GetBlob downloads the blob with the given digest. The caller must close the returned
+// reader and is responsible for verifying the digest of its content.
+func (c *Client) GetBlob(ctx context.Context, digest string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.repositoryURL()+"/blobs/"+digest, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("getting blob %s from %s: unexpected status code %d", digest, c.Reference.Registry, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// PushBlob uploads a blob in a single request. content is read from the start and must
+// hash to digest.
+func (c *Client) PushBlob(ctx context.Context, digest string, size int64, content io.ReadSeeker) error {
+	resp, err := c.do(ctx, http.MethodPost, c.repositoryURL()+"/blobs/uploads/", nil, "")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("starting blob upload: unexpected status code %d", resp.StatusCode)
+	}
+	location, err := resp.Location()
+	if err != nil {
+		return fmt.Errorf("starting blob upload: %w", err)
+	}
+	query := location.Query()
+	query.Set("digest", digest)
+	location.RawQuery = query.Encode()
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, location.String(), content)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err = c.send(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("uploading blob %s: unexpected status code %d", digest, resp.StatusCode)
+	}
+	return nil
+}
+
+// PushManifest uploads an image manifest under tag and returns its digest.
+func (c *Client) PushManifest(ctx context.Context, tag string, manifest Manifest) (string, error) {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.do(ctx, http.MethodPut, c.repositoryURL()+"/manifests/"+tag, data, manifest.MediaType)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("uploading manifest %s: unexpected status code %d", tag, resp.StatusCode)
+	}
+	return Digest(data), nil
+}
+
+// Digest returns the sha256 digest of data in the format used by registries.
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// do sends a request with an optional body, authenticating and retrying once if the
+// registry asks for credentials.
+func (c *Client) do(ctx context.Context, method, uri string, body []byte, contentType string) (*http.Response, error) {
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, uri, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		return req, nil
+	}
+
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	if err := c.authenticate(ctx, resp.Header.Get("WWW-Authenticate")); err != nil {
+		return nil, err
+	}
+	req, err = newRequest()
+	if err != nil {
+		return nil, err
+	}
+	return c.send(req)
+}
+
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	for key, values := range c.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	return c.HTTPClient.Do(req)
+}
+
+// authenticate gets a token for a Bearer challenge. Basic challenges are answered with
+// the client credentials, which send already does.
+func (c *Client) authenticate(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	switch scheme {
+	case "basic":
+		if c.Username == "" {
+			return fmt.Errorf("registry %s requires credentials", c.Reference.Registry)
+		}
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("registry %s requires unsupported authentication %q", c.Reference.Registry, challenge)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return fmt.Errorf("invalid token realm in challenge %q", challenge)
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("getting registry token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("getting registry token: unexpected status code %d", resp.StatusCode)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("decoding registry token: %w", err)
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	if c.token == "" {
+		return fmt.Errorf("registry %s returned an empty token", c.Reference.Registry)
+	}
+	return nil
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+// Bearer realm="https://auth.example.com/token",service="registry",scope="repository:foo:pull".
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return strings.ToLower(scheme), params
+}
@@ -0,0 +1,67 @@
+package oci_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/eks-hybrid/internal/oci"
+	"github.com/aws/eks-hybrid/internal/oci/ocitest"
+)
+
+func TestPushWithTokenAuth(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	registry := ocitest.NewRegistry(t)
+	registry.Token = "registry-token"
+	registry.Username = "robot"
+	registry.Password = "secret"
+
+	client := oci.NewClient(oci.Reference{Registry: registry.Host(), Repository: "eks/artifacts"})
+	client.PlainHTTP = true
+	client.Username = "robot"
+	client.Password = "secret"
+
+	content := []byte("kubelet binary")
+	digest := oci.Digest(content)
+	g.Expect(client.BlobExists(ctx, digest)).To(BeFalse())
+	g.Expect(client.PushBlob(ctx, digest, int64(len(content)), bytes.NewReader(content))).To(Succeed())
+	g.Expect(client.BlobExists(ctx, digest)).To(BeTrue())
+	blob, ok := registry.Blob("eks/artifacts", digest)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(blob).To(Equal(content))
+
+	manifest := oci.Manifest{
+		SchemaVersion: 2,
+		MediaType:     oci.MediaTypeImageManifest,
+		Layers:        []oci.Descriptor{{MediaType: "application/octet-stream", Digest: digest, Size: int64(len(content))}},
+	}
+	manifestDigest, err := client.PushManifest(ctx, "1.31", manifest)
+	g.Expect(err).NotTo(HaveOccurred())
+	data, ok := registry.Manifest("eks/artifacts", "1.31")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(oci.Digest(data)).To(Equal(manifestDigest))
+
+	var pushed oci.Manifest
+	g.Expect(json.Unmarshal(data, &pushed)).To(Succeed())
+	g.Expect(pushed.Layers).To(Equal(manifest.Layers))
+}
+
+func TestPushBadCredentials(t *testing.T) {
+	g := NewWithT(t)
+	registry := ocitest.NewRegistry(t)
+	registry.Token = "registry-token"
+	registry.Username = "robot"
+	registry.Password = "secret"
+
+	client := oci.NewClient(oci.Reference{Registry: registry.Host(), Repository: "eks/artifacts"})
+	client.PlainHTTP = true
+	client.Username = "robot"
+	client.Password = "wrong"
+
+	_, err := client.BlobExists(context.Background(), oci.Digest([]byte("kubelet binary")))
+	g.Expect(err).To(MatchError(ContainSubstring("getting registry token")))
+}
@@ -0,0 +1,192 @@
+// Package ocitest provides an in-memory OCI registry for tests.
+package ocitest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Registry is an in-memory stand-in for a registry implementing the OCI distribution spec.
+// It supports blob and manifest pulls and pushes and, optionally, Bearer token authentication.
+type Registry struct {
+	*httptest.Server
+
+	// Token, if set, is required as a Bearer token. Clients get it from the token endpoint
+	// of the registry, with Username and Password if those are set.
+	Token    string
+	Username string
+	Password string
+
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	uploads   int
+}
+
+// NewRegistry starts a Registry over plain HTTP. The server is closed when the test ends.
+func NewRegistry(tb testing.TB) *Registry {
+	r := &Registry{
+		blobs:     map[string][]byte{},
+		manifests: map[string][]byte{},
+	}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serve))
+	tb.Cleanup(r.Server.Close)
+	return r
+}
+
+// Host returns the host:port of the registry, as used in references.
+func (r *Registry) Host() string {
+	return strings.TrimPrefix(r.URL, "http://")
+}
+
+// Blob returns the blob with the given digest in repository.
+func (r *Registry) Blob(repository, digest string) ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, ok := r.blobs[repository+"@"+digest]
+	return data, ok
+}
+
+// AddBlob stores data as a blob of repository and returns its digest.
+func (r *Registry) AddBlob(repository string, data []byte) string {
+	sum := sha256.Sum256(data)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.blobs[repository+"@"+digest] = data
+	return digest
+}
+
+// Manifest returns the manifest pushed to repository under reference, a tag or digest.
+func (r *Registry) Manifest(repository, reference string) ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, ok := r.manifests[repository+":"+reference]
+	return data, ok
+}
+
+// Uploads returns the number of completed blob uploads.
+func (r *Registry) Uploads() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.uploads
+}
+
+func (r *Registry) serve(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		r.serveToken(w, req)
+		return
+	}
+	if !r.authorized(req) {
+		if r.Token != "" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test-registry"`, r.URL))
+		} else {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test-registry"`)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	switch {
+	case strings.Contains(path, "/blobs/uploads/"):
+		r.serveUpload(w, req, path[:strings.Index(path, "/blobs/uploads/")])
+	case strings.Contains(path, "/blobs/"):
+		repository, digest, _ := strings.Cut(path, "/blobs/")
+		r.serveContent(w, req, r.blobs, repository+"@"+digest)
+	case strings.Contains(path, "/manifests/"):
+		repository, reference, _ := strings.Cut(path, "/manifests/")
+		if req.Method == http.MethodPut {
+			r.pushManifest(w, req, repository, reference)
+			return
+		}
+		r.serveContent(w, req, r.manifests, repository+":"+reference)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (r *Registry) authorized(req *http.Request) bool {
+	if r.Token != "" {
+		return req.Header.Get("Authorization") == "Bearer "+r.Token
+	}
+	if r.Username != "" {
+		username, password, ok := req.BasicAuth()
+		return ok && username == r.Username && password == r.Password
+	}
+	return true
+}
+
+func (r *Registry) serveToken(w http.ResponseWriter, req *http.Request) {
+	if r.Username != "" {
+		username, password, ok := req.BasicAuth()
+		if !ok || username != r.Username || password != r.Password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	fmt.Fprintf(w, `{"token":%q}`, r.Token)
+}
+
+func (r *Registry) serveUpload(w http.ResponseWriter, req *http.Request, repository string) {
+	switch req.Method {
+	case http.MethodPost:
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/session", repository))
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodPut:
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		sum := sha256.Sum256(data)
+		digest := "sha256:" + hex.EncodeToString(sum[:])
+		if req.URL.Query().Get("digest") != digest {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.mu.Lock()
+		r.blobs[repository+"@"+digest] = data
+		r.uploads++
+		r.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *Registry) pushManifest(w http.ResponseWriter, req *http.Request, repository, reference string) {
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	sum := sha256.Sum256(data)
+	r.mu.Lock()
+	r.manifests[repository+":"+reference] = data
+	r.manifests[repository+":sha256:"+hex.EncodeToString(sum[:])] = data
+	r.mu.Unlock()
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (r *Registry) serveContent(w http.ResponseWriter, req *http.Request, store map[string][]byte, key string) {
+	r.mu.Lock()
+	data, ok := store[key]
+	r.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+	if req.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(data)
+}
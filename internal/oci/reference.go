@@ -0,0 +1,61 @@
+package oci
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scheme is the URI scheme of OCI references.
+const Scheme = "oci://"
+
+// Reference identifies a repository in a registry and, optionally, a tag or digest in it.
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses references like oci://registry/repository, with an optional
+// :tag or @sha256:<hex> suffix. The oci:// prefix is optional.
+func ParseReference(uri string) (Reference, error) {
+	rest := strings.TrimPrefix(uri, Scheme)
+	registry, repository, ok := strings.Cut(rest, "/")
+	if !ok || registry == "" || repository == "" {
+		return Reference{}, fmt.Errorf("invalid OCI reference %s, expected oci://registry/repository", uri)
+	}
+
+	ref := Reference{Registry: registry}
+	if name, digest, ok := strings.Cut(repository, "@"); ok {
+		if !strings.HasPrefix(digest, "sha256:") || len(digest) != len("sha256:")+64 {
+			return Reference{}, fmt.Errorf("invalid digest in OCI reference %s, expected sha256:<hex>", uri)
+		}
+		repository, ref.Digest = name, digest
+	}
+	// A colon after the last slash separates the tag. Colons before it belong to a registry port.
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository, ref.Tag = repository[:i], repository[i+1:]
+	}
+	if repository == "" || repository != strings.ToLower(repository) {
+		return Reference{}, fmt.Errorf("invalid repository in OCI reference %s, repositories must be lowercase", uri)
+	}
+	ref.Repository = repository
+	return ref, nil
+}
+
+// String returns the reference as an oci:// URI.
+func (r Reference) String() string {
+	s := Scheme + r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// WithDigest returns a reference to the content with the given digest in the same repository.
+func (r Reference) WithDigest(digest string) Reference {
+	return Reference{Registry: r.Registry, Repository: r.Repository, Digest: digest}
+}
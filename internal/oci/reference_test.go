@@ -0,0 +1,64 @@
+package oci_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/eks-hybrid/internal/oci"
+)
+
+func TestParseReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	tests := []struct {
+		name    string
+		uri     string
+		want    oci.Reference
+		wantErr string
+	}{
+		{
+			name: "repository",
+			uri:  "oci://registry.example.com/eks/artifacts",
+			want: oci.Reference{Registry: "registry.example.com", Repository: "eks/artifacts"},
+		},
+		{
+			name: "tag and registry port",
+			uri:  "oci://localhost:5000/artifacts:1.31",
+			want: oci.Reference{Registry: "localhost:5000", Repository: "artifacts", Tag: "1.31"},
+		},
+		{
+			name: "digest",
+			uri:  "oci://registry.example.com/artifacts@" + digest,
+			want: oci.Reference{Registry: "registry.example.com", Repository: "artifacts", Digest: digest},
+		},
+		{
+			name:    "missing repository",
+			uri:     "oci://registry.example.com",
+			wantErr: "expected oci://registry/repository",
+		},
+		{
+			name:    "invalid digest",
+			uri:     "oci://registry.example.com/artifacts@sha256:abc",
+			wantErr: "invalid digest",
+		},
+		{
+			name:    "uppercase repository",
+			uri:     "oci://registry.example.com/Artifacts",
+			wantErr: "must be lowercase",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ref, err := oci.ParseReference(tc.uri)
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ref).To(Equal(tc.want))
+			g.Expect(ref.String()).To(Equal(tc.uri))
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+// UpgradeDir is where nodeadm upgrade stores the snapshot taken before upgrading.
+const UpgradeDir = "/opt/nodeadm/upgrade-snapshot"
+
+const (
+	manifestFile = "snapshot.yaml"
+	dataDir      = "data"
+)
+
+// Snapshot is a copy of a set of host paths that can be restored later.
+type Snapshot struct {
+	CreatedAt time.Time `json:"createdAt"`
+	// Description is a free form description of the state captured, like the versions installed.
+	Description string  `json:"description,omitempty"`
+	Entries     []Entry `json:"entries"`
+
+	dir string
+}
+
+// Entry is a single path captured in a snapshot.
+type Entry struct {
+	Path string `json:"path"`
+	// Existed is false when the path didn't exist when the snapshot was taken.
+	// Restoring the snapshot removes it.
+	Existed bool `json:"existed"`
+}
+
+// Take copies paths into dir, replacing any snapshot previously stored there.
+// Directories are copied recursively and symlinks are preserved.
+func Take(dir, description string, paths []string) (*Snapshot, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, errors.Wrap(err, "removing previous snapshot")
+	}
+
+	snapshot := &Snapshot{
+		CreatedAt:   time.Now().UTC(),
+		Description: description,
+		dir:         dir,
+	}
+	for _, path := range paths {
+		_, err := os.Lstat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			snapshot.Entries = append(snapshot.Entries, Entry{Path: path})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if err := copyPath(path, snapshot.dataPath(path)); err != nil {
+			return nil, errors.Wrapf(err, "copying %s to snapshot", path)
+		}
+		snapshot.Entries = append(snapshot.Entries, Entry{Path: path, Existed: true})
+	}
+
+	// The manifest is written last so a partial snapshot is never loaded.
+	data, err := yaml.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if err := util.WriteFileWithDir(filepath.Join(dir, manifestFile), data, 0o644); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Load reads the snapshot stored in dir.
+func Load(dir string) (*Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var snapshot Snapshot
+	if err := yaml.Unmarshal(data, &snapshot); err != nil {
+		return nil, errors.Wrap(err, "invalid yaml data in snapshot")
+	}
+	snapshot.dir = dir
+	return &snapshot, nil
+}
+
+// Restore puts back every path captured in the snapshot, removing the current content.
+// Paths that didn't exist when the snapshot was taken are removed.
+func (s *Snapshot) Restore() error {
+	for _, entry := range s.Entries {
+		if err := os.RemoveAll(entry.Path); err != nil {
+			return errors.Wrapf(err, "removing %s", entry.Path)
+		}
+		if !entry.Existed {
+			continue
+		}
+		if err := copyPath(s.dataPath(entry.Path), entry.Path); err != nil {
+			return errors.Wrapf(err, "restoring %s", entry.Path)
+		}
+	}
+	return nil
+}
+
+// Remove deletes the snapshot from disk.
+func (s *Snapshot) Remove() error {
+	return os.RemoveAll(s.dir)
+}
+
+func (s *Snapshot) dataPath(path string) string {
+	return filepath.Join(s.dir, dataDir, path)
+}
+
+// copyPath copies src to dst. If src is a directory, its content is copied recursively.
+func copyPath(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relPath)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			return fmt.Errorf("unsupported file type %s for %s", info.Mode().Type(), path)
+		}
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,61 @@
+package snapshot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/eks-hybrid/internal/snapshot"
+)
+
+func TestTakeAndRestore(t *testing.T) {
+	g := NewWithT(t)
+	root := t.TempDir()
+	snapshotDir := filepath.Join(t.TempDir(), "snapshot")
+
+	binPath := filepath.Join(root, "usr/bin/kubelet")
+	configDir := filepath.Join(root, "etc/kubernetes/kubelet")
+	linkPath := filepath.Join(root, "etc/kubernetes/current")
+	newPath := filepath.Join(root, "etc/kubernetes/new.conf")
+
+	writeFile(g, binPath, "old-kubelet", 0o755)
+	writeFile(g, filepath.Join(configDir, "config.json"), "old-config", 0o644)
+	writeFile(g, filepath.Join(configDir, "config.json.d/10-overrides.json"), "old-overrides", 0o644)
+	g.Expect(os.Symlink(configDir, linkPath)).To(Succeed())
+
+	_, err := snapshot.Take(snapshotDir, "test", []string{binPath, configDir, linkPath, newPath})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	writeFile(g, binPath, "new-kubelet", 0o755)
+	g.Expect(os.RemoveAll(filepath.Join(configDir, "config.json.d"))).To(Succeed())
+	writeFile(g, filepath.Join(configDir, "config.json"), "new-config", 0o644)
+	g.Expect(os.Remove(linkPath)).To(Succeed())
+	writeFile(g, newPath, "new", 0o644)
+
+	snap, err := snapshot.Load(snapshotDir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(snap.Description).To(Equal("test"))
+	g.Expect(snap.Restore()).To(Succeed())
+
+	g.Expect(os.ReadFile(binPath)).To(BeEquivalentTo("old-kubelet"))
+	info, err := os.Stat(binPath)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o755)))
+	g.Expect(os.ReadFile(filepath.Join(configDir, "config.json"))).To(BeEquivalentTo("old-config"))
+	g.Expect(os.ReadFile(filepath.Join(configDir, "config.json.d/10-overrides.json"))).To(BeEquivalentTo("old-overrides"))
+	g.Expect(os.Readlink(linkPath)).To(Equal(configDir))
+	g.Expect(newPath).NotTo(BeAnExistingFile())
+}
+
+func TestLoadMissingSnapshot(t *testing.T) {
+	g := NewWithT(t)
+	_, err := snapshot.Load(t.TempDir())
+	g.Expect(os.IsNotExist(err)).To(BeTrue())
+}
+
+func writeFile(g *WithT, path, content string, perm os.FileMode) {
+	g.Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+	g.Expect(os.WriteFile(path, []byte(content), perm)).To(Succeed())
+}
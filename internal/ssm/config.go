@@ -52,7 +52,7 @@ func (s *ssm) registerMachine(ctx context.Context, cfg *api.NodeConfig) error {
 			)
 		}
 
-		if err := cmd.Retry(ctx, cmdBuilder, SSMRegistrationBackoff); err != nil {
+		if err := cmd.Retry(ctx, cmdBuilder, SSMRegistrationBackoff, cfg.Spec.Hybrid.SSM.ActivationCode); err != nil {
 			return fmt.Errorf("failed to register machine with SSM after multiple attempts: %w", err)
 		}
 	}
@@ -17,7 +17,7 @@ import (
 const awsSharedCredentialsFileEnvVar = "AWS_SHARED_CREDENTIALS_FILE"
 
 func WaitForAWSConfig(ctx context.Context, nodeConfig *api.NodeConfig, backoff time.Duration) (aws.Config, error) {
-	credsFile := awsCredsFile()
+	credsFile := CredentialsFilePath()
 	for !file.Exists(credsFile) {
 		select {
 		case <-ctx.Done():
@@ -38,7 +38,8 @@ func WaitForAWSConfig(ctx context.Context, nodeConfig *api.NodeConfig, backoff t
 	)
 }
 
-func awsCredsFile() string {
+// CredentialsFilePath returns the AWS shared credentials file the SSM agent writes to.
+func CredentialsFilePath() string {
 	credsFile := awsCredentialsFilePath
 	if cFile, ok := os.LookupEnv(awsSharedCredentialsFileEnvVar); ok {
 		credsFile = cFile
@@ -11,6 +11,7 @@ import (
 
 	"github.com/aws/eks-hybrid/internal/api"
 	"github.com/aws/eks-hybrid/internal/daemon"
+	"github.com/aws/eks-hybrid/internal/ledger"
 	"github.com/aws/eks-hybrid/internal/system"
 )
 
@@ -90,7 +91,7 @@ func (s *ssm) EnsureRunning(ctx context.Context) error {
 func (s *ssm) PostLaunch() error {
 	if s.nodeConfig.Spec.Hybrid.EnableCredentialsFile {
 		s.logger.Info("Creating symlink for AWS credentials", zap.String("Symbolic link path", symlinkedAWSConfigPath))
-		err := os.MkdirAll(eksHybridPath, 0o755)
+		err := ledger.MkdirAll(eksHybridPath, 0o755)
 		if err != nil {
 			return fmt.Errorf("creating path: %v", err)
 		}
@@ -104,6 +105,9 @@ func (s *ssm) PostLaunch() error {
 		if err != nil {
 			return fmt.Errorf("creating symlink: %v", err)
 		}
+		if err := ledger.RecordSymlink(symlinkedAWSConfigPath, defaultAWSConfigPath); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -118,6 +122,12 @@ func (s *ssm) Name() string {
 	return SsmDaemonName
 }
 
+// DaemonName returns the name of the SSM agent daemon on this OS.
+func DaemonName() string {
+	setDaemonName()
+	return SsmDaemonName
+}
+
 func setDaemonName() {
 	osToDaemonName := map[string]string{
 		system.UbuntuOsName: "snap.amazon-ssm-agent.amazon-ssm-agent",
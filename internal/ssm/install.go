@@ -18,6 +18,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/aws/eks-hybrid/internal/artifact"
+	"github.com/aws/eks-hybrid/internal/ledger"
 	"github.com/aws/eks-hybrid/internal/tracker"
 	"github.com/aws/eks-hybrid/internal/util"
 	"github.com/aws/eks-hybrid/internal/util/cmd"
@@ -261,7 +262,7 @@ func configureSSMAgent(installRoot string) error {
 		}
 
 		// create empty directory, if doesn't exist
-		if err := os.MkdirAll(filepath.Dir(configFile), 0o700); err != nil {
+		if err := ledger.MkdirAll(filepath.Dir(configFile), 0o700); err != nil {
 			return fmt.Errorf("failed to create config directory: %w", err)
 		}
 	} else {
@@ -297,5 +298,5 @@ func configureSSMAgent(installRoot string) error {
 		return fmt.Errorf("failed to write config file %s: %w", configFile, err)
 	}
 
-	return nil
+	return ledger.RecordFile(configFile, data != nil)
 }
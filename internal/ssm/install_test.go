@@ -144,6 +144,7 @@ func TestInstall(t *testing.T) {
 					return server.URL + "/latest/linux_amd64/ssm-setup-cli", nil
 				}),
 				ssm.WithPublicKey(publicKey),
+				ssm.WithStagingDir(t.TempDir()),
 			)
 
 			tr := &tracker.Tracker{Artifacts: &tracker.InstalledArtifacts{}}
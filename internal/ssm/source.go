@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"runtime"
 
@@ -87,13 +88,21 @@ func WithDnsSuffix(dnsSuffix string) SSMInstallerOption {
 	}
 }
 
+// WithStagingDir overrides the directory the installer is downloaded to.
+func WithStagingDir(dir string) SSMInstallerOption {
+	return func(s *ssmInstallerSource) {
+		s.stagingDir = dir
+	}
+}
+
 // SSMInstaller provides a Source that retrieves the SSM installer from the official
 // release endpoint.
 func NewSSMInstaller(logger *zap.Logger, region string, opts ...SSMInstallerOption) Source {
 	s := &ssmInstallerSource{
-		region:    region,
-		logger:    logger,
-		publicKey: ssmPublicGPGKey,
+		region:     region,
+		logger:     logger,
+		publicKey:  ssmPublicGPGKey,
+		stagingDir: awsinternal.StagingDir,
 	}
 
 	// Set default URL builder
@@ -112,6 +121,9 @@ type ssmInstallerSource struct {
 	logger      *zap.Logger
 	buildSSMURL func() (string, error)
 	publicKey   string
+	// stagingDir is where the installer is downloaded to, so an interrupted download is
+	// resumed by the next install.
+	stagingDir string
 }
 
 func (s ssmInstallerSource) GetSSMInstaller(ctx context.Context) (io.ReadCloser, error) {
@@ -122,7 +134,10 @@ func (s ssmInstallerSource) GetSSMInstaller(ctx context.Context) (io.ReadCloser,
 
 	s.logger.Info("Downloading SSM installer", zap.String("region", s.region), zap.String("url", endpoint))
 
-	obj, err := util.GetHttpFileReader(ctx, endpoint)
+	if err := os.MkdirAll(s.stagingDir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating staging directory: %w", err)
+	}
+	obj, err := util.DownloadFile(ctx, endpoint, awsinternal.PartialPath(s.stagingDir, "ssm-setup-cli"))
 	if err != nil {
 		return nil, err
 	}
@@ -158,8 +173,16 @@ func (s ssmInstallerSource) defaultBuildSSMURL() (string, error) {
 		dnsSuffix = awsinternal.GetPartitionDNSSuffix(partition)
 	}
 
-	platform := fmt.Sprintf("%s_%s", variant, runtime.GOARCH)
-	return fmt.Sprintf("https://amazon-ssm-%s.s3.%s.%s/latest/%s/ssm-setup-cli", s.region, s.region, dnsSuffix, platform), nil
+	return InstallerURL(s.region, dnsSuffix, variant, runtime.GOARCH), nil
+}
+
+// InstallerVariants are the platform variants the SSM installer is published for.
+var InstallerVariants = []string{"linux", "debian"}
+
+// InstallerURL returns the URL of the SSM installer for a platform variant and architecture.
+func InstallerURL(region, dnsSuffix, variant, arch string) string {
+	platform := fmt.Sprintf("%s_%s", variant, arch)
+	return fmt.Sprintf("https://amazon-ssm-%s.s3.%s.%s/latest/%s/ssm-setup-cli", region, region, dnsSuffix, platform)
 }
 
 // detectPlatformVariant returns a portion of the SSM installers URL that is dependent on the
@@ -10,6 +10,7 @@ import (
 	. "github.com/onsi/gomega"
 	"go.uber.org/zap"
 
+	"github.com/aws/eks-hybrid/internal/aws"
 	"github.com/aws/eks-hybrid/internal/ssm"
 	"github.com/aws/eks-hybrid/internal/test"
 )
@@ -78,8 +79,10 @@ func TestGetSSMInstaller(t *testing.T) {
 				}
 			}
 
+			stagingDir := t.TempDir()
 			source := ssm.NewSSMInstaller(zap.NewNop(), "test-region",
 				ssm.WithURLBuilder(urlBuilder),
+				ssm.WithStagingDir(stagingDir),
 			)
 			reader, err := source.GetSSMInstaller(context.Background())
 
@@ -95,6 +98,9 @@ func TestGetSSMInstaller(t *testing.T) {
 			data, err := io.ReadAll(reader)
 			g.Expect(err).NotTo(HaveOccurred())
 			g.Expect(string(data)).To(Equal(tt.serverResponse))
+			// The installer is kept in the staging directory until installed, so an
+			// interrupted install resumes the download.
+			g.Expect(aws.PartialPath(stagingDir, "ssm-setup-cli")).To(BeAnExistingFile())
 		})
 	}
 }
@@ -0,0 +1,89 @@
+package status
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// PrintText writes a human readable report of the status to w.
+func PrintText(w io.Writer, status *NodeStatus, now time.Time) error {
+	if !status.Installed {
+		_, err := fmt.Fprintln(w, "No nodeadm components installed. Please use nodeadm install and nodeadm init commands to bootstrap a node")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tINSTALLED\tVERSION\tSHA256\tURI")
+	for _, c := range status.Components {
+		name := c.Name
+		if c.Source != "" {
+			name = fmt.Sprintf("%s (%s)", c.Name, c.Source)
+		}
+		fmt.Fprintf(tw, "%s\t%t\t%s\t%s\t%s\n", name, c.Installed, valueOrError(c.Version, c.Error), valueOrError(shortChecksum(c.Sha256), ""), valueOrError(c.URI, ""))
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "DAEMON\tSTATUS")
+	for _, d := range status.Daemons {
+		fmt.Fprintf(tw, "%s\t%s\n", d.Name, valueOrError(string(d.Status), d.Error))
+	}
+	fmt.Fprintln(tw)
+
+	if status.Credentials != nil {
+		fmt.Fprintf(tw, "Credential provider:\t%s\n", status.Credentials.Provider)
+		fmt.Fprintf(tw, "Credentials expire:\t%s\n", credentialsExpiration(status.Credentials, now))
+	}
+
+	fmt.Fprintf(tw, "Node:\t%s\n", nodeSummary(status.Node))
+	return tw.Flush()
+}
+
+func valueOrError(value, err string) string {
+	if err != "" {
+		return "error: " + err
+	}
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// shortChecksum abbreviates a checksum for the text report, the JSON report has it in full.
+func shortChecksum(sha256 string) string {
+	if len(sha256) > 12 {
+		return sha256[:12]
+	}
+	return sha256
+}
+
+func credentialsExpiration(credentials *Credentials, now time.Time) string {
+	switch {
+	case credentials.Error != "":
+		return "error: " + credentials.Error
+	case credentials.Expires == nil:
+		return "not reported"
+	case credentials.Expires.Before(now):
+		return fmt.Sprintf("%s (expired)", credentials.Expires.Format(time.RFC3339))
+	default:
+		return fmt.Sprintf("%s (in %s)", credentials.Expires.Format(time.RFC3339), credentials.Expires.Sub(now).Round(time.Second))
+	}
+}
+
+func nodeSummary(node Node) string {
+	name := node.Name
+	if name == "" {
+		name = "-"
+	}
+	switch {
+	case node.Error != "":
+		return fmt.Sprintf("%s, error: %s", name, node.Error)
+	case !node.Registered:
+		return fmt.Sprintf("%s, not registered", name)
+	case node.Ready:
+		return fmt.Sprintf("%s, registered, Ready", name)
+	default:
+		return fmt.Sprintf("%s, registered, NotReady", name)
+	}
+}
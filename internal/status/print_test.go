@@ -0,0 +1,74 @@
+package status_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/eks-hybrid/internal/creds"
+	"github.com/aws/eks-hybrid/internal/daemon"
+	"github.com/aws/eks-hybrid/internal/status"
+)
+
+func TestPrintText(t *testing.T) {
+	g := NewWithT(t)
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	expires := now.Add(45 * time.Minute)
+	nodeStatus := &status.NodeStatus{
+		Installed: true,
+		Components: []status.Component{
+			{Name: "containerd", Installed: true, Source: "distro", Version: "1.7.27"},
+			{Name: "kubelet", Installed: true, Error: "exec: \"kubelet\": executable file not found in $PATH"},
+			{Name: "kubectl", Installed: false},
+			{
+				Name:      "cni-plugins",
+				Installed: true,
+				Version:   "v1.6.2",
+				URI:       "https://hybrid-assets.eks.amazonaws.com/releases/cni-plugins.tgz",
+			},
+			{
+				Name:      "aws-iam-authenticator",
+				Installed: true,
+				Version:   "v0.6.29",
+				URI:       "https://hybrid-assets.eks.amazonaws.com/releases/aws-iam-authenticator",
+				Sha256:    "4a5b0b0c1f6b0d9e3e8c1a2f3d4e5f60718293a4b5c6d7e8f90123456789abcd",
+			},
+		},
+		Daemons: []status.Daemon{
+			{Name: "containerd", Status: daemon.DaemonStatusRunning},
+			{Name: "kubelet", Status: daemon.DaemonStatusStopped},
+		},
+		Credentials: &status.Credentials{
+			Provider: creds.IamRolesAnywhereCredentialProvider,
+			Expires:  &expires,
+		},
+		Node: status.Node{Name: "mi-123", Registered: true, Ready: true},
+	}
+
+	var out bytes.Buffer
+	g.Expect(status.PrintText(&out, nodeStatus, now)).To(Succeed())
+	g.Expect(out.String()).To(Equal(`COMPONENT              INSTALLED  VERSION                                                     SHA256        URI
+containerd (distro)    true       1.7.27                                                      -             -
+kubelet                true       error: exec: "kubelet": executable file not found in $PATH  -             -
+kubectl                false      -                                                           -             -
+cni-plugins            true       v1.6.2                                                      -             https://hybrid-assets.eks.amazonaws.com/releases/cni-plugins.tgz
+aws-iam-authenticator  true       v0.6.29                                                     4a5b0b0c1f6b  https://hybrid-assets.eks.amazonaws.com/releases/aws-iam-authenticator
+
+DAEMON      STATUS
+containerd  running
+kubelet     stopped
+
+Credential provider:  iam-ra
+Credentials expire:   2025-01-01T10:45:00Z (in 45m0s)
+Node:                 mi-123, registered, Ready
+`))
+}
+
+func TestPrintTextNotInstalled(t *testing.T) {
+	g := NewWithT(t)
+	var out bytes.Buffer
+	g.Expect(status.PrintText(&out, &status.NodeStatus{}, time.Now())).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("No nodeadm components installed"))
+}
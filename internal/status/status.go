@@ -0,0 +1,264 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/aws/eks-hybrid/internal/artifact"
+	"github.com/aws/eks-hybrid/internal/containerd"
+	"github.com/aws/eks-hybrid/internal/creds"
+	"github.com/aws/eks-hybrid/internal/daemon"
+	"github.com/aws/eks-hybrid/internal/iamrolesanywhere"
+	"github.com/aws/eks-hybrid/internal/kubelet"
+	"github.com/aws/eks-hybrid/internal/node/hybrid"
+	"github.com/aws/eks-hybrid/internal/nodevalidator"
+	"github.com/aws/eks-hybrid/internal/ssm"
+	"github.com/aws/eks-hybrid/internal/tracker"
+	"github.com/aws/eks-hybrid/internal/util"
+)
+
+// NodeStatus is a point in time report of the state of a hybrid node.
+type NodeStatus struct {
+	// Installed is false when the tracker doesn't exist, meaning nodeadm install hasn't run.
+	Installed   bool         `json:"installed"`
+	Components  []Component  `json:"components"`
+	Daemons     []Daemon     `json:"daemons"`
+	Credentials *Credentials `json:"credentials,omitempty"`
+	Node        Node         `json:"node"`
+}
+
+// Component is an artifact tracked by nodeadm install.
+type Component struct {
+	Name      string `json:"name"`
+	Installed bool   `json:"installed"`
+	// Source is only set for containerd: distro, docker or none.
+	Source string `json:"source,omitempty"`
+	// Version is read from the binary on disk for kubelet and containerd. For the other
+	// components it is the version recorded when they were installed.
+	Version string `json:"version,omitempty"`
+	// URI is where the component was downloaded from, without credentials, and Sha256 the
+	// checksum of the file installed, as recorded by nodeadm. Archives have no checksum.
+	URI         string     `json:"uri,omitempty"`
+	Sha256      string     `json:"sha256,omitempty"`
+	InstalledAt *time.Time `json:"installedAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// Daemon is a systemd unit managed by nodeadm.
+type Daemon struct {
+	Name   string              `json:"name"`
+	Status daemon.DaemonStatus `json:"status"`
+	Error  string              `json:"error,omitempty"`
+}
+
+// Credentials reports the AWS credentials the node uses to authenticate with the cluster.
+type Credentials struct {
+	Provider creds.CredentialProvider `json:"provider"`
+	// Expires is only set when the credentials source reports an expiration.
+	Expires *time.Time `json:"expires,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+// Node reports the Node object registered by the kubelet.
+type Node struct {
+	Name       string `json:"name,omitempty"`
+	Registered bool   `json:"registered"`
+	Ready      bool   `json:"ready"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Collector gathers the state of the node from the tracker, the host and the cluster.
+// Failures reading a single piece of state are reported in the status instead of
+// failing the whole collection.
+type Collector struct {
+	DaemonManager daemon.DaemonManager
+	// AwsConfigPath is the AWS config written for IAM Roles Anywhere.
+	// Defaults to iamrolesanywhere.DefaultAWSConfigPath.
+	AwsConfigPath string
+}
+
+func (c *Collector) Collect(ctx context.Context) (*NodeStatus, error) {
+	status := &NodeStatus{}
+
+	installed, err := tracker.GetInstalledArtifacts()
+	if errors.Is(err, fs.ErrNotExist) {
+		return status, nil
+	} else if err != nil {
+		return nil, err
+	}
+	status.Installed = true
+	artifacts := installed.Artifacts
+
+	status.Components = c.components(artifacts)
+	addRecords(status.Components, installed.Records)
+	status.Daemons = c.daemons(artifacts)
+
+	if provider, err := creds.GetCredentialProviderFromInstalledArtifacts(artifacts); err == nil {
+		status.Credentials = c.credentials(ctx, provider)
+	}
+
+	if artifacts.Kubelet {
+		status.Node = c.node(ctx)
+	}
+
+	return status, nil
+}
+
+func (c *Collector) components(artifacts *tracker.InstalledArtifacts) []Component {
+	containerdComponent := Component{
+		Name:      artifact.Containerd,
+		Installed: artifacts.Containerd != tracker.ContainerdSourceNone,
+		Source:    string(artifacts.Containerd),
+	}
+	if containerdComponent.Installed {
+		containerdComponent.Version, containerdComponent.Error = versionOrError(containerd.GetContainerdVersion())
+	}
+
+	kubeletComponent := Component{Name: artifact.Kubelet, Installed: artifacts.Kubelet}
+	if kubeletComponent.Installed {
+		kubeletComponent.Version, kubeletComponent.Error = versionOrError(kubelet.GetKubeletVersion())
+	}
+
+	return []Component{
+		containerdComponent,
+		kubeletComponent,
+		{Name: artifact.Kubectl, Installed: artifacts.Kubectl},
+		{Name: artifact.CniPlugins, Installed: artifacts.CniPlugins},
+		{Name: artifact.ImageCredentialProvider, Installed: artifacts.ImageCredentialProvider},
+		{Name: artifact.IamAuthenticator, Installed: artifacts.IamAuthenticator},
+		{Name: artifact.IamRolesAnywhere, Installed: artifacts.IamRolesAnywhere},
+		{Name: artifact.Ssm, Installed: artifacts.Ssm},
+		{Name: artifact.Iptables, Installed: artifacts.Iptables},
+	}
+}
+
+func versionOrError(version string, err error) (string, string) {
+	if err != nil {
+		return "", err.Error()
+	}
+	return version, ""
+}
+
+// addRecords fills in the provenance nodeadm recorded for the installed components.
+func addRecords(components []Component, records map[string]*tracker.ArtifactRecord) {
+	for i := range components {
+		record, ok := records[components[i].Name]
+		if !ok || !components[i].Installed {
+			continue
+		}
+		if components[i].Version == "" && components[i].Error == "" {
+			components[i].Version = record.Version
+		}
+		components[i].URI = util.RedactURI(record.URI)
+		components[i].Sha256 = record.Sha256
+		if !record.InstalledAt.IsZero() {
+			installedAt := record.InstalledAt
+			components[i].InstalledAt = &installedAt
+		}
+	}
+}
+
+func (c *Collector) daemons(artifacts *tracker.InstalledArtifacts) []Daemon {
+	var names []string
+	if artifacts.Containerd != tracker.ContainerdSourceNone {
+		names = append(names, containerd.ContainerdDaemonName)
+	}
+	if artifacts.Kubelet {
+		names = append(names, kubelet.KubeletDaemonName)
+	}
+	if artifacts.Ssm {
+		names = append(names, ssm.SsmDaemonName)
+	}
+	if artifacts.IamRolesAnywhere {
+		names = append(names, iamrolesanywhere.DaemonName)
+	}
+
+	daemons := make([]Daemon, 0, len(names))
+	for _, name := range names {
+		d := Daemon{Name: name}
+		status, err := c.DaemonManager.GetDaemonStatus(name)
+		if err != nil {
+			d.Status = daemon.DaemonStatusUnknown
+			d.Error = err.Error()
+		} else {
+			d.Status = status
+		}
+		daemons = append(daemons, d)
+	}
+	return daemons
+}
+
+func (c *Collector) credentials(ctx context.Context, provider creds.CredentialProvider) *Credentials {
+	credentials := &Credentials{Provider: provider}
+
+	opts := []func(*config.LoadOptions) error{
+		// Avoid falling back to IMDS if the machine happens to be an EC2 instance.
+		config.WithEC2IMDSClientEnableState(imds.ClientDisabled),
+	}
+	switch provider {
+	case creds.SsmCredentialProvider:
+		opts = append(opts,
+			config.WithSharedCredentialsFiles([]string{ssm.CredentialsFilePath()}),
+			config.WithSharedConfigFiles([]string{}),
+		)
+	case creds.IamRolesAnywhereCredentialProvider:
+		awsConfigPath := c.AwsConfigPath
+		if awsConfigPath == "" {
+			awsConfigPath = iamrolesanywhere.DefaultAWSConfigPath
+		}
+		opts = append(opts,
+			config.WithSharedConfigFiles([]string{awsConfigPath}),
+			config.WithSharedCredentialsFiles([]string{iamrolesanywhere.EksHybridAwsCredentialsPath}),
+			config.WithSharedConfigProfile(iamrolesanywhere.ProfileName),
+		)
+	}
+
+	awsConfig, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		credentials.Error = err.Error()
+		return credentials
+	}
+	retrieved, err := awsConfig.Credentials.Retrieve(ctx)
+	if err != nil {
+		credentials.Error = err.Error()
+		return credentials
+	}
+	if retrieved.CanExpire {
+		expires := retrieved.Expires.UTC()
+		credentials.Expires = &expires
+	}
+	return credentials
+}
+
+func (c *Collector) node(ctx context.Context) Node {
+	var node Node
+	name, err := kubelet.GetNodeName()
+	if err != nil {
+		node.Error = err.Error()
+		return node
+	}
+	node.Name = name
+
+	client, err := hybrid.BuildKubeClient()
+	if err != nil {
+		node.Error = err.Error()
+		return node
+	}
+	k8sNode, err := client.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return node
+	} else if err != nil {
+		node.Error = err.Error()
+		return node
+	}
+	node.Registered = true
+	node.Ready = nodevalidator.IsNodeReady(k8sNode)
+	return node
+}
@@ -11,6 +11,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/aws/eks-hybrid/internal/api"
+	"github.com/aws/eks-hybrid/internal/ledger"
 	"github.com/aws/eks-hybrid/internal/util"
 )
 
@@ -81,12 +82,19 @@ func (a *networkingAspect) ensureEKSNetworkConfiguration() error {
 		return fmt.Errorf("failed to generate eks_primary_eni_only network configuration: %w", err)
 	}
 	zap.L().Info("writing eks_primary_eni_only network configuration")
-	if err := os.MkdirAll(networkCfgDropInDir, networkConfDropInDirPerms); err != nil {
+	if err := ledger.MkdirAll(util.HostPath(networkCfgDropInDir), networkConfDropInDirPerms); err != nil {
 		return fmt.Errorf("failed to create network configuration drop-in directory %s: %w", networkCfgDropInDir, err)
 	}
-	if err := os.WriteFile(eksPrimaryENIOnlyConfPathName, eksPrimaryENIOnlyConfContent, networkConfFilePerms); err != nil {
+	preexisting := ledger.Exists(util.HostPath(eksPrimaryENIOnlyConfPathName))
+	if err := os.WriteFile(util.HostPath(eksPrimaryENIOnlyConfPathName), eksPrimaryENIOnlyConfContent, networkConfFilePerms); err != nil {
 		return fmt.Errorf("failed to write eks_primary_eni_only network configuration: %w", err)
 	}
+	if err := ledger.RecordFile(util.HostPath(eksPrimaryENIOnlyConfPathName), preexisting); err != nil {
+		return err
+	}
+	if util.DryRun() {
+		return nil
+	}
 	if err := a.reloadNetworkConfigurations(); err != nil {
 		return fmt.Errorf("failed to reload network configurations: %w", err)
 	}
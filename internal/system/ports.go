@@ -7,6 +7,7 @@ import (
 
 	"github.com/aws/eks-hybrid/internal/api"
 	"github.com/aws/eks-hybrid/internal/firewall"
+	"github.com/aws/eks-hybrid/internal/util"
 )
 
 const (
@@ -46,6 +47,10 @@ func (s *portsAspect) Name() string {
 }
 
 func (s *portsAspect) Setup() error {
+	if util.DryRun() {
+		s.logger.Info("Dry run: skipping firewall rules")
+		return nil
+	}
 	firewallEnabled, err := s.firewallManager.IsEnabled()
 	if err != nil {
 		s.logger.Warn("Failed to get firewall status", zap.Error(err))
@@ -13,12 +13,14 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/aws/eks-hybrid/internal/api"
+	"github.com/aws/eks-hybrid/internal/util"
 )
 
 const (
 	swapAspectName    = "swap"
 	swapTypePartition = "partition"
 	swapTypeFile      = "file"
+	fstabPath         = "/etc/fstab"
 )
 
 type swapAspect struct {
@@ -49,7 +51,9 @@ func (s *swapAspect) Setup() error {
 	if hasSwapPartition {
 		return fmt.Errorf("failed to disable swap: partition type swap found on the host")
 	}
-	if err = s.swapOff(swapfiles); err != nil {
+	if util.DryRun() {
+		s.logger.Info("Dry run: skipping swapoff")
+	} else if err = s.swapOff(swapfiles); err != nil {
 		return err
 	}
 	return disableSwapOnFstab()
@@ -148,11 +152,15 @@ type mount struct {
 }
 
 func disableSwapOnFstab() error {
-	file, err := os.OpenFile("/etc/fstab", os.O_RDWR, 0o644)
+	file, err := os.Open(fstabPath)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
 
 	var bs []byte
 	buf := bytes.NewBuffer(bs)
@@ -169,14 +177,10 @@ func disableSwapOnFstab() error {
 			buf.WriteString(scanner.Text() + "\n")
 		}
 	}
-	if err := file.Truncate(0); err != nil {
-		return err
-	}
-	if _, err := file.Seek(0, 0); err != nil {
+	if err := scanner.Err(); err != nil {
 		return err
 	}
-	_, err = buf.WriteTo(file)
-	return err
+	return util.WriteFileWithDir(fstabPath, buf.Bytes(), info.Mode().Perm())
 }
 
 func parseFstabLine(line string) (*mount, error) {
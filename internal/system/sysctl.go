@@ -41,6 +41,9 @@ func (s *sysctlAspect) Setup() error {
 	if err := writeSysctlConfig(); err != nil {
 		return err
 	}
+	if util.DryRun() {
+		return nil
+	}
 	return reloadSysctl()
 }
 
@@ -5,6 +5,7 @@ import (
 	"io/fs"
 	"os"
 	"path"
+	"time"
 
 	"github.com/pkg/errors"
 	"sigs.k8s.io/yaml"
@@ -21,10 +22,44 @@ const (
 	ContainerdSourceDocker ContainerdSourceName = "docker"
 )
 
-const trackerFile = "/opt/nodeadm/tracker"
+// FilePath is where the tracker is stored.
+const FilePath = "/opt/nodeadm/tracker"
+
+// SchemaVersion is the version of the tracker file format written by this nodeadm.
+// Bump it and add a migration when the format changes.
+const SchemaVersion = 2
 
 type Tracker struct {
+	// Version is the schema version of the tracker file. Files written before the
+	// format was versioned don't have one and are migrated when loaded.
+	Version   int
 	Artifacts *InstalledArtifacts
+	// Manifest is the release manifest the artifacts were last installed or upgraded from.
+	Manifest *Manifest `json:",omitempty"`
+	// Records holds where each artifact on disk came from, keyed by artifact name.
+	// Artifacts installed before records were tracked don't have one.
+	Records map[string]*ArtifactRecord `json:",omitempty"`
+	// Pins holds the versions components are pinned to, keyed by their name in the release
+	// manifest. Upgrades keep them until they are released.
+	Pins map[string]string `json:",omitempty"`
+}
+
+// Manifest identifies a release manifest.
+type Manifest struct {
+	URI               string
+	Sha256            string `json:",omitempty"`
+	KubernetesVersion string `json:",omitempty"`
+}
+
+// ArtifactRecord is the provenance of an artifact installed by nodeadm.
+type ArtifactRecord struct {
+	Version string `json:",omitempty"`
+	URI     string `json:",omitempty"`
+	Sha256  string `json:",omitempty"`
+	Path    string
+	// Files holds the hex encoded sha256 of each file extracted from archive artifacts, keyed by path.
+	Files       map[string]string `json:",omitempty"`
+	InstalledAt time.Time
 }
 
 type InstalledArtifacts struct {
@@ -64,8 +99,47 @@ func (tracker *Tracker) Add(componentName string) error {
 	return nil
 }
 
+// IsInstalled returns true if the component is tracked as installed.
+func (artifacts *InstalledArtifacts) IsInstalled(componentName string) bool {
+	switch componentName {
+	case artifact.Containerd:
+		return artifacts.Containerd != ContainerdSourceNone && artifacts.Containerd != ""
+	case artifact.CniPlugins:
+		return artifacts.CniPlugins
+	case artifact.IamAuthenticator:
+		return artifacts.IamAuthenticator
+	case artifact.IamRolesAnywhere:
+		return artifacts.IamRolesAnywhere
+	case artifact.ImageCredentialProvider:
+		return artifacts.ImageCredentialProvider
+	case artifact.Kubectl:
+		return artifacts.Kubectl
+	case artifact.Kubelet:
+		return artifacts.Kubelet
+	case artifact.Ssm:
+		return artifacts.Ssm
+	case artifact.Iptables:
+		return artifacts.Iptables
+	default:
+		return false
+	}
+}
+
+// Record stores the provenance of an installed artifact. If the artifact was already
+// recorded with the same checksum, its install time is preserved.
+func (tracker *Tracker) Record(componentName string, record ArtifactRecord) {
+	if tracker.Records == nil {
+		tracker.Records = map[string]*ArtifactRecord{}
+	}
+	if current, ok := tracker.Records[componentName]; ok && current.Sha256 != "" && current.Sha256 == record.Sha256 {
+		record.InstalledAt = current.InstalledAt
+	}
+	tracker.Records[componentName] = &record
+}
+
 // Save() saves the tracker to file
 func (tracker *Tracker) Save() error {
+	tracker.Version = SchemaVersion
 	// ensure containerd source is populated with none/distro/docker
 	containerdSource, err := ContainerdSource(string(tracker.Artifacts.Containerd))
 	if err != nil {
@@ -77,17 +151,21 @@ func (tracker *Tracker) Save() error {
 		return err
 	}
 
-	return util.WriteFileWithDir(trackerFile, data, 0o644)
+	return util.WriteFileWithDir(FilePath, data, 0o644)
 }
 
 func Clear() error {
-	return os.RemoveAll(path.Dir(trackerFile))
+	return os.RemoveAll(path.Dir(FilePath))
 }
 
 // GetInstalledArtifacts reads the tracker file and returns the current
-// installed artifacts
+// installed artifacts. Trackers written with older schema versions are migrated.
 func GetInstalledArtifacts() (*Tracker, error) {
-	yamlFileData, err := os.ReadFile(trackerFile)
+	return load(FilePath)
+}
+
+func load(trackerPath string) (*Tracker, error) {
+	yamlFileData, err := os.ReadFile(trackerPath)
 	if err != nil {
 		return nil, err
 	}
@@ -96,6 +174,9 @@ func GetInstalledArtifacts() (*Tracker, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "invalid yaml data in tracker")
 	}
+	if err := migrate(&artifacts); err != nil {
+		return nil, err
+	}
 	// containerd will be non-empty if containerd is being managed by nodeadm
 	// otherwise it *may* be empty, which we want to ensure is treated as "none"
 	containerdSource, err := ContainerdSource(string(artifacts.Artifacts.Containerd))
@@ -114,6 +195,7 @@ func GetCurrentState() (*Tracker, error) {
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
 			return &Tracker{
+				Version:   SchemaVersion,
 				Artifacts: &InstalledArtifacts{},
 			}, nil
 		}
@@ -0,0 +1,103 @@
+package tracker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+
+	"github.com/aws/eks-hybrid/internal/artifact"
+)
+
+func TestLoadMigratesUnversionedTracker(t *testing.T) {
+	g := NewWithT(t)
+	trackerPath := filepath.Join(t.TempDir(), "tracker")
+	g.Expect(os.WriteFile(trackerPath, []byte(`Artifacts:
+  CniPlugins: true
+  Containerd: distro
+  IamAuthenticator: true
+  IamRolesAnywhere: false
+  ImageCredentialProvider: true
+  Iptables: true
+  Kubectl: true
+  Kubelet: true
+  Ssm: true
+`), 0o644)).To(Succeed())
+
+	tracker, err := load(trackerPath)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tracker.Version).To(Equal(SchemaVersion))
+	g.Expect(tracker.Artifacts).To(Equal(&InstalledArtifacts{
+		Containerd:              ContainerdSourceDistro,
+		CniPlugins:              true,
+		IamAuthenticator:        true,
+		ImageCredentialProvider: true,
+		Kubectl:                 true,
+		Kubelet:                 true,
+		Ssm:                     true,
+		Iptables:                true,
+	}))
+	g.Expect(tracker.Records).To(BeEmpty())
+	g.Expect(tracker.Manifest).To(BeNil())
+}
+
+func TestLoadRoundTrip(t *testing.T) {
+	g := NewWithT(t)
+	installedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	want := &Tracker{
+		Version: SchemaVersion,
+		Artifacts: &InstalledArtifacts{
+			Containerd: ContainerdSourceNone,
+			Kubelet:    true,
+		},
+		Manifest: &Manifest{
+			URI:               "https://hybrid-assets.eks.amazonaws.com/manifest.yaml",
+			Sha256:            "abc",
+			KubernetesVersion: "1.31.2",
+		},
+		Records: map[string]*ArtifactRecord{
+			artifact.Kubelet: {
+				Version:     "1.31.2",
+				URI:         "https://hybrid-assets.eks.amazonaws.com/1.31.2/bin/linux/amd64/kubelet",
+				Sha256:      "def",
+				Path:        "/usr/bin/kubelet",
+				InstalledAt: installedAt,
+			},
+		},
+	}
+	data, err := yaml.Marshal(want)
+	g.Expect(err).NotTo(HaveOccurred())
+	trackerPath := filepath.Join(t.TempDir(), "tracker")
+	g.Expect(os.WriteFile(trackerPath, data, 0o644)).To(Succeed())
+
+	got, err := load(trackerPath)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal(want))
+}
+
+func TestLoadNewerSchemaVersion(t *testing.T) {
+	g := NewWithT(t)
+	trackerPath := filepath.Join(t.TempDir(), "tracker")
+	g.Expect(os.WriteFile(trackerPath, []byte("Version: 99\nArtifacts:\n  Kubelet: true\n"), 0o644)).To(Succeed())
+
+	_, err := load(trackerPath)
+	g.Expect(err).To(MatchError(ContainSubstring("tracker schema version 99 is newer")))
+}
+
+func TestRecordPreservesInstallTimeForSameChecksum(t *testing.T) {
+	g := NewWithT(t)
+	tracker := &Tracker{Artifacts: &InstalledArtifacts{}}
+	first := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(24 * time.Hour)
+
+	tracker.Record(artifact.Kubelet, ArtifactRecord{Version: "1.31.1", Sha256: "aaa", InstalledAt: first})
+	tracker.Record(artifact.Kubelet, ArtifactRecord{Version: "1.31.1", Sha256: "aaa", InstalledAt: second})
+	g.Expect(tracker.Records[artifact.Kubelet].InstalledAt).To(Equal(first))
+
+	tracker.Record(artifact.Kubelet, ArtifactRecord{Version: "1.31.2", Sha256: "bbb", InstalledAt: second})
+	g.Expect(tracker.Records[artifact.Kubelet].InstalledAt).To(Equal(second))
+	g.Expect(tracker.Records[artifact.Kubelet].Version).To(Equal("1.31.2"))
+}
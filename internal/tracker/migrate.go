@@ -0,0 +1,33 @@
+package tracker
+
+import "fmt"
+
+// migrations upgrade a tracker from the schema version used as key to the next one.
+var migrations = map[int]func(*Tracker){
+	// Version 1 trackers only record which artifacts are installed. Their provenance
+	// is unknown, so no records are created and they are filled on the next upgrade.
+	1: func(tracker *Tracker) {},
+}
+
+// migrate upgrades tracker in place to SchemaVersion.
+func migrate(tracker *Tracker) error {
+	// Trackers written before the format was versioned don't have a version.
+	if tracker.Version == 0 {
+		tracker.Version = 1
+	}
+	if tracker.Version > SchemaVersion {
+		return fmt.Errorf("tracker schema version %d is newer than the supported version %d, please use a newer nodeadm", tracker.Version, SchemaVersion)
+	}
+	if tracker.Artifacts == nil {
+		tracker.Artifacts = &InstalledArtifacts{}
+	}
+	for tracker.Version < SchemaVersion {
+		migration, ok := migrations[tracker.Version]
+		if !ok {
+			return fmt.Errorf("no migration for tracker schema version %d", tracker.Version)
+		}
+		migration(tracker)
+		tracker.Version++
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -15,9 +16,13 @@ import (
 // so they can be retried independently.
 type Builder func(context.Context) *exec.Cmd
 
+// redacted replaces the secrets in the logs and errors of Retry.
+const redacted = "<redacted>"
+
 // Retry runs the command until it succeeds or the context is cancelled.
-// The backoff duration is the time to wait between retries.
-func Retry(ctx context.Context, newCmd Builder, backoff time.Duration) error {
+// The backoff duration is the time to wait between retries. Secrets, for
+// example arguments read from a secret store, are redacted from the errors.
+func Retry(ctx context.Context, newCmd Builder, backoff time.Duration, secrets ...string) error {
 	log := logger.FromContext(ctx)
 	var err error
 	for {
@@ -27,7 +32,7 @@ func Retry(ctx context.Context, newCmd Builder, backoff time.Duration) error {
 		if err == nil {
 			return nil
 		}
-		err = fmt.Errorf("running command %s: %s [Err %s]", cmd.Args, out, err)
+		err = fmt.Errorf("%s", redact(fmt.Sprintf("running command %s: %s [Err %s]", cmd.Args, out, err), secrets))
 		log.Info("Command failed, retrying", zap.Duration("backoff", backoff), zap.Error(err))
 		select {
 		case <-ctx.Done():
@@ -36,3 +41,12 @@ func Retry(ctx context.Context, newCmd Builder, backoff time.Duration) error {
 		}
 	}
 }
+
+func redact(message string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			message = strings.ReplaceAll(message, secret, redacted)
+		}
+	}
+	return message
+}
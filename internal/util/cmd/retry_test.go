@@ -61,3 +61,17 @@ func TestRetryTimeout(t *testing.T) {
 		cmd.Retry(ctx, cmdBuilder.build, 1*time.Millisecond),
 	).To(MatchError(ContainSubstring(`running command [fake-command]:  [Err exec: "fake-command": executable file not found in $PATH]`)))
 }
+
+func TestRetryRedactsSecrets(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	cmdBuilder := &testCmd{}
+	cmdBuilder.set("false", "-register", "-code", "activation-code-secret")
+
+	err := cmd.Retry(ctx, cmdBuilder.build, 1*time.Millisecond, "activation-code-secret")
+	g.Expect(err).To(MatchError(ContainSubstring("running command [false -register -code <redacted>]")))
+	g.Expect(err.Error()).NotTo(ContainSubstring("activation-code-secret"))
+}
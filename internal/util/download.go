@@ -0,0 +1,392 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/aws/eks-hybrid/internal/logger"
+)
+
+const (
+	// DefaultStallTimeout is how long a download can go without receiving data before
+	// it's interrupted and resumed.
+	DefaultStallTimeout = time.Minute
+
+	// maxResumes is how many times in a row a download is resumed without receiving data.
+	maxResumes = 5
+
+	// maxLimitedRead bounds each read of a bandwidth limited download, so a download
+	// waits at most a second for the limiter when it has the bandwidth to itself.
+	maxLimitedRead = 64 * 1024
+)
+
+var (
+	resumeBackoff = 2 * time.Second
+
+	// downloadLimiter caps the bandwidth of all downloads together. It is nil when unlimited.
+	downloadLimiter *rate.Limiter
+	stallTimeout    = DefaultStallTimeout
+)
+
+// SetDownloadLimits caps the combined bandwidth of all downloads at bytesPerSecond and
+// sets how long an HTTP download can stall before it's resumed. A bytesPerSecond of 0
+// removes the cap and a stall timeout of 0 disables stall detection.
+func SetDownloadLimits(bytesPerSecond int64, stall time.Duration) {
+	downloadLimiter = nil
+	if bytesPerSecond > 0 {
+		downloadLimiter = rate.NewLimiter(rate.Limit(bytesPerSecond), int(min(bytesPerSecond, maxLimitedRead)))
+	}
+	stallTimeout = stall
+}
+
+// ParseBandwidth parses a bandwidth in bytes per second written as a quantity, for
+// example 512Ki or 2M. An empty string means unlimited and returns 0.
+func ParseBandwidth(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bandwidth %s, expected bytes per second such as 512Ki or 2M: %w", value, err)
+	}
+	bytesPerSecond, ok := quantity.AsInt64()
+	if !ok || bytesPerSecond < 0 {
+		return 0, fmt.Errorf("invalid bandwidth %s, expected bytes per second such as 512Ki or 2M", value)
+	}
+	return bytesPerSecond, nil
+}
+
+// limitBandwidth makes reads from rc wait for the download limiter, if any.
+func limitBandwidth(ctx context.Context, rc io.ReadCloser) io.ReadCloser {
+	if downloadLimiter == nil {
+		return rc
+	}
+	return &limitedReader{ctx: ctx, rc: rc, limiter: downloadLimiter}
+}
+
+type limitedReader struct {
+	ctx     context.Context
+	rc      io.ReadCloser
+	limiter *rate.Limiter
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.rc.Read(p[:min(len(p), l.limiter.Burst())])
+	if n > 0 {
+		if waitErr := l.limiter.WaitN(l.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+func (l *limitedReader) Close() error {
+	return l.rc.Close()
+}
+
+// resumableReader reads the body of an HTTP download. When the connection breaks or no
+// data is received for the stall timeout, the download is resumed where it stopped with
+// a Range request, so a caller writing the body to a file never sees the interruption.
+// If-Range makes sure the rest of the body comes from the same version of the file.
+type resumableReader struct {
+	ctx          context.Context
+	uri          string
+	stallTimeout time.Duration
+	limiter      *rate.Limiter
+
+	// offset is the number of bytes of the file read so far.
+	offset int64
+	// validator is the ETag, or else the Last-Modified date, of the file being read.
+	validator string
+	resumes   int
+
+	body     io.ReadCloser
+	cancel   context.CancelFunc
+	watchdog *time.Timer
+	mu       sync.Mutex
+	stalled  bool
+}
+
+func openResumable(ctx context.Context, uri string) (io.ReadCloser, error) {
+	return openResumableAt(ctx, uri, 0, "")
+}
+
+// openResumableAt opens the download of uri from offset on, provided the file still has
+// the given validator.
+func openResumableAt(ctx context.Context, uri string, offset int64, validator string) (*resumableReader, error) {
+	r := &resumableReader{
+		ctx:          ctx,
+		uri:          uri,
+		stallTimeout: stallTimeout,
+		limiter:      downloadLimiter,
+		offset:       offset,
+		validator:    validator,
+	}
+	if err := r.open(newRetryableHttpClient(2*time.Second, 3)); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// DownloadFile downloads uri to path and returns path opened for reading. The data of an
+// interrupted HTTP download is kept in path, along with the ETag or Last-Modified date of
+// the file in path+".validator", and the next call for the same uri resumes from there if
+// the file didn't change. Other URIs are opened with GetHttpFileReader and not written to path.
+func DownloadFile(ctx context.Context, uri, path string) (io.ReadCloser, error) {
+	if offlineRoot != "" || !(strings.HasPrefix(uri, "https://") || strings.HasPrefix(uri, "http://")) {
+		return GetHttpFileReader(ctx, uri)
+	}
+
+	validatorPath := path + ".validator"
+	var offset int64
+	var validator string
+	if state, err := os.ReadFile(validatorPath); err == nil {
+		if stateURI, stateValidator, ok := strings.Cut(string(state), "\n"); ok && stateURI == uri && stateValidator != "" {
+			if info, err := os.Stat(path); err == nil {
+				offset, validator = info.Size(), stateValidator
+			}
+		}
+	}
+
+	var r *resumableReader
+	var err error
+	if offset > 0 {
+		if r, err = openResumableAt(ctx, uri, offset, validator); err != nil {
+			// The file changed or can't be resumed, start over.
+			logger.FromContext(ctx).Warn("Could not resume previous download. Restarting...", zap.String("url", uri), zap.Int64("offset", offset), zap.Error(err))
+			offset = 0
+		} else {
+			logger.FromContext(ctx).Info("Resuming previous download", zap.String("url", uri), zap.Int64("offset", offset))
+		}
+	}
+	if offset == 0 {
+		if r, err = openResumableAt(ctx, uri, 0, ""); err != nil {
+			return nil, err
+		}
+	}
+	defer r.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+	fh, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	if _, err := fh.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if r.validator == "" {
+		// Without validator the data can't be told apart from a newer version of the file.
+		_ = os.Remove(validatorPath)
+	} else if err := os.WriteFile(validatorPath, []byte(uri+"\n"+r.validator), 0o644); err != nil {
+		return nil, err
+	}
+
+	if _, err := io.Copy(fh, r); err != nil {
+		return nil, err
+	}
+	if err := fh.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(validatorPath); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+type httpDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// open requests the file from offset on.
+func (r *resumableReader) open(client httpDoer) error {
+	requestCtx, cancel := context.WithCancel(r.ctx)
+	request, err := http.NewRequestWithContext(requestCtx, http.MethodGet, r.uri, nil)
+	if err != nil {
+		cancel()
+		return errors.Wrapf(err, "failed creating request from url: %s", r.uri)
+	}
+	request.Header.Add(userAgentHeader, userAgent)
+	if r.offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", r.offset))
+		if r.validator != "" {
+			request.Header.Set("If-Range", r.validator)
+		}
+	}
+
+	r.cancel = cancel
+	r.arm()
+	resp, err := client.Do(request)
+	if err != nil {
+		err = r.stallError(err)
+		r.close()
+		return errors.Wrapf(err, "failed reading file from url: %s", r.uri)
+	}
+	r.body = resp.Body
+
+	switch {
+	case r.offset == 0:
+		r.validator = resp.Header.Get("ETag")
+		if r.validator == "" {
+			r.validator = resp.Header.Get("Last-Modified")
+		}
+	case resp.StatusCode == http.StatusPartialContent:
+		if !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", r.offset)) {
+			r.close()
+			return fmt.Errorf("resuming %s: unexpected content range %q", r.uri, resp.Header.Get("Content-Range"))
+		}
+	case resp.StatusCode == http.StatusOK && r.validator == "":
+		// The server ignored the range and the file can't be told apart from a newer
+		// version, skip what was already read and rely on the checksum verification.
+		if _, err := io.CopyN(io.Discard, r.body, r.offset); err != nil {
+			err = r.stallError(err)
+			r.close()
+			return errors.Wrapf(err, "resuming %s", r.uri)
+		}
+	case resp.StatusCode == http.StatusOK:
+		r.close()
+		return fmt.Errorf("resuming %s: file changed during download", r.uri)
+	default:
+		r.close()
+		return fmt.Errorf("resuming %s: unexpected status code: %d", r.uri, resp.StatusCode)
+	}
+	return nil
+}
+
+func (r *resumableReader) Read(p []byte) (int, error) {
+	if r.limiter != nil {
+		p = p[:min(len(p), r.limiter.Burst())]
+	}
+	for {
+		if r.body == nil {
+			return 0, fmt.Errorf("reading closed download of %s", r.uri)
+		}
+		n, err := r.body.Read(p)
+		if n > 0 {
+			r.offset += int64(n)
+			r.resumes = 0
+			if waitErr := r.wait(n); waitErr != nil {
+				return n, waitErr
+			}
+		}
+		if err == nil || err == io.EOF {
+			return n, err
+		}
+		if r.ctx.Err() != nil {
+			return n, r.ctx.Err()
+		}
+		if resumeErr := r.resume(err); resumeErr != nil {
+			return n, resumeErr
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// wait waits for the bandwidth limiter without counting the time against the stall timeout.
+func (r *resumableReader) wait(n int) error {
+	if r.limiter == nil {
+		r.arm()
+		return nil
+	}
+	r.disarm()
+	defer r.arm()
+	return r.limiter.WaitN(r.ctx, n)
+}
+
+// resume reopens the download from the current offset after it was interrupted by cause.
+func (r *resumableReader) resume(cause error) error {
+	cause = r.stallError(cause)
+	r.close()
+	for r.resumes < maxResumes {
+		r.resumes++
+		logger.FromContext(r.ctx).Warn("Download interrupted. Resuming...",
+			zap.String("url", r.uri), zap.Int64("offset", r.offset), zap.Int("attempt", r.resumes), zap.Error(cause))
+
+		select {
+		case <-time.After(resumeBackoff):
+		case <-r.ctx.Done():
+			return r.ctx.Err()
+		}
+		if cause = r.open(http.DefaultClient); cause == nil {
+			return nil
+		}
+	}
+	return errors.Wrapf(cause, "failed reading file from url: %s after %d resumes", r.uri, r.resumes)
+}
+
+// arm (re)starts the stall timer, which cancels the request when it fires.
+func (r *resumableReader) arm() {
+	if r.stallTimeout <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.watchdog != nil {
+		r.watchdog.Reset(r.stallTimeout)
+		return
+	}
+	cancel := r.cancel
+	r.watchdog = time.AfterFunc(r.stallTimeout, func() {
+		r.mu.Lock()
+		r.stalled = true
+		r.mu.Unlock()
+		cancel()
+	})
+}
+
+func (r *resumableReader) disarm() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.watchdog != nil {
+		r.watchdog.Stop()
+	}
+}
+
+// stallError replaces err with a stall error if the request was canceled by the stall timer.
+func (r *resumableReader) stallError(err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stalled {
+		return fmt.Errorf("no data received for %s", r.stallTimeout)
+	}
+	return err
+}
+
+// close releases the current request. The reader can be reopened afterwards.
+func (r *resumableReader) close() {
+	r.mu.Lock()
+	if r.watchdog != nil {
+		r.watchdog.Stop()
+		r.watchdog = nil
+	}
+	r.stalled = false
+	r.mu.Unlock()
+	if r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+func (r *resumableReader) Close() error {
+	r.close()
+	return nil
+}
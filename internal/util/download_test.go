@@ -0,0 +1,215 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setDownloadLimits(t *testing.T, bytesPerSecond int64, stall time.Duration) {
+	backoff := resumeBackoff
+	resumeBackoff = 0
+	SetDownloadLimits(bytesPerSecond, stall)
+	t.Cleanup(func() {
+		resumeBackoff = backoff
+		SetDownloadLimits(0, DefaultStallTimeout)
+	})
+}
+
+// newInterruptingServer serves content with Range support, interrupting the first response
+// halfway through with interrupt. etag returns the ETag of each request.
+func newInterruptingServer(t *testing.T, content []byte, etag func(request int32) string, interrupt func(w http.ResponseWriter, r *http.Request)) (*httptest.Server, *[]string) {
+	var requests atomic.Int32
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := requests.Add(1)
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", etag(request))
+		if request == 1 {
+			w.Header().Set("Content-Length", "1024")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(content[:512])
+			w.(http.Flusher).Flush()
+			interrupt(w, r)
+			return
+		}
+		http.ServeContent(w, r, "artifact", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+	return server, &ranges
+}
+
+func sameETag(int32) string { return `"v1"` }
+
+func TestGetHttpFileResumesBrokenDownload(t *testing.T) {
+	setDownloadLimits(t, 0, DefaultStallTimeout)
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64)
+	server, ranges := newInterruptingServer(t, content, sameETag, func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	data, err := GetHttpFile(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	assert.Equal(t, []string{"", "bytes=512-"}, *ranges)
+}
+
+func TestGetHttpFileResumesStalledDownload(t *testing.T) {
+	setDownloadLimits(t, 0, 200*time.Millisecond)
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64)
+	server, ranges := newInterruptingServer(t, content, sameETag, func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	data, err := GetHttpFile(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	assert.Equal(t, []string{"", "bytes=512-"}, *ranges)
+}
+
+func TestGetHttpFileRefusesChangedFile(t *testing.T) {
+	setDownloadLimits(t, 0, DefaultStallTimeout)
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64)
+	server, _ := newInterruptingServer(t, content, func(request int32) string {
+		if request == 1 {
+			return `"v1"`
+		}
+		return `"v2"`
+	}, func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	_, err := GetHttpFile(context.Background(), server.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file changed during download")
+}
+
+// newRangeServer serves content with the given ETag and Range support and records the
+// Range header of every request.
+func newRangeServer(t *testing.T, content []byte, etag string) (*httptest.Server, *[]string) {
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "artifact", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+	return server, &ranges
+}
+
+func TestDownloadFileResumesPreviousDownload(t *testing.T) {
+	setDownloadLimits(t, 0, DefaultStallTimeout)
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64)
+	server, ranges := newRangeServer(t, content, `"v1"`)
+	path := filepath.Join(t.TempDir(), "artifact.partial")
+	require.NoError(t, os.WriteFile(path, content[:512], 0o644))
+	require.NoError(t, os.WriteFile(path+".validator", []byte(server.URL+"\n"+`"v1"`), 0o644))
+
+	file, err := DownloadFile(context.Background(), server.URL, path)
+	require.NoError(t, err)
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	assert.Equal(t, []string{"bytes=512-"}, *ranges)
+	assert.NoFileExists(t, path+".validator")
+}
+
+func TestDownloadFileRestartsChangedFile(t *testing.T) {
+	setDownloadLimits(t, 0, DefaultStallTimeout)
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64)
+	server, ranges := newRangeServer(t, content, `"v2"`)
+	path := filepath.Join(t.TempDir(), "artifact.partial")
+	require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("x"), 512), 0o644))
+	require.NoError(t, os.WriteFile(path+".validator", []byte(server.URL+"\n"+`"v1"`), 0o644))
+
+	file, err := DownloadFile(context.Background(), server.URL, path)
+	require.NoError(t, err)
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	assert.Equal(t, []string{"bytes=512-", ""}, *ranges)
+}
+
+func TestDownloadFileKeepsInterruptedDownload(t *testing.T) {
+	setDownloadLimits(t, 0, DefaultStallTimeout)
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64)
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Length", "1024")
+		_, _ = w.Write(content[:512])
+		w.(http.Flusher).Flush()
+		// nodeadm is stopped halfway through the download.
+		time.AfterFunc(100*time.Millisecond, cancel)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "artifact.partial")
+
+	_, err := DownloadFile(ctx, server.URL, path)
+	require.Error(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content[:512], data)
+	validator, err := os.ReadFile(path + ".validator")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"\n"+`"v1"`, string(validator))
+}
+
+func TestGetHttpFileBandwidthLimit(t *testing.T) {
+	setDownloadLimits(t, 16*1024, DefaultStallTimeout)
+	content := bytes.Repeat([]byte("a"), 32*1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	reader, err := GetHttpFileReader(context.Background(), server.URL)
+	require.NoError(t, err)
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	// The first 16KiB are served by the limiter burst, the rest at 16KiB/s.
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+}
+
+func TestParseBandwidth(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr string
+	}{
+		{value: "", want: 0},
+		{value: "512Ki", want: 512 * 1024},
+		{value: "2M", want: 2000000},
+		{value: "fast", wantErr: "invalid bandwidth fast"},
+		{value: "-1M", wantErr: "invalid bandwidth -1M"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := ParseBandwidth(tc.value)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, strings.HasPrefix(err.Error(), tc.wantErr), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -9,15 +9,53 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+
+	"github.com/aws/eks-hybrid/internal/ledger"
 )
 
+// dryRunRoot is the directory host files are written to instead of their real location
+// when running in dry-run mode. It is empty otherwise.
+var dryRunRoot string
+
+// SetDryRunRoot redirects every file written through the helpers in this package to
+// the same path under root, leaving the host untouched. It also makes DryRun return true
+// so callers can avoid side effects other than writing files. The returned func restores
+// the previous root, callers are expected to defer it.
+func SetDryRunRoot(root string) (restore func()) {
+	previous := dryRunRoot
+	dryRunRoot = root
+	return func() {
+		dryRunRoot = previous
+	}
+}
+
+// DryRun returns true if host files are being rendered to a dry-run root.
+func DryRun() bool {
+	return dryRunRoot != ""
+}
+
+// HostPath returns the location a host file is written to. This is the path itself
+// unless running in dry-run mode.
+func HostPath(filePath string) string {
+	if dryRunRoot == "" {
+		return filePath
+	}
+	return filepath.Join(dryRunRoot, filePath)
+}
+
 // Wraps os.WriteFile to automatically create parent directories such that the
-// caller does not need to ensure the existence of the file's directory
+// caller does not need to ensure the existence of the file's directory.
+// The file and the directories created are recorded in the install ledger.
 func WriteFileWithDir(filePath string, data []byte, perm fs.FileMode) error {
-	if err := os.MkdirAll(path.Dir(filePath), perm); err != nil {
+	filePath = HostPath(filePath)
+	if err := ledger.MkdirAll(path.Dir(filePath), perm); err != nil {
+		return err
+	}
+	preexisting := ledger.Exists(filePath)
+	if err := os.WriteFile(filePath, data, perm); err != nil {
 		return err
 	}
-	return os.WriteFile(filePath, data, perm)
+	return ledger.RecordFile(filePath, preexisting)
 }
 
 // IsFilePathExists checks whether specific file path exists
@@ -34,9 +72,11 @@ func IsFilePathExists(filePath string) (bool, error) {
 
 // WriteFileWithDirFromReader writes to a file from a byte reader interface
 func WriteFileWithDirFromReader(path string, reader io.Reader, perm fs.FileMode) error {
-	if err := os.MkdirAll(filepath.Dir(path), perm); err != nil {
+	path = HostPath(path)
+	if err := ledger.MkdirAll(filepath.Dir(path), perm); err != nil {
 		return err
 	}
+	preexisting := ledger.Exists(path)
 	fh, err := os.Create(path)
 	if err != nil {
 		return err
@@ -45,15 +85,20 @@ func WriteFileWithDirFromReader(path string, reader io.Reader, perm fs.FileMode)
 	if _, err := io.Copy(fh, reader); err != nil {
 		return err
 	}
-	return os.Chmod(path, perm)
+	if err := os.Chmod(path, perm); err != nil {
+		return err
+	}
+	return ledger.RecordFile(path, preexisting)
 }
 
 // WriteFileUniqueLine creates the dir and file if it doesn't exist and writes the input data to the file
 // If the file already exist, the input data will only be appended if it doesn't exist in the file
 func WriteFileUniqueLine(filepath string, data []byte, perm fs.FileMode) error {
-	if err := os.MkdirAll(path.Dir(filepath), perm); err != nil {
+	filepath = HostPath(filepath)
+	if err := ledger.MkdirAll(path.Dir(filepath), perm); err != nil {
 		return err
 	}
+	preexisting := ledger.Exists(filepath)
 	file, err := os.OpenFile(filepath, os.O_APPEND|os.O_CREATE|os.O_RDWR, perm)
 	if err != nil {
 		return err
@@ -66,6 +111,12 @@ func WriteFileUniqueLine(filepath string, data []byte, perm fs.FileMode) error {
 			return nil
 		}
 	}
-	_, err = file.WriteString(string(data) + "\n")
-	return err
+	if _, err := file.WriteString(string(data) + "\n"); err != nil {
+		return err
+	}
+	if preexisting {
+		// The rest of the file isn't owned by nodeadm, only the line is undone.
+		return ledger.RecordLine(filepath, string(data))
+	}
+	return ledger.RecordFile(filepath, preexisting)
 }
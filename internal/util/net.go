@@ -5,7 +5,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -17,6 +21,34 @@ const userAgentHeader = "User-Agent"
 
 var userAgent = fmt.Sprintf("nodeadm/%s (%s/%s)", version.GitVersion, runtime.GOOS, runtime.GOARCH)
 
+// offlineRoot is a local directory mirroring remote files, laid out as <root>/<host>/<path>.
+// When set, files are read from it and the network is never used. It is empty otherwise.
+var offlineRoot string
+
+// SetOfflineRoot makes GetHttpFile and GetHttpFileReader read every file from the
+// mirror at root instead of downloading it. An empty root restores downloads.
+func SetOfflineRoot(root string) {
+	offlineRoot = root
+}
+
+// Offline returns true if files are read from an offline mirror.
+func Offline() bool {
+	return offlineRoot != ""
+}
+
+// OfflinePath returns where the file at uri is stored in an offline mirror at root.
+func OfflinePath(root, uri string) (string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing url: %s", uri)
+	}
+	rel := filepath.Join(parsed.Host, filepath.FromSlash(parsed.Path))
+	if parsed.Host == "" || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("url %s can't be stored in an offline mirror", uri)
+	}
+	return filepath.Join(root, rel), nil
+}
+
 func GetHttpFile(ctx context.Context, uri string) ([]byte, error) {
 	reader, err := GetHttpFileReader(ctx, uri)
 	if err != nil {
@@ -32,19 +64,26 @@ func GetHttpFile(ctx context.Context, uri string) ([]byte, error) {
 	return data, nil
 }
 
+// GetHttpFileReader opens the file at uri. HTTP downloads interrupted by a broken connection
+// or a stall are resumed where they stopped, and downloads share the bandwidth cap set with
+// SetDownloadLimits.
 func GetHttpFileReader(ctx context.Context, uri string) (io.ReadCloser, error) {
-	request, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed creating request from url: %s", uri)
+	// Artifacts synced to a local directory are referenced with file:// URIs.
+	if path, ok := strings.CutPrefix(uri, "file://"); ok {
+		fh, err := os.Open(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading file from url: %s", uri)
+		}
+		return fh, nil
 	}
-	request.Header.Add(userAgentHeader, userAgent)
-
-	httpRetryClient := newRetryableHttpClient(2*time.Second, 3)
-	resp, err := httpRetryClient.Do(request)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed reading file from url: %s", uri)
+	if offlineRoot != "" {
+		return openOfflineFile(uri)
+	}
+	if strings.HasPrefix(uri, S3Scheme) {
+		return openS3Object(ctx, uri)
 	}
-	return resp.Body, nil
+
+	return openResumable(ctx, uri)
 }
 
 type retryHttpClient struct {
@@ -68,7 +107,7 @@ func (hc *retryHttpClient) Do(req *http.Request) (*http.Response, error) {
 		if err != nil {
 			continue
 		}
-		if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode != http.StatusOK && (resp.StatusCode != http.StatusPartialContent || req.Header.Get("Range") == "") {
 			err = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 			continue
 		}
@@ -76,3 +115,45 @@ func (hc *retryHttpClient) Do(req *http.Request) (*http.Response, error) {
 	}
 	return nil, fmt.Errorf("max retries achieved for http request: %s : %w", req.Host, err)
 }
+
+func openOfflineFile(uri string) (io.ReadCloser, error) {
+	path, err := OfflinePath(offlineRoot, uri)
+	if err != nil {
+		return nil, err
+	}
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s from offline mirror %s", uri, offlineRoot)
+	}
+	return fh, nil
+}
+
+// RedactURI strips the userinfo and the query string of uri, which can carry credentials
+// such as the signature of a presigned URL, so uri can be logged or included in errors.
+func RedactURI(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		// Only keep the scheme and host of a URI that can't be parsed.
+		scheme, rest, ok := strings.Cut(uri, "://")
+		if !ok {
+			return "<redacted>"
+		}
+		host, _, _ := strings.Cut(rest, "/")
+		host, _, _ = strings.Cut(host, "?")
+		host = host[strings.LastIndex(host, "@")+1:]
+		return scheme + "://" + host + "/<redacted>"
+	}
+	parsed.User = nil
+	parsed.RawQuery = ""
+	parsed.ForceQuery = false
+	return parsed.String()
+}
+
+// RedactURIs returns uris redacted with RedactURI.
+func RedactURIs(uris []string) []string {
+	redacted := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		redacted = append(redacted, RedactURI(uri))
+	}
+	return redacted
+}
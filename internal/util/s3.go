@@ -0,0 +1,67 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/pkg/errors"
+)
+
+// S3Scheme is the scheme of URIs referencing S3 objects as s3://bucket/key.
+const S3Scheme = "s3://"
+
+// S3Client is the subset of the S3 API used to read s3:// URIs.
+type S3Client interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// s3ClientProvider builds the client reading s3:// URIs. It is nil when they aren't supported.
+var s3ClientProvider func(context.Context) (S3Client, error)
+
+// SetS3ClientProvider sets how GetHttpFile and GetHttpFileReader build the client reading
+// s3:// URIs. The provider is called on every read, so credentials that show up while
+// nodeadm runs, for example once the node is registered, are picked up. A nil provider
+// disables s3:// URIs.
+func SetS3ClientProvider(provider func(context.Context) (S3Client, error)) {
+	s3ClientProvider = provider
+}
+
+// ParseS3URI returns the bucket and key of an s3://bucket/key URI.
+func ParseS3URI(uri string) (string, string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "parsing url: %s", uri)
+	}
+	key := strings.TrimPrefix(parsed.Path, "/")
+	if parsed.Scheme != "s3" || parsed.Host == "" || key == "" {
+		return "", "", fmt.Errorf("invalid S3 URI %s, expected s3://bucket/key", uri)
+	}
+	return parsed.Host, key, nil
+}
+
+func openS3Object(ctx context.Context, uri string) (io.ReadCloser, error) {
+	bucket, key, err := ParseS3URI(uri)
+	if err != nil {
+		return nil, err
+	}
+	if s3ClientProvider == nil {
+		return nil, fmt.Errorf("s3:// URIs are not supported by this command: %s", uri)
+	}
+	client, err := s3ClientProvider(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating S3 client to read %s", uri)
+	}
+	object, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed reading file from url: %s", uri)
+	}
+	return limitBandwidth(ctx, object.Body), nil
+}
@@ -0,0 +1,87 @@
+package util
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+// newS3CompatibleServer serves objects like an S3-compatible server with path style
+// addressing, refusing requests that aren't signed with SigV4.
+func newS3CompatibleServer(t *testing.T, objects map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`))
+			return
+		}
+		content, ok := objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func setTestS3Client(t *testing.T, endpoint, accessKey string) {
+	client := s3.New(s3.Options{
+		Region:       "us-west-2",
+		BaseEndpoint: aws.String(endpoint),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider(accessKey, "secret", ""),
+	})
+	SetS3ClientProvider(func(context.Context) (S3Client, error) { return client, nil })
+	t.Cleanup(func() { SetS3ClientProvider(nil) })
+}
+
+func TestGetHttpFileS3(t *testing.T) {
+	server := newS3CompatibleServer(t, map[string]string{
+		"/private-bucket/eks/1.31/manifest.yaml": "manifest content",
+	})
+	setTestS3Client(t, server.URL, "AKIDEXAMPLE")
+
+	data, err := GetHttpFile(context.Background(), "s3://private-bucket/eks/1.31/manifest.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "manifest content", string(data))
+
+	_, err = GetHttpFile(context.Background(), "s3://private-bucket/eks/1.31/missing.yaml")
+	assert.ErrorContains(t, err, "NoSuchKey")
+}
+
+func TestGetHttpFileS3WrongCredentials(t *testing.T) {
+	server := newS3CompatibleServer(t, map[string]string{
+		"/private-bucket/eks/kubelet": "kubelet binary",
+	})
+	setTestS3Client(t, server.URL, "AKIDOTHER")
+
+	_, err := GetHttpFile(context.Background(), "s3://private-bucket/eks/kubelet")
+	assert.ErrorContains(t, err, "AccessDenied")
+}
+
+func TestGetHttpFileS3NotConfigured(t *testing.T) {
+	_, err := GetHttpFile(context.Background(), "s3://private-bucket/eks/kubelet")
+	assert.ErrorContains(t, err, "s3:// URIs are not supported")
+}
+
+func TestParseS3URI(t *testing.T) {
+	bucket, key, err := ParseS3URI("s3://private-bucket/eks/1.31/kubelet")
+	assert.NoError(t, err)
+	assert.Equal(t, "private-bucket", bucket)
+	assert.Equal(t, "eks/1.31/kubelet", key)
+
+	for _, uri := range []string{"s3://private-bucket", "s3:///kubelet", "https://private-bucket/kubelet"} {
+		_, _, err := ParseS3URI(uri)
+		assert.ErrorContains(t, err, "expected s3://bucket/key", uri)
+	}
+}